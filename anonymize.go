@@ -0,0 +1,120 @@
+package log
+
+import (
+	"net"
+	"strings"
+
+	"go.uber.org/zap/zapcore"
+)
+
+var (
+	ipv4Mask = net.CIDRMask(24, 32)
+	ipv6Mask = net.CIDRMask(48, 128)
+)
+
+// isIPRune reports whether c may be part of a textual IP address.
+func isIPRune(c rune) bool {
+	return c == '.' || c == ':' ||
+		('0' <= c && c <= '9') || ('a' <= c && c <= 'f') || ('A' <= c && c <= 'F')
+}
+
+// isWordRune reports whether c, next to an IP-looking token, makes it part of
+// a larger word such as "v1.2.3.4" or "1.2.3.4-rc1" rather than an address.
+func isWordRune(c byte) bool {
+	return c == '-' || c == '_' || c == '+' ||
+		('0' <= c && c <= '9') || ('a' <= c && c <= 'z') || ('A' <= c && c <= 'Z')
+}
+
+// anonymizeIPs truncates the IP addresses in s, e.g. in
+// "/ip4/192.0.2.42/tcp/4001" or "192.0.2.42:4001", to their network. It
+// reports whether any was found.
+//
+// Dotted numbers attached to a word, like "v1.2.3.4", are left alone, but a
+// free-standing "1.2.3.4" can't be told apart from an address and is
+// truncated.
+func anonymizeIPs(s string) (string, bool) {
+	var sb strings.Builder
+	changed := false
+	for i := 0; i < len(s); {
+		if !isIPRune(rune(s[i])) {
+			sb.WriteByte(s[i])
+			i++
+			continue
+		}
+		j := i
+		for j < len(s) && isIPRune(rune(s[j])) {
+			j++
+		}
+		token := s[i:j]
+		inWord := (i > 0 && isWordRune(s[i-1])) || (j < len(s) && isWordRune(s[j]))
+		if anon, ok := anonymizeIP(token); ok && !inWord {
+			token = anon
+			changed = true
+		}
+		sb.WriteString(token)
+		i = j
+	}
+	if !changed {
+		return s, false
+	}
+	return sb.String(), true
+}
+
+// anonymizeIP truncates token if it is an IP address, optionally followed by
+// a port.
+func anonymizeIP(token string) (string, bool) {
+	if !strings.ContainsAny(token, ".:") {
+		return token, false
+	}
+	host, port := token, ""
+	if net.ParseIP(token) == nil {
+		// IPv6 addresses with a port are bracketed, so this is IPv4.
+		var err error
+		if host, port, err = net.SplitHostPort(token); err != nil {
+			return token, false
+		}
+	}
+	ip := net.ParseIP(host)
+	if ip == nil {
+		return token, false
+	}
+	if ip4 := ip.To4(); ip4 != nil && !strings.Contains(host, ":") {
+		host = ip4.Mask(ipv4Mask).String()
+	} else {
+		host = ip.Mask(ipv6Mask).String()
+	}
+	if port != "" {
+		return net.JoinHostPort(host, port), true
+	}
+	return host, true
+}
+
+// anonymizedArray anonymizes the IP addresses in the strings of an array,
+// e.g. of zap.Strings or zap.Stringers fields.
+type anonymizedArray struct {
+	zapcore.ArrayMarshaler
+}
+
+func (a anonymizedArray) MarshalLogArray(enc zapcore.ArrayEncoder) error {
+	return a.ArrayMarshaler.MarshalLogArray(anonymizingArrayEncoder{enc})
+}
+
+type anonymizingArrayEncoder struct {
+	zapcore.ArrayEncoder
+}
+
+func (enc anonymizingArrayEncoder) AppendString(s string) {
+	s, _ = anonymizeIPs(s)
+	enc.ArrayEncoder.AppendString(s)
+}
+
+func (enc anonymizingArrayEncoder) AppendByteString(b []byte) {
+	if s, ok := anonymizeIPs(string(b)); ok {
+		b = []byte(s)
+	}
+	enc.ArrayEncoder.AppendByteString(b)
+}
+
+func (enc anonymizingArrayEncoder) AppendArray(arr zapcore.ArrayMarshaler) error {
+	return enc.ArrayEncoder.AppendArray(anonymizedArray{arr})
+}
@@ -0,0 +1,42 @@
+package log
+
+import (
+	"testing"
+
+	"go.uber.org/zap/zapcore"
+	"go.uber.org/zap/zaptest/observer"
+)
+
+func TestAnonymizeIPs(t *testing.T) {
+	for in, want := range map[string]string{
+		"/ip4/192.0.2.42/tcp/4001":              "/ip4/192.0.2.0/tcp/4001",
+		"/ip6/2001:db8:1:2::42/udp/4001/quic":   "/ip6/2001:db8:1::/udp/4001/quic",
+		"dialing 198.51.100.7 and 203.0.113.9":  "dialing 198.51.100.0 and 203.0.113.0",
+		"cafe at 12:30:45, v1.2.3":              "cafe at 12:30:45, v1.2.3",
+		"bafybeigdyrzt5sfp7udm7hu76uh7y26nf3ef": "bafybeigdyrzt5sfp7udm7hu76uh7y26nf3ef",
+		"dialing 198.51.100.7:4001":             "dialing 198.51.100.0:4001",
+		"dialing [2001:db8:1:2::42]:4001":       "dialing [2001:db8:1::]:4001",
+		"go-log v1.2.3.4":                       "go-log v1.2.3.4",
+		"release 1.2.3.4-rc1":                   "release 1.2.3.4-rc1",
+		"go1.21.3.4":                            "go1.21.3.4",
+	} {
+		if got, _ := anonymizeIPs(in); got != want {
+			t.Errorf("got %q for %q, want %q", got, in, want)
+		}
+	}
+
+	reg := NewRegistry(Config{Level: LevelInfo, AnonymizeIPs: true})
+	core, logs := observer.New(zapcore.DebugLevel)
+	reg.SetPrimaryCore(core)
+	reg.Logger("test").Infow("scooby", "addr", "/ip4/192.0.2.42/tcp/4001", "snacks", 2,
+		"peers", []string{"/ip4/192.0.2.42/tcp/4001", "198.51.100.7:4001"})
+
+	fields := logs.All()[0].ContextMap()
+	if fields["addr"] != "/ip4/192.0.2.0/tcp/4001" || fields["snacks"] != int64(2) {
+		t.Errorf("got fields %v", fields)
+	}
+	if peers, _ := fields["peers"].([]interface{}); len(peers) != 2 ||
+		peers[0] != "/ip4/192.0.2.0/tcp/4001" || peers[1] != "198.51.100.0:4001" {
+		t.Errorf("got peers %v", fields["peers"])
+	}
+}
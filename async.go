@@ -0,0 +1,141 @@
+package log
+
+import (
+	"sync"
+
+	"go.uber.org/zap/buffer"
+	"go.uber.org/zap/zapcore"
+)
+
+// AsyncPolicy decides what happens to entries logged while the queue of
+// asynchronous outputs is full. See Config.AsyncQueueSize.
+type AsyncPolicy int
+
+const (
+	// AsyncBlock makes logging calls wait for room in the queue, so that no
+	// entries are lost.
+	AsyncBlock AsyncPolicy = iota
+	// AsyncDrop discards entries while the queue is full. They are counted
+	// in Metrics.Dropped.
+	AsyncDrop
+)
+
+// Flush waits until the entries queued for asynchronous outputs are written
+// and syncs all outputs.
+func Flush() error {
+	return defaultRegistry.Flush()
+}
+
+// Flush flushes the outputs of this registry. See the package-level Flush.
+func (r *Registry) Flush() error {
+	return r.loggerCore.Sync()
+}
+
+// openAsync makes writes to ws asynchronous if cfg asks for it. The returned
+// function stops the writer, after writing the queued entries, before
+// calling closeOutputs.
+func openAsync(ws zapcore.WriteSyncer, closeOutputs func(), cfg Config, m *metrics) (zapcore.WriteSyncer, func()) {
+	if cfg.AsyncQueueSize <= 0 {
+		return ws, closeOutputs
+	}
+	async := newAsyncWriteSyncer(ws, cfg.AsyncQueueSize, cfg.AsyncPolicy, m)
+	return async, func() {
+		async.close()
+		closeOutputs()
+	}
+}
+
+// asyncRecord is an encoded entry, or a request to be notified once all
+// previous entries were written.
+type asyncRecord struct {
+	buf     *buffer.Buffer
+	flushed chan struct{}
+}
+
+// asyncWriteSyncer queues writes in a bounded channel drained by a
+// background goroutine, so that slow outputs don't hold up logging calls.
+type asyncWriteSyncer struct {
+	ws     zapcore.WriteSyncer
+	policy AsyncPolicy
+	m      *metrics
+
+	mu     sync.RWMutex // guards closed, held for reading while sending
+	closed bool
+	queue  chan asyncRecord
+	done   chan struct{}
+}
+
+func newAsyncWriteSyncer(ws zapcore.WriteSyncer, size int, policy AsyncPolicy, m *metrics) *asyncWriteSyncer {
+	w := &asyncWriteSyncer{
+		ws:     ws,
+		policy: policy,
+		m:      m,
+		queue:  make(chan asyncRecord, size),
+		done:   make(chan struct{}),
+	}
+	go w.run()
+	return w
+}
+
+func (w *asyncWriteSyncer) run() {
+	defer close(w.done)
+	for rec := range w.queue {
+		if rec.flushed != nil {
+			close(rec.flushed)
+			continue
+		}
+		// Errors are counted by the countingWriteSyncer below.
+		_, _ = w.ws.Write(rec.buf.Bytes())
+		rec.buf.Free()
+	}
+}
+
+// Write queues a copy of p, as zap reuses its buffers.
+func (w *asyncWriteSyncer) Write(p []byte) (int, error) {
+	w.mu.RLock()
+	defer w.mu.RUnlock()
+
+	if w.closed {
+		w.m.addDropped(1)
+		return len(p), nil
+	}
+	rec := asyncRecord{buf: bufferPool.Get()}
+	_, _ = rec.buf.Write(p)
+	if w.policy == AsyncDrop {
+		select {
+		case w.queue <- rec:
+		default:
+			rec.buf.Free()
+			w.m.addDropped(1)
+		}
+	} else {
+		w.queue <- rec
+	}
+	return len(p), nil
+}
+
+// Sync waits for the queued entries to be written, then syncs the output.
+func (w *asyncWriteSyncer) Sync() error {
+	w.mu.RLock()
+	if !w.closed {
+		flushed := make(chan struct{})
+		w.queue <- asyncRecord{flushed: flushed}
+		w.mu.RUnlock()
+		<-flushed
+	} else {
+		w.mu.RUnlock()
+	}
+	return w.ws.Sync()
+}
+
+// close writes the queued entries and stops the background goroutine.
+// Later writes are dropped.
+func (w *asyncWriteSyncer) close() {
+	w.mu.Lock()
+	if !w.closed {
+		w.closed = true
+		close(w.queue)
+	}
+	w.mu.Unlock()
+	<-w.done
+}
@@ -0,0 +1,99 @@
+package log
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestAsyncOutput(t *testing.T) {
+	logfile := filepath.Join(t.TempDir(), "go-log-test")
+	reg := NewRegistry(Config{
+		Format:         PlaintextOutput,
+		Level:          LevelInfo,
+		File:           logfile,
+		AsyncQueueSize: 16,
+	})
+	log := reg.Logger("test")
+
+	for i := 0; i < 100; i++ {
+		log.Infow("scooby", "i", i)
+	}
+	if err := reg.Flush(); err != nil {
+		t.Fatal(err)
+	}
+	b, err := os.ReadFile(logfile)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if n := strings.Count(string(b), "scooby"); n != 100 {
+		t.Errorf("got %d entries after Flush, want 100", n)
+	}
+	if m := reg.GetMetrics(); m.Dropped != 0 {
+		t.Errorf("got %d dropped entries, want 0", m.Dropped)
+	}
+
+	log.Info("doo")
+	if err := reg.Close(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	if b, _ := os.ReadFile(logfile); !strings.Contains(string(b), "doo") {
+		t.Error("expected Close to write the queued entries")
+	}
+}
+
+// blockingWriteSyncer blocks writes until release is closed.
+type blockingWriteSyncer struct {
+	release chan struct{}
+}
+
+func (w *blockingWriteSyncer) Write(p []byte) (int, error) {
+	<-w.release
+	return len(p), nil
+}
+
+func (w *blockingWriteSyncer) Sync() error {
+	return nil
+}
+
+func TestAsyncDropPolicy(t *testing.T) {
+	m := &metrics{}
+	out := &blockingWriteSyncer{release: make(chan struct{})}
+	w := newAsyncWriteSyncer(out, 2, AsyncDrop, m)
+
+	// One entry is held by the background goroutine, two are queued. The
+	// others must not block.
+	for i := 0; i < 10; i++ {
+		if _, err := w.Write([]byte("scooby\n")); err != nil {
+			t.Fatal(err)
+		}
+	}
+	close(out.release)
+	if err := w.Sync(); err != nil {
+		t.Fatal(err)
+	}
+	w.close()
+
+	if d := m.snapshot().Dropped; d < 7 || d > 8 {
+		t.Errorf("got %d dropped entries, want 7 or 8", d)
+	}
+}
+
+func TestConfigFromEnvAsync(t *testing.T) {
+	t.Setenv(envAsync, "4096")
+	t.Setenv(envAsyncPolicy, "drop")
+	cfg, err := ConfigFromEnv()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if cfg.AsyncQueueSize != 4096 || cfg.AsyncPolicy != AsyncDrop {
+		t.Errorf("got queue size %d and policy %d", cfg.AsyncQueueSize, cfg.AsyncPolicy)
+	}
+
+	t.Setenv(envAsyncPolicy, "later")
+	if _, err := ConfigFromEnv(); err == nil {
+		t.Error("expected an error for an unknown policy")
+	}
+}
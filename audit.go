@@ -0,0 +1,236 @@
+package log
+
+import (
+	"bufio"
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"sync"
+
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+)
+
+// ErrNoAuditOutput is reported when an audit logger is used while no audit
+// file is configured.
+var ErrNoAuditOutput = errors.New("no audit output configured")
+
+// AuditLogger returns a logger for security-relevant events, such as key
+// usage or configuration changes. Its entries, at any level, only go to the
+// audit file configured with Config.AuditFile or GOLOG_AUDIT_FILE.
+//
+// Each audit record is a JSON line carrying the hash of the previous record
+// ("prev") and its own hash ("hash"), and the file is synced after every
+// record. VerifyAuditLog checks that the records were not edited, removed or
+// inserted in place; see there for what it can't detect.
+func AuditLogger(subsystem string) *ZapEventLogger {
+	return defaultRegistry.AuditLogger(subsystem)
+}
+
+// AuditLogger returns an audit logger writing to the audit file of this
+// registry. See the package-level AuditLogger.
+func (r *Registry) AuditLogger(subsystem string) *ZapEventLogger {
+	logger := zap.New(&auditCore{sink: r.audit}, zap.AddCaller(), zap.WithClock(r.clock)).Named(subsystem).Sugar()
+	return &ZapEventLogger{
+		reg:           r,
+		system:        subsystem,
+		SugaredLogger: *logger,
+		skipLogger:    *logger.Desugar().WithOptions(zap.AddCallerSkip(1)).Sugar(),
+	}
+}
+
+// auditSink is the hash-chained audit file of a registry.
+type auditSink struct {
+	mu   sync.Mutex // guards the fields below
+	path string
+	f    *os.File
+	prev string // hex hash of the last record
+}
+
+// setFile switches to the audit file at path, continuing its chain. An
+// empty path closes the current file.
+func (s *auditSink) setFile(path string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if path == s.path && (path == "" || s.f != nil) {
+		return nil
+	}
+
+	var f *os.File
+	var prev string
+	if path != "" {
+		var err error
+		f, err = os.OpenFile(path, os.O_RDWR|os.O_APPEND|os.O_CREATE, 0o600)
+		if err != nil {
+			return fmt.Errorf("failed to open audit file: %w", err)
+		}
+		if prev, err = lastAuditHash(f); err != nil {
+			f.Close() // nolint:errcheck
+			return fmt.Errorf("failed to read audit file %q: %w", path, err)
+		}
+	}
+
+	if s.f != nil {
+		s.f.Close() // nolint:errcheck
+	}
+	s.path, s.f, s.prev = path, f, prev
+	return nil
+}
+
+func (s *auditSink) close() error {
+	return s.setFile("")
+}
+
+// write appends a record, encoded as a JSON object, to the chain.
+func (s *auditSink) write(enc zapcore.Encoder, ent zapcore.Entry, fields []zapcore.Field) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.f == nil {
+		return ErrNoAuditOutput
+	}
+
+	buf, err := enc.EncodeEntry(ent, append(fields, zap.String("prev", s.prev)))
+	if err != nil {
+		return err
+	}
+	defer buf.Free()
+
+	record, hash := chainAuditRecord(bytes.TrimRight(buf.Bytes(), "\n"))
+	if _, err := s.f.Write(record); err != nil {
+		return err
+	}
+	if err := s.f.Sync(); err != nil {
+		return err
+	}
+	s.prev = hash
+	return nil
+}
+
+// chainAuditRecord hashes a JSON object and returns it with the hash added
+// as its last member, followed by a newline.
+func chainAuditRecord(obj []byte) ([]byte, string) {
+	sum := sha256.Sum256(obj)
+	hash := hex.EncodeToString(sum[:])
+
+	record := make([]byte, 0, len(obj)+len(hash)+12)
+	record = append(record, obj[:len(obj)-1]...)
+	record = append(record, `,"hash":"`...)
+	record = append(record, hash...)
+	record = append(record, "\"}\n"...)
+	return record, hash
+}
+
+// unchainAuditRecord splits a record written by chainAuditRecord into the
+// hashed object and its hash.
+func unchainAuditRecord(record []byte) ([]byte, string, error) {
+	i := bytes.LastIndex(record, []byte(`,"hash":"`))
+	if i < 0 || !bytes.HasSuffix(record, []byte(`"}`)) {
+		return nil, "", errors.New("missing hash")
+	}
+	hash := string(record[i+len(`,"hash":"`) : len(record)-2])
+	obj := append(append([]byte(nil), record[:i]...), '}')
+	return obj, hash, nil
+}
+
+func lastAuditHash(r io.Reader) (string, error) {
+	var last []byte
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(nil, 1<<20)
+	for scanner.Scan() {
+		if len(scanner.Bytes()) > 0 {
+			last = append(last[:0], scanner.Bytes()...)
+		}
+	}
+	if err := scanner.Err(); err != nil || last == nil {
+		return "", err
+	}
+	_, hash, err := unchainAuditRecord(last)
+	return hash, err
+}
+
+// VerifyAuditLog checks the hash chain of an audit file written by audit
+// loggers, returning an error describing the first broken record if a
+// record was modified, or records were removed or inserted before the last
+// one.
+//
+// The hashes are not keyed, so the chain only reveals edits of records that
+// left the later ones in place: removing the last records, or rewriting the
+// chain from a modified record on, verifies cleanly. To detect those, keep
+// the hash of the last record, or the number of records, somewhere the
+// writer of the file can't change, and compare it to the file.
+func VerifyAuditLog(r io.Reader) error {
+	prev := ""
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(nil, 1<<20)
+	for n := 1; scanner.Scan(); n++ {
+		if len(scanner.Bytes()) == 0 {
+			continue
+		}
+		obj, hash, err := unchainAuditRecord(scanner.Bytes())
+		if err != nil {
+			return fmt.Errorf("audit record %d: %w", n, err)
+		}
+		var rec struct {
+			Prev string `json:"prev"`
+		}
+		if err := json.Unmarshal(obj, &rec); err != nil {
+			return fmt.Errorf("audit record %d: %w", n, err)
+		}
+		if rec.Prev != prev {
+			return fmt.Errorf("audit record %d: chain broken, previous record hash mismatch", n)
+		}
+		if sum := sha256.Sum256(obj); hex.EncodeToString(sum[:]) != hash {
+			return fmt.Errorf("audit record %d: hash mismatch", n)
+		}
+		prev = hash
+	}
+	return scanner.Err()
+}
+
+// auditCore writes all entries to an audit sink.
+type auditCore struct {
+	sink   *auditSink
+	fields []zapcore.Field
+}
+
+var _ zapcore.Core = (*auditCore)(nil)
+
+var auditEncoderConfig = func() zapcore.EncoderConfig {
+	encCfg := zap.NewProductionEncoderConfig()
+	encCfg.EncodeTime = zapcore.ISO8601TimeEncoder
+	encCfg.EncodeLevel = lowercaseLevelEncoder
+	encCfg.LineEnding = "\n"
+	return encCfg
+}()
+
+func (c *auditCore) Enabled(zapcore.Level) bool {
+	return true
+}
+
+func (c *auditCore) With(fields []zapcore.Field) zapcore.Core {
+	return &auditCore{
+		sink:   c.sink,
+		fields: append(append([]zapcore.Field(nil), c.fields...), fields...),
+	}
+}
+
+func (c *auditCore) Check(ent zapcore.Entry, ce *zapcore.CheckedEntry) *zapcore.CheckedEntry {
+	return ce.AddCore(ent, c)
+}
+
+func (c *auditCore) Write(ent zapcore.Entry, fields []zapcore.Field) error {
+	all := make([]zapcore.Field, 0, len(c.fields)+len(fields)+1)
+	all = append(append(all, c.fields...), fields...)
+	return c.sink.write(zapcore.NewJSONEncoder(auditEncoderConfig), ent, all)
+}
+
+func (c *auditCore) Sync() error {
+	return nil
+}
@@ -0,0 +1,59 @@
+package log
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"go.uber.org/zap/zapcore"
+)
+
+func TestAuditLogger(t *testing.T) {
+	skipWithoutDebug(t)
+
+	path := filepath.Join(t.TempDir(), "audit.log")
+	reg := NewRegistry(Config{Level: LevelError, AuditFile: path})
+
+	audit := reg.AuditLogger("keystore")
+	audit.Infow("key used", "key", "self")
+	audit.With("peer", "scooby").Debug("config changed")
+
+	// The chain continues across restarts.
+	if err := reg.Close(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	reg = NewRegistry(Config{Level: LevelError, AuditFile: path})
+	reg.AuditLogger("keystore").Warn("key deleted")
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if lines := strings.Split(strings.TrimSpace(string(data)), "\n"); len(lines) != 3 {
+		t.Fatalf("got %q, want 3 records", lines)
+	}
+	if err := VerifyAuditLog(bytes.NewReader(data)); err != nil {
+		t.Errorf("unexpected error: %v", err)
+	}
+
+	tampered := bytes.Replace(data, []byte(`"key":"self"`), []byte(`"key":"other"`), 1)
+	if err := VerifyAuditLog(bytes.NewReader(tampered)); err == nil {
+		t.Error("expected an error for a modified record")
+	}
+	removed := data[bytes.IndexByte(data, '\n')+1:]
+	if err := VerifyAuditLog(bytes.NewReader(removed)); err == nil {
+		t.Error("expected an error for a removed record")
+	}
+}
+
+func TestAuditLoggerWithoutFile(t *testing.T) {
+	reg := NewRegistry(Config{Level: LevelError})
+	err := (&auditCore{sink: reg.audit}).Write(zapcore.Entry{Message: "scooby"}, nil)
+	if !errors.Is(err, ErrNoAuditOutput) {
+		t.Errorf("got %v, want ErrNoAuditOutput", err)
+	}
+}
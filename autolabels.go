@@ -0,0 +1,106 @@
+package log
+
+import (
+	"fmt"
+	"os"
+	"runtime/debug"
+	"strconv"
+	"time"
+
+	"go.uber.org/multierr"
+)
+
+// bootTime approximates the start of the process.
+var bootTime = time.Now()
+
+// autoLabels compute the built-in labels that AutoLabels accepts.
+var autoLabels = map[string]func() (string, error){
+	"host": os.Hostname,
+	"pid": func() (string, error) {
+		return strconv.Itoa(os.Getpid()), nil
+	},
+	"version": func() (string, error) {
+		info, err := readBuildInfo()
+		if err != nil {
+			return "", err
+		}
+		return info.Main.Version, nil
+	},
+	"vcs.revision": func() (string, error) {
+		info, err := readBuildInfo()
+		if err != nil {
+			return "", err
+		}
+		for _, setting := range info.Settings {
+			if setting.Key == "vcs.revision" {
+				return setting.Value, nil
+			}
+		}
+		return "", fmt.Errorf("no revision in the build information")
+	},
+	"go": func() (string, error) {
+		info, err := readBuildInfo()
+		if err != nil {
+			return "", err
+		}
+		return info.GoVersion, nil
+	},
+	"boot": func() (string, error) {
+		return bootTime.UTC().Format(time.RFC3339), nil
+	},
+}
+
+// autoLabelGroups are names AutoLabels accepts for several labels at once.
+// The labels of a group that can't be computed are left out silently.
+var autoLabelGroups = map[string][]string{
+	"build": {"version", "vcs.revision", "go"},
+}
+
+func readBuildInfo() (*debug.BuildInfo, error) {
+	info, ok := debug.ReadBuildInfo()
+	if !ok {
+		return nil, fmt.Errorf("no build information in the binary")
+	}
+	return info, nil
+}
+
+// AutoLabels returns the named built-in labels, to be added to
+// Config.Labels:
+//
+//   - host: the hostname
+//   - pid: the process ID
+//   - version: the version of the main module, from the build information
+//   - vcs.revision: the version control revision the binary was built from
+//   - go: the version of Go the binary was built with
+//   - boot: the time the process started, in RFC 3339 format
+//
+// "build" stands for version, vcs.revision and go, so that every entry
+// identifies the exact binary. They can also be enabled with
+// GOLOG_LOG_LABELS=auto:host,pid,build. Labels that can't be computed are
+// left out and reported in the error.
+func AutoLabels(names ...string) (map[string]string, error) {
+	labels := make(map[string]string, len(names))
+	var errs error
+	for _, name := range names {
+		if group, ok := autoLabelGroups[name]; ok {
+			for _, name := range group {
+				if v, err := autoLabels[name](); err == nil {
+					labels[name] = v
+				}
+			}
+			continue
+		}
+		label, ok := autoLabels[name]
+		if !ok {
+			errs = multierr.Append(errs, fmt.Errorf("unknown automatic label %q", name))
+			continue
+		}
+		v, err := label()
+		if err != nil {
+			errs = multierr.Append(errs, fmt.Errorf("automatic label %q: %w", name, err))
+			continue
+		}
+		labels[name] = v
+	}
+	return labels, errs
+}
@@ -0,0 +1,44 @@
+package log
+
+import (
+	"os"
+	"runtime"
+	"strconv"
+	"testing"
+)
+
+func TestAutoLabels(t *testing.T) {
+	labels, err := AutoLabels("pid", "boot", "nope")
+	if err == nil {
+		t.Error("expected an error for the unknown label")
+	}
+	if labels["pid"] != strconv.Itoa(os.Getpid()) || labels["boot"] == "" || len(labels) != 2 {
+		t.Errorf("got labels %v", labels)
+	}
+}
+
+func TestAutoLabelsFromEnv(t *testing.T) {
+	t.Setenv(envLoggingLabels, "app=example,auto:pid,boot,dc=sjc-1,pid=mine")
+
+	cfg, err := ConfigFromEnv()
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(cfg.Labels) != 4 || cfg.Labels["app"] != "example" || cfg.Labels["dc"] != "sjc-1" || cfg.Labels["boot"] == "" {
+		t.Errorf("got labels %v", cfg.Labels)
+	}
+	if cfg.Labels["pid"] != "mine" {
+		t.Errorf("got pid %q, want explicit labels to win", cfg.Labels["pid"])
+	}
+}
+
+func TestBuildLabels(t *testing.T) {
+	labels, err := AutoLabels("build")
+	if err != nil {
+		t.Fatal(err)
+	}
+	// Test binaries have no revision.
+	if labels["go"] != runtime.Version() || labels["version"] == "" {
+		t.Errorf("got labels %v", labels)
+	}
+}
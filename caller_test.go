@@ -0,0 +1,58 @@
+package log
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+
+	"go.uber.org/zap/zapcore"
+)
+
+func TestCallerFormat(t *testing.T) {
+	for _, tc := range []struct {
+		name string
+		cfg  Config
+		want string
+		not  string
+	}{
+		{name: "short", want: "/caller_test.go:", not: "\t/"},
+		{name: "full", cfg: Config{CallerFormat: CallerFull}, want: "\t/"},
+		{name: "function", cfg: Config{CallerFormat: CallerFunction}, want: "\tgithub.com/ipfs/go-log/v2.TestCallerFormat"},
+		{name: "disabled", cfg: Config{DisableCaller: true}, not: "caller_test.go"},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			tc.cfg.Format = PlaintextOutput
+			tc.cfg.Level = LevelInfo
+			reg := NewRegistry(tc.cfg)
+			var buf bytes.Buffer
+			reg.SetPrimaryCore(newConfiguredCore(tc.cfg, zapcore.AddSync(&buf), LevelDebug))
+
+			reg.Logger("test").Info("scooby")
+
+			got := buf.String()
+			if tc.want != "" && !strings.Contains(got, tc.want) {
+				t.Errorf("got %q, want it to contain %q", got, tc.want)
+			}
+			if tc.not != "" && strings.Contains(got, tc.not) {
+				t.Errorf("got %q, want it not to contain %q", got, tc.not)
+			}
+		})
+	}
+}
+
+func TestCallerFromEnv(t *testing.T) {
+	t.Setenv(envCaller, "false")
+	if cfg, err := ConfigFromEnv(); err != nil || !cfg.DisableCaller {
+		t.Errorf("got DisableCaller %v, %v, want it set", cfg.DisableCaller, err)
+	}
+
+	t.Setenv(envCaller, "function")
+	if cfg, err := ConfigFromEnv(); err != nil || cfg.DisableCaller || cfg.CallerFormat != CallerFunction {
+		t.Errorf("got %v, %v, %v, want the function format", cfg.DisableCaller, cfg.CallerFormat, err)
+	}
+
+	t.Setenv(envCaller, "fancy")
+	if _, err := ConfigFromEnv(); err == nil {
+		t.Error("expected an error for an unknown caller format")
+	}
+}
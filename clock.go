@@ -0,0 +1,57 @@
+package log
+
+import (
+	"sync/atomic"
+	"time"
+
+	"go.uber.org/zap/zapcore"
+)
+
+// SetClock makes the package-level loggers timestamp their entries with
+// clock instead of the system clock, e.g. for stable timestamps in tests
+// and examples. A nil clock restores the system clock. SetupLogging
+// replaces it with Config.Clock.
+func SetClock(clock zapcore.Clock) {
+	defaultRegistry.SetClock(clock)
+}
+
+// SetClock changes the clock of the loggers of this registry. See the
+// package-level SetClock.
+func (r *Registry) SetClock(clock zapcore.Clock) {
+	r.clock.store(clock)
+}
+
+// registryClock is the clock of the loggers of a registry, which forwards
+// to a clock that can be changed at any time.
+type registryClock struct {
+	v atomic.Value // holds a clockHolder
+}
+
+// clockHolder gives the values stored in registryClock.v the same type.
+type clockHolder struct {
+	zapcore.Clock
+}
+
+var _ zapcore.Clock = (*registryClock)(nil)
+
+func (c *registryClock) store(clock zapcore.Clock) {
+	if clock == nil {
+		clock = zapcore.DefaultClock
+	}
+	c.v.Store(clockHolder{clock})
+}
+
+func (c *registryClock) load() zapcore.Clock {
+	if h, ok := c.v.Load().(clockHolder); ok {
+		return h.Clock
+	}
+	return zapcore.DefaultClock
+}
+
+func (c *registryClock) Now() time.Time {
+	return c.load().Now()
+}
+
+func (c *registryClock) NewTicker(d time.Duration) *time.Ticker {
+	return c.load().NewTicker(d)
+}
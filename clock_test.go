@@ -0,0 +1,60 @@
+package log
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+	"time"
+
+	"go.uber.org/zap/zapcore"
+)
+
+// fixedClock always returns the same time.
+type fixedClock time.Time
+
+func (c fixedClock) Now() time.Time {
+	return time.Time(c)
+}
+
+func (c fixedClock) NewTicker(d time.Duration) *time.Ticker {
+	return time.NewTicker(d)
+}
+
+var scoobyTime = time.Date(2010, 5, 23, 15, 14, 0, 0, time.UTC)
+
+func TestClock(t *testing.T) {
+	reg := NewRegistry(Config{Level: LevelInfo, Clock: fixedClock(scoobyTime)})
+	var buf bytes.Buffer
+	reg.SetPrimaryCore(newCore(PlaintextOutput, zapcore.AddSync(&buf), LevelInfo))
+	log := reg.Logger("core")
+
+	log.Info("scooby")
+	reg.SetClock(fixedClock(scoobyTime.Add(time.Hour)))
+	log.Info("doo")
+	reg.SetClock(nil)
+	log.Info("velma")
+
+	lines := strings.Split(strings.TrimSpace(buf.String()), "\n")
+	if len(lines) != 3 {
+		t.Fatalf("got %q, want 3 lines", lines)
+	}
+	if !strings.HasPrefix(lines[0], "2010-05-23T15:14:00.000Z\t") || !strings.HasPrefix(lines[1], "2010-05-23T16:14:00.000Z\t") {
+		t.Errorf("got %q with fixed clocks", lines[:2])
+	}
+	if strings.HasPrefix(lines[2], "2010-") {
+		t.Errorf("got %q with the system clock", lines[2])
+	}
+}
+
+func ExampleRegistry_SetClock() {
+	reg := NewRegistry(Config{
+		Format:        PlaintextOutput,
+		Stdout:        true,
+		Level:         LevelInfo,
+		DisableCaller: true,
+	})
+	reg.SetClock(fixedClock(scoobyTime))
+	reg.Logger("example").Infow("block fetched", "size", 42)
+	// Output:
+	// 2010-05-23T15:14:00.000Z	INFO	example	block fetched	{"size": 42}
+}
@@ -0,0 +1,75 @@
+package log
+
+import (
+	"context"
+	"io"
+
+	"go.uber.org/multierr"
+)
+
+// Close flushes all pending entries and releases the resources held by the
+// logging system: it syncs all cores, closes all PipeReaders and
+// EntryReaders and closes the outputs opened by SetupLogging. Errors are
+// aggregated (see go.uber.org/multierr).
+//
+// Close is meant to be called right before the process exits, to make sure
+// the last entries reach disk or the network. Entries logged afterwards may
+// be lost. If ctx expires before everything was flushed, Close returns
+// ctx.Err() without waiting any longer.
+func Close(ctx context.Context) error {
+	return defaultRegistry.Close(ctx)
+}
+
+// Close flushes and releases the outputs of this registry. See the
+// package-level Close.
+func (r *Registry) Close(ctx context.Context) error {
+	done := make(chan error, 1)
+	go func() {
+		done <- r.close()
+	}()
+
+	select {
+	case err := <-done:
+		return err
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+func (r *Registry) close() error {
+	err := r.loggerCore.Sync()
+
+	r.mu.Lock()
+	pipes := make([]io.Closer, 0, len(r.pipes))
+	for p := range r.pipes {
+		pipes = append(pipes, p)
+	}
+	r.mu.Unlock()
+
+	for _, p := range pipes {
+		err = multierr.Append(err, p.Close())
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.setCloseOutputs(nil)
+	r.outputs, r.std = nil, nil
+	err = multierr.Append(err, r.audit.close())
+	err = multierr.Append(err, r.events.close())
+
+	return err
+}
+
+func (r *Registry) trackPipe(p io.Closer) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.pipes[p] = struct{}{}
+}
+
+func (r *Registry) untrackPipe(p io.Closer) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	delete(r.pipes, p)
+}
@@ -0,0 +1,53 @@
+package log
+
+import (
+	"context"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestClose(t *testing.T) {
+	logfile := filepath.Join(t.TempDir(), "go-log-test")
+	reg := NewRegistry(Config{
+		Format: PlaintextOutput,
+		Level:  LevelInfo,
+		File:   logfile,
+	})
+	log := reg.Logger("test")
+
+	r := reg.NewPipeReader()
+	done := make(chan error, 1)
+	go func() {
+		_, err := io.Copy(io.Discard, r)
+		done <- err
+	}()
+
+	log.Info("scooby")
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	if err := reg.Close(ctx); err != nil {
+		t.Fatal(err)
+	}
+
+	select {
+	case err := <-done:
+		if err != nil {
+			t.Errorf("unexpected error: %v", err)
+		}
+	case <-ctx.Done():
+		t.Fatal("pipe reader was not closed")
+	}
+
+	content, err := os.ReadFile(logfile)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(string(content), "scooby") {
+		t.Errorf("got %q, wanted it to contain log output", content)
+	}
+}
@@ -0,0 +1,36 @@
+package log
+
+import (
+	"hash/fnv"
+	"sync"
+
+	"go.uber.org/zap/zapcore"
+)
+
+// subsystemColors are the ANSI foreground colors subsystem names are
+// rendered in, leaving out black and white which may be the background.
+var subsystemColors = []string{
+	"31", "32", "33", "34", "35", "36",
+	"91", "92", "93", "94", "95", "96",
+}
+
+// subsystemColor returns the escape sequence of the color of the named
+// subsystem, which is stable across runs.
+func subsystemColor(name string) string {
+	h := fnv.New32a()
+	_, _ = h.Write([]byte(name))
+	return "\x1b[" + subsystemColors[h.Sum32()%uint32(len(subsystemColors))] + "m"
+}
+
+// coloredNames caches the colored rendering of logger names.
+var coloredNames sync.Map // name -> string
+
+// colorNameEncoder renders logger names in the color of their subsystem, so
+// that the entries of interleaved subsystems are told apart at a glance.
+func colorNameEncoder(name string, enc zapcore.PrimitiveArrayEncoder) {
+	colored, ok := coloredNames.Load(name)
+	if !ok {
+		colored, _ = coloredNames.LoadOrStore(name, subsystemColor(name)+name+"\x1b[0m")
+	}
+	enc.AppendString(colored.(string))
+}
@@ -0,0 +1,35 @@
+package log
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+
+	"go.uber.org/zap/zapcore"
+)
+
+func TestSubsystemColors(t *testing.T) {
+	if subsystemColor("dht") != subsystemColor("dht") {
+		t.Fatal("subsystem colors aren't stable")
+	}
+
+	for _, tc := range []struct {
+		cfg   Config
+		color bool
+	}{
+		{cfg: Config{Format: ColorizedOutput, SubsystemColors: true}, color: true},
+		{cfg: Config{Format: PrettyOutput, SubsystemColors: true}, color: true},
+		{cfg: Config{Format: ColorizedOutput}},
+		{cfg: Config{Format: PlaintextOutput, SubsystemColors: true}},
+	} {
+		reg := NewRegistry(tc.cfg)
+		var buf bytes.Buffer
+		reg.SetPrimaryCore(newConfiguredCore(tc.cfg, zapcore.AddSync(&buf), LevelDebug))
+		reg.Logger("dht").Error("scooby")
+
+		colored := strings.Contains(buf.String(), subsystemColor("dht")+"dht\x1b[0m")
+		if colored != tc.color {
+			t.Errorf("got %q with %+v, want colored name: %v", buf.String(), tc.cfg, tc.color)
+		}
+	}
+}
@@ -0,0 +1,422 @@
+package log
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/fsnotify/fsnotify"
+	"gopkg.in/yaml.v3"
+)
+
+// configReloadDelay debounces bursts of file events, e.g. from editors
+// writing a file in several steps.
+const configReloadDelay = 100 * time.Millisecond
+
+// configFile is the schema of a logging configuration file. Settings that
+// are omitted keep the value of the base configuration.
+//
+// Example (JSON):
+//
+//	{
+//	  "format": "json",
+//	  "level": "info",
+//	  "subsystems": {"dht": "debug"},
+//	  "outputs": ["stderr", "file"],
+//	  "file": "/var/log/ipfs.log",
+//	  "labels": {"dc": "sjc-1"},
+//	  "profiles": {"verbose-dht": {"subsystems": {"dht": "debug"}}},
+//	  "profile": "verbose-dht",
+//	  "rules": [{"subsystem": "dht", "fields": {"peer": "QmScooby"}, "sink": "/var/log/scooby.log"}],
+//	  "files": [{"path": "/var/log/ipfs-errors.log", "level": "warn", "format": "json", "max_size": 10485760}]
+//	}
+type configFile struct {
+	Format     string            `json:"format" yaml:"format"`
+	Level      string            `json:"level" yaml:"level"`
+	Subsystems map[string]string `json:"subsystems" yaml:"subsystems"`
+	Outputs    []string          `json:"outputs" yaml:"outputs"`
+	File       string            `json:"file" yaml:"file"`
+	URL        string            `json:"url" yaml:"url"`
+	Fallback   string            `json:"fallback" yaml:"fallback"`
+	Labels     map[string]string `json:"labels" yaml:"labels"`
+
+	Profiles map[string]configProfile `json:"profiles" yaml:"profiles"`
+	Profile  string                   `json:"profile" yaml:"profile"`
+
+	// Rules and Files replace those of the base configuration.
+	Rules []configRule       `json:"rules" yaml:"rules"`
+	Files []configFileOutput `json:"files" yaml:"files"`
+}
+
+// configFileOutput is the schema of a FileOutput in a configuration file.
+type configFileOutput struct {
+	Path       string `json:"path" yaml:"path"`
+	Level      string `json:"level" yaml:"level"`
+	Format     string `json:"format" yaml:"format"`
+	MaxSize    int64  `json:"max_size" yaml:"max_size"`
+	MaxBackups int    `json:"max_backups" yaml:"max_backups"`
+	Retention  string `json:"retention" yaml:"retention"`
+
+	Compression     string `json:"compression" yaml:"compression"`
+	CompressBackups bool   `json:"compress_backups" yaml:"compress_backups"`
+
+	Mode       string `json:"mode" yaml:"mode"`
+	CreateDirs bool   `json:"create_dirs" yaml:"create_dirs"`
+
+	MaxTotalSize int64 `json:"max_total_size" yaml:"max_total_size"`
+	MinFreeSpace int64 `json:"min_free_space" yaml:"min_free_space"`
+}
+
+func (cf *configFileOutput) output() (FileOutput, error) {
+	out := FileOutput{
+		Path:            cf.Path,
+		MaxSize:         cf.MaxSize,
+		MaxBackups:      cf.MaxBackups,
+		CompressBackups: cf.CompressBackups,
+		CreateDirs:      cf.CreateDirs,
+		MaxTotalSize:    cf.MaxTotalSize,
+		MinFreeSpace:    cf.MinFreeSpace,
+	}
+	if cf.Path == "" {
+		return out, fmt.Errorf("missing path")
+	}
+	if cf.Retention != "" {
+		d, err := time.ParseDuration(cf.Retention)
+		if err != nil {
+			return out, fmt.Errorf("invalid retention %q: %w", cf.Retention, err)
+		}
+		out.Retention = d
+	}
+	compression, err := parseCompression(cf.Compression)
+	if err != nil {
+		return out, err
+	}
+	out.Compression = compression
+	if cf.Mode != "" {
+		mode, err := strconv.ParseUint(cf.Mode, 8, 32)
+		if err != nil || mode > 0o777 {
+			return out, fmt.Errorf("invalid mode %q", cf.Mode)
+		}
+		out.Mode = os.FileMode(mode)
+	}
+	if cf.Level != "" {
+		lvl, err := LevelFromString(cf.Level)
+		if err != nil {
+			return out, fmt.Errorf("invalid level %q: %w", cf.Level, err)
+		}
+		out.Level = &lvl
+	}
+	if cf.Format != "" {
+		format, err := parseLogFormat(cf.Format)
+		if err != nil {
+			return out, err
+		}
+		out.Format = &format
+	}
+	return out, nil
+}
+
+// configRule is the schema of a Rule in a configuration file.
+type configRule struct {
+	Subsystem string            `json:"subsystem" yaml:"subsystem"`
+	MinLevel  string            `json:"min_level" yaml:"min_level"`
+	MaxLevel  string            `json:"max_level" yaml:"max_level"`
+	Fields    map[string]string `json:"fields" yaml:"fields"`
+	SetLevel  string            `json:"set_level" yaml:"set_level"`
+	Labels    map[string]string `json:"labels" yaml:"labels"`
+	Drop      bool              `json:"drop" yaml:"drop"`
+	Sink      string            `json:"sink" yaml:"sink"`
+}
+
+func (cr *configRule) rule() (Rule, error) {
+	rule := Rule{
+		Subsystem: cr.Subsystem,
+		Fields:    cr.Fields,
+		Labels:    cr.Labels,
+		Drop:      cr.Drop,
+		Sink:      cr.Sink,
+	}
+	for _, l := range []struct {
+		name string
+		dst  **LogLevel
+	}{
+		{cr.MinLevel, &rule.MinLevel},
+		{cr.MaxLevel, &rule.MaxLevel},
+		{cr.SetLevel, &rule.SetLevel},
+	} {
+		if l.name == "" {
+			continue
+		}
+		lvl, err := LevelFromString(l.name)
+		if err != nil {
+			return rule, fmt.Errorf("invalid level %q: %w", l.name, err)
+		}
+		*l.dst = &lvl
+	}
+	return rule, nil
+}
+
+// configProfile is the schema of a level profile in a configuration file.
+type configProfile struct {
+	Level      string            `json:"level" yaml:"level"`
+	Subsystems map[string]string `json:"subsystems" yaml:"subsystems"`
+}
+
+func (cp *configProfile) profile() (LevelProfile, error) {
+	var profile LevelProfile
+	if cp.Level != "" {
+		lvl, err := LevelFromString(cp.Level)
+		if err != nil {
+			return profile, fmt.Errorf("invalid level %q: %w", cp.Level, err)
+		}
+		profile.Level = &lvl
+	}
+	profile.SubsystemLevels = make(map[string]LogLevel, len(cp.Subsystems))
+	for name, level := range cp.Subsystems {
+		lvl, err := LevelFromString(level)
+		if err != nil {
+			return profile, fmt.Errorf("invalid level %q for %s: %w", level, name, err)
+		}
+		profile.SubsystemLevels[name] = lvl
+	}
+	return profile, nil
+}
+
+// LoadConfigFile reads the logging configuration file at path and applies it
+// on top of base, which is not modified. Files with a .yaml or .yml extension
+// are parsed as YAML, anything else as JSON. See GOLOG_LOG_CONFIG for the
+// supported settings.
+func LoadConfigFile(path string, base Config) (Config, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return base, err
+	}
+
+	var cf configFile
+	switch strings.ToLower(filepath.Ext(path)) {
+	case ".yaml", ".yml":
+		dec := yaml.NewDecoder(bytes.NewReader(data))
+		dec.KnownFields(true)
+		err = dec.Decode(&cf)
+	default:
+		dec := json.NewDecoder(bytes.NewReader(data))
+		dec.DisallowUnknownFields()
+		err = dec.Decode(&cf)
+	}
+	if err != nil {
+		return base, fmt.Errorf("failed to parse %s: %w", path, err)
+	}
+
+	return cf.apply(base)
+}
+
+func (cf *configFile) apply(cfg Config) (Config, error) {
+	// Don't modify the maps of the caller's config.
+	subsystemLevels := make(map[string]LogLevel, len(cfg.SubsystemLevels)+len(cf.Subsystems))
+	for k, v := range cfg.SubsystemLevels {
+		subsystemLevels[k] = v
+	}
+	labels := make(map[string]string, len(cfg.Labels)+len(cf.Labels))
+	for k, v := range cfg.Labels {
+		labels[k] = v
+	}
+	cfg.SubsystemLevels = subsystemLevels
+	cfg.Labels = labels
+
+	if cf.Format != "" {
+		format, err := parseLogFormat(cf.Format)
+		if err != nil {
+			return cfg, err
+		}
+		cfg.Format = format
+	}
+	if cf.Level != "" {
+		lvl, err := LevelFromString(cf.Level)
+		if err != nil {
+			return cfg, fmt.Errorf("invalid level %q: %w", cf.Level, err)
+		}
+		cfg.Level = lvl
+	}
+	for name, level := range cf.Subsystems {
+		lvl, err := LevelFromString(level)
+		if err != nil {
+			return cfg, fmt.Errorf("invalid level %q for %s: %w", level, name, err)
+		}
+		cfg.SubsystemLevels[name] = lvl
+	}
+	if cf.File != "" {
+		cfg.File = cf.File
+	}
+	if cf.URL != "" {
+		cfg.URL = cf.URL
+	}
+	if cf.Fallback != "" {
+		cfg.Fallback = cf.Fallback
+	}
+	if cf.Outputs != nil {
+		cfg.Stderr, cfg.Stdout = false, false
+		file, url := cfg.File, cfg.URL
+		cfg.File, cfg.URL = "", ""
+		for _, out := range cf.Outputs {
+			switch out {
+			case "stderr":
+				cfg.Stderr = true
+			case "stdout":
+				cfg.Stdout = true
+			case "file":
+				if file == "" {
+					return cfg, fmt.Errorf("output %q requires a file", out)
+				}
+				cfg.File = file
+			case "url":
+				if url == "" {
+					return cfg, fmt.Errorf("output %q requires a url", out)
+				}
+				cfg.URL = url
+			default:
+				return cfg, fmt.Errorf("unrecognized log output %q", out)
+			}
+		}
+	}
+	for k, v := range cf.Labels {
+		cfg.Labels[k] = v
+	}
+	if len(cf.Profiles) > 0 {
+		profiles := make(map[string]LevelProfile, len(cfg.Profiles)+len(cf.Profiles))
+		for name, p := range cfg.Profiles {
+			profiles[name] = p
+		}
+		for name, cp := range cf.Profiles {
+			p, err := cp.profile()
+			if err != nil {
+				return cfg, fmt.Errorf("profile %q: %w", name, err)
+			}
+			profiles[name] = p
+		}
+		cfg.Profiles = profiles
+	}
+	if cf.Profile != "" {
+		cfg.Profile = cf.Profile
+	}
+	if cf.Rules != nil {
+		cfg.Rules = make([]Rule, len(cf.Rules))
+		for i := range cf.Rules {
+			rule, err := cf.Rules[i].rule()
+			if err != nil {
+				return cfg, fmt.Errorf("rule %d: %w", i, err)
+			}
+			cfg.Rules[i] = rule
+		}
+	}
+	if cf.Files != nil {
+		cfg.Files = make([]FileOutput, len(cf.Files))
+		for i := range cf.Files {
+			out, err := cf.Files[i].output()
+			if err != nil {
+				return cfg, fmt.Errorf("file %d: %w", i, err)
+			}
+			cfg.Files[i] = out
+		}
+	}
+	return cfg, nil
+}
+
+// WatchConfigFile applies the logging configuration file at path on top of
+// base, and re-applies it whenever the file changes until stop is called.
+// Errors while reloading are logged and leave the current configuration in
+// place.
+func WatchConfigFile(path string, base Config) (stop func() error, err error) {
+	return defaultRegistry.WatchConfigFile(path, base)
+}
+
+// WatchConfigFile applies and watches a configuration file for this
+// registry. See the package-level WatchConfigFile.
+func (r *Registry) WatchConfigFile(path string, base Config) (stop func() error, err error) {
+	path, err = filepath.Abs(path)
+	if err != nil {
+		return nil, err
+	}
+
+	reload := func() error {
+		cfg, err := LoadConfigFile(path, base)
+		if err != nil {
+			return err
+		}
+		return r.SetupLoggingWithError(cfg)
+	}
+	if err := reload(); err != nil {
+		return nil, err
+	}
+
+	r.mu.Lock()
+	r.reloadConfig = reload
+	r.mu.Unlock()
+
+	watcher, err := fsnotify.NewWatcher()
+	if err != nil {
+		return nil, err
+	}
+	// Watch the directory rather than the file, so that files replaced by
+	// renaming over them (as many editors do) keep being watched.
+	if err := watcher.Add(filepath.Dir(path)); err != nil {
+		watcher.Close() // nolint:errcheck
+		return nil, err
+	}
+
+	var (
+		wg    sync.WaitGroup
+		mu    sync.Mutex // guards timer
+		timer *time.Timer
+	)
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		for {
+			select {
+			case ev, ok := <-watcher.Events:
+				if !ok {
+					return
+				}
+				if filepath.Clean(ev.Name) != path || !ev.Has(fsnotify.Write|fsnotify.Create) {
+					continue
+				}
+				mu.Lock()
+				if timer != nil {
+					timer.Stop()
+				}
+				timer = time.AfterFunc(configReloadDelay, func() {
+					if err := reload(); err != nil {
+						r.getLogger("setup-logger").Errorw("failed to reload logging configuration", "path", path, "error", err)
+					}
+				})
+				mu.Unlock()
+			case err, ok := <-watcher.Errors:
+				if !ok {
+					return
+				}
+				r.getLogger("setup-logger").Errorw("failed to watch logging configuration", "path", path, "error", err)
+			}
+		}
+	}()
+
+	return func() error {
+		r.mu.Lock()
+		r.reloadConfig = nil
+		r.mu.Unlock()
+
+		err := watcher.Close()
+		wg.Wait()
+		mu.Lock()
+		if timer != nil {
+			timer.Stop()
+		}
+		mu.Unlock()
+		return err
+	}, nil
+}
@@ -0,0 +1,106 @@
+package log
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestLoadConfigFile(t *testing.T) {
+	dir := t.TempDir()
+	base := Config{
+		Format:          ColorizedOutput,
+		Level:           LevelError,
+		Stderr:          true,
+		SubsystemLevels: map[string]LogLevel{"test1": LevelInfo},
+	}
+
+	jsonPath := filepath.Join(dir, "config.json")
+	if err := os.WriteFile(jsonPath, []byte(`{
+		"format": "json",
+		"level": "warn",
+		"subsystems": {"test2": "debug"},
+		"outputs": ["stdout"],
+		"labels": {"dc": "sjc-1"}
+	}`), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	yamlPath := filepath.Join(dir, "config.yaml")
+	if err := os.WriteFile(yamlPath, []byte(`
+format: json
+level: warn
+subsystems:
+  test2: debug
+outputs: [stdout]
+labels:
+  dc: sjc-1
+`), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	for _, path := range []string{jsonPath, yamlPath} {
+		cfg, err := LoadConfigFile(path, base)
+		if err != nil {
+			t.Fatalf("%s: %v", path, err)
+		}
+		if cfg.Format != JSONOutput || cfg.Level != LevelWarn || cfg.Stderr || !cfg.Stdout {
+			t.Errorf("%s: got %+v", path, cfg)
+		}
+		if cfg.SubsystemLevels["test1"] != LevelInfo || cfg.SubsystemLevels["test2"] != LevelDebug {
+			t.Errorf("%s: got subsystem levels %v", path, cfg.SubsystemLevels)
+		}
+		if cfg.Labels["dc"] != "sjc-1" {
+			t.Errorf("%s: got labels %v", path, cfg.Labels)
+		}
+	}
+	if _, ok := base.SubsystemLevels["test2"]; ok {
+		t.Error("base config was modified")
+	}
+
+	badPath := filepath.Join(dir, "bad.json")
+	if err := os.WriteFile(badPath, []byte(`{"levle": "debug"}`), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := LoadConfigFile(badPath, base); err == nil {
+		t.Error("expected an error for an unknown setting")
+	}
+}
+
+func TestWatchConfigFile(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "config.json")
+	if err := os.WriteFile(path, []byte(`{"level": "info"}`), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	reg := newRegistry()
+	reg.getLogger("test-watch")
+	stop, err := reg.WatchConfigFile(path, Config{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer stop() // nolint:errcheck
+
+	if lvl, _ := reg.SubsystemLevel("test-watch"); lvl != LevelInfo {
+		t.Fatalf("got level %v, want %v", lvl, LevelInfo)
+	}
+
+	if err := os.WriteFile(path, []byte(`{"level": "info", "subsystems": {"test-watch": "debug"}}`), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	deadline := time.Now().Add(5 * time.Second)
+	for {
+		if lvl, _ := reg.SubsystemLevel("test-watch"); lvl == LevelDebug {
+			break
+		}
+		if time.Now().After(deadline) {
+			t.Fatal("configuration was not reloaded")
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+
+	if err := stop(); err != nil {
+		t.Fatal(err)
+	}
+}
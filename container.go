@@ -0,0 +1,26 @@
+package log
+
+import (
+	"os"
+)
+
+// containerMarkers are files container runtimes create in the containers
+// they run.
+var containerMarkers = []string{
+	"/.dockerenv",        // Docker
+	"/run/.containerenv", // Podman
+}
+
+// inContainer reports whether the process seems to run in a container, or
+// in a Kubernetes pod.
+func inContainer() bool {
+	if os.Getenv("KUBERNETES_SERVICE_HOST") != "" {
+		return true
+	}
+	for _, path := range containerMarkers {
+		if _, err := os.Stat(path); err == nil {
+			return true
+		}
+	}
+	return false
+}
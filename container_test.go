@@ -0,0 +1,62 @@
+package log
+
+import (
+	"os"
+	"testing"
+)
+
+func TestMain(m *testing.M) {
+	// Keep the defaults of ConfigFromEnv the same in and out of containers
+	// and CI.
+	os.Setenv(envContainer, "false")
+	os.Unsetenv("GITHUB_ACTIONS")
+	os.Exit(m.Run())
+}
+
+func TestContainerDefaults(t *testing.T) {
+	t.Setenv(envContainer, "true")
+	cfg, err := ConfigFromEnv()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if cfg.Format != JSONOutput || cfg.Stderr || !cfg.Stdout {
+		t.Errorf("got format %v, stderr %v and stdout %v", cfg.Format, cfg.Stderr, cfg.Stdout)
+	}
+
+	t.Setenv(envLoggingFmt, "nocolor")
+	t.Setenv(envLoggingOutput, "stderr")
+	cfg, err = ConfigFromEnv()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if cfg.Format != PlaintextOutput || !cfg.Stderr || cfg.Stdout {
+		t.Errorf("got format %v, stderr %v and stdout %v with explicit settings", cfg.Format, cfg.Stderr, cfg.Stdout)
+	}
+
+	t.Setenv(envContainer, "maybe")
+	if _, err := ConfigFromEnv(); err == nil {
+		t.Error("expected an error for an invalid value")
+	}
+}
+
+func TestInContainer(t *testing.T) {
+	markers := containerMarkers
+	defer func() { containerMarkers = markers }()
+	containerMarkers = []string{t.TempDir() + "/.dockerenv"}
+
+	t.Setenv("KUBERNETES_SERVICE_HOST", "")
+	if inContainer() {
+		t.Error("expected not to be in a container")
+	}
+	t.Setenv("KUBERNETES_SERVICE_HOST", "10.0.0.1")
+	if !inContainer() {
+		t.Error("expected to be in a pod")
+	}
+	t.Setenv("KUBERNETES_SERVICE_HOST", "")
+	if err := os.WriteFile(containerMarkers[0], nil, 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if !inContainer() {
+		t.Error("expected to be in a container")
+	}
+}
@@ -0,0 +1,123 @@
+package log
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+
+	"go.uber.org/zap/zapcore"
+)
+
+type contextFieldsKey struct{}
+
+// ContextWithFields returns a copy of ctx carrying the given key-value pairs
+// (as accepted by Infow and friends). Logging helpers taking a context add
+// them to the entries they emit.
+func ContextWithFields(ctx context.Context, kv ...interface{}) context.Context {
+	if len(kv) == 0 {
+		return ctx
+	}
+	fields := contextFields(ctx)
+	fields = append(fields[:len(fields):len(fields)], kv...)
+	return context.WithValue(ctx, contextFieldsKey{}, fields)
+}
+
+// contextFields returns the key-value pairs added with ContextWithFields.
+func contextFields(ctx context.Context) []interface{} {
+	if ctx == nil {
+		return nil
+	}
+	fields, _ := ctx.Value(contextFieldsKey{}).([]interface{})
+	return fields
+}
+
+type contextLoggerKey struct{}
+
+// DefaultContextSubsystem is the subsystem of the logger FromContext returns
+// for contexts without one.
+const DefaultContextSubsystem = "default"
+
+// NewContext returns a copy of ctx carrying logger, typically one with
+// request-scoped fields bound with With, for FromContext to retrieve further
+// down the call graph.
+func NewContext(ctx context.Context, logger *ZapEventLogger) context.Context {
+	return context.WithValue(ctx, contextLoggerKey{}, logger)
+}
+
+// FromContext returns the logger carried by ctx, as added with NewContext,
+// or the logger of the DefaultContextSubsystem if there is none.
+func FromContext(ctx context.Context) *ZapEventLogger {
+	if ctx != nil {
+		if logger, ok := ctx.Value(contextLoggerKey{}).(*ZapEventLogger); ok && logger != nil {
+			return logger
+		}
+	}
+	return Logger(DefaultContextSubsystem)
+}
+
+// CorrelationIDKey is the key correlation IDs are logged under.
+const CorrelationIDKey = "correlation_id"
+
+type correlationIDKey struct{}
+
+// NewCorrelationID returns a random correlation ID of 32 hexadecimal
+// digits.
+func NewCorrelationID() string {
+	var id [16]byte
+	if _, err := rand.Read(id[:]); err != nil {
+		panic(err)
+	}
+	return hex.EncodeToString(id[:])
+}
+
+// ContextWithCorrelationID returns a copy of ctx carrying the correlation ID
+// id, which the logging helpers taking a context add to their entries under
+// CorrelationIDKey, like fields added with ContextWithFields. Passing the ID
+// along with requests to other services, e.g. in a header, allows following
+// a request across subsystems and processes in the logs alone.
+//
+// If id is empty, ctx is returned as is if it carries an ID already, and
+// with a new one otherwise.
+func ContextWithCorrelationID(ctx context.Context, id string) context.Context {
+	if id == "" {
+		if CorrelationID(ctx) != "" {
+			return ctx
+		}
+		id = NewCorrelationID()
+	}
+	if CorrelationID(ctx) == "" {
+		ctx = ContextWithFields(ctx, CorrelationIDKey, id)
+	} else {
+		// Replace the previous ID rather than logging both.
+		fields := withoutField(contextFields(ctx), CorrelationIDKey)
+		ctx = context.WithValue(ctx, contextFieldsKey{}, append(fields, CorrelationIDKey, id))
+	}
+	return context.WithValue(ctx, correlationIDKey{}, id)
+}
+
+// withoutField returns a copy of the key-value pairs kv, as accepted by
+// Infow, without the key.
+func withoutField(kv []interface{}, key string) []interface{} {
+	out := make([]interface{}, 0, len(kv))
+	for i := 0; i < len(kv); i++ {
+		if _, ok := kv[i].(zapcore.Field); ok || i+1 == len(kv) {
+			out = append(out, kv[i])
+			continue
+		}
+		if k, ok := kv[i].(string); !ok || k != key {
+			out = append(out, kv[i], kv[i+1])
+		}
+		i++
+	}
+	return out
+}
+
+// CorrelationID returns the correlation ID carried by ctx, or "" if there
+// is none.
+func CorrelationID(ctx context.Context) string {
+	if ctx == nil {
+		return ""
+	}
+	id, _ := ctx.Value(correlationIDKey{}).(string)
+	return id
+}
@@ -0,0 +1,45 @@
+package log
+
+import (
+	"context"
+	"testing"
+)
+
+func TestLoggerContext(t *testing.T) {
+	reg := NewRegistry(Config{Level: LevelError})
+	logger := reg.Logger("http").With("request", 42)
+
+	ctx := NewContext(context.Background(), logger)
+	if got := FromContext(ctx); got != logger {
+		t.Errorf("got %+v, want the logger added to the context", got)
+	}
+	if got := FromContext(context.Background()); got.system != DefaultContextSubsystem {
+		t.Errorf("got subsystem %q without a logger", got.system)
+	}
+	// nolint:staticcheck
+	if got := FromContext(nil); got.system != DefaultContextSubsystem {
+		t.Errorf("got subsystem %q for a nil context", got.system)
+	}
+}
+
+func TestCorrelationID(t *testing.T) {
+	ctx := ContextWithFields(context.Background(), "peer", "QmScooby")
+	if id := CorrelationID(ctx); id != "" {
+		t.Errorf("got ID %q for a new context", id)
+	}
+
+	ctx = ContextWithCorrelationID(ctx, "")
+	id := CorrelationID(ctx)
+	if len(id) != 32 {
+		t.Fatalf("got generated ID %q", id)
+	}
+	if ContextWithCorrelationID(ctx, "") != ctx {
+		t.Error("expected the context to keep its ID")
+	}
+
+	ctx = ContextWithCorrelationID(ctx, "scooby-42")
+	fields := contextFields(ctx)
+	if len(fields) != 4 || fields[0] != "peer" || fields[2] != CorrelationIDKey || fields[3] != "scooby-42" {
+		t.Errorf("got fields %v", fields)
+	}
+}
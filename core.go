@@ -3,6 +3,7 @@ package log
 import (
 	"reflect"
 	"sync"
+	"sync/atomic"
 
 	"go.uber.org/multierr"
 	"go.uber.org/zap"
@@ -11,28 +12,40 @@ import (
 
 var _ zapcore.Core = (*lockedMultiCore)(nil)
 
+// lockedMultiCore tees entries to a list of cores that can be changed at any
+// time. The list is copied on write and swapped atomically, so logging never
+// waits on a lock.
 type lockedMultiCore struct {
-	mu    sync.RWMutex // guards mutations to cores slice
-	cores []zapcore.Core
+	mu    sync.Mutex // serializes mutations of cores
+	cores atomic.Pointer[[]zapcore.Core]
 }
 
-func (l *lockedMultiCore) With(fields []zapcore.Field) zapcore.Core {
-	l.mu.RLock()
-	defer l.mu.RUnlock()
-	sub := &lockedMultiCore{
-		cores: make([]zapcore.Core, len(l.cores)),
+func newLockedMultiCore(cores ...zapcore.Core) *lockedMultiCore {
+	l := &lockedMultiCore{}
+	l.cores.Store(&cores)
+	return l
+}
+
+// load returns the current cores, which must not be modified.
+func (l *lockedMultiCore) load() []zapcore.Core {
+	if cores := l.cores.Load(); cores != nil {
+		return *cores
 	}
-	for i := range l.cores {
-		sub.cores[i] = l.cores[i].With(fields)
+	return nil
+}
+
+func (l *lockedMultiCore) With(fields []zapcore.Field) zapcore.Core {
+	cores := l.load()
+	sub := make([]zapcore.Core, len(cores))
+	for i := range cores {
+		sub[i] = cores[i].With(fields)
 	}
-	return sub
+	return newLockedMultiCore(sub...)
 }
 
 func (l *lockedMultiCore) Enabled(lvl zapcore.Level) bool {
-	l.mu.RLock()
-	defer l.mu.RUnlock()
-	for i := range l.cores {
-		if l.cores[i].Enabled(lvl) {
+	for _, core := range l.load() {
+		if core.Enabled(lvl) {
 			return true
 		}
 	}
@@ -40,30 +53,29 @@ func (l *lockedMultiCore) Enabled(lvl zapcore.Level) bool {
 }
 
 func (l *lockedMultiCore) Check(ent zapcore.Entry, ce *zapcore.CheckedEntry) *zapcore.CheckedEntry {
-	l.mu.RLock()
-	defer l.mu.RUnlock()
-	for i := range l.cores {
-		ce = l.cores[i].Check(ent, ce)
+	for _, core := range l.load() {
+		ce = core.Check(ent, ce)
 	}
 	return ce
 }
 
+// Write writes the entry to the cores enabling its level. Cores only filter
+// entries in Check, so this lets the cores wrapping a lockedMultiCore write
+// to it directly.
 func (l *lockedMultiCore) Write(ent zapcore.Entry, fields []zapcore.Field) error {
-	l.mu.RLock()
-	defer l.mu.RUnlock()
 	var err error
-	for i := range l.cores {
-		err = multierr.Append(err, l.cores[i].Write(ent, fields))
+	for _, core := range l.load() {
+		if core.Enabled(ent.Level) {
+			err = multierr.Append(err, core.Write(ent, fields))
+		}
 	}
 	return err
 }
 
 func (l *lockedMultiCore) Sync() error {
-	l.mu.RLock()
-	defer l.mu.RUnlock()
 	var err error
-	for i := range l.cores {
-		err = multierr.Append(err, l.cores[i].Sync())
+	for _, core := range l.load() {
+		err = multierr.Append(err, core.Sync())
 	}
 	return err
 }
@@ -72,50 +84,351 @@ func (l *lockedMultiCore) AddCore(core zapcore.Core) {
 	l.mu.Lock()
 	defer l.mu.Unlock()
 
-	l.cores = append(l.cores, core)
+	old := l.load()
+	cores := make([]zapcore.Core, len(old), len(old)+1)
+	copy(cores, old)
+	cores = append(cores, core)
+	l.cores.Store(&cores)
 }
 
 func (l *lockedMultiCore) DeleteCore(core zapcore.Core) {
 	l.mu.Lock()
 	defer l.mu.Unlock()
 
-	w := 0
-	for i := 0; i < len(l.cores); i++ {
-		if reflect.DeepEqual(l.cores[i], core) {
-			continue
+	old := l.load()
+	cores := make([]zapcore.Core, 0, len(old))
+	for _, c := range old {
+		if !reflect.DeepEqual(c, core) {
+			cores = append(cores, c)
 		}
-		l.cores[w] = l.cores[i]
-		w++
 	}
-	l.cores = l.cores[:w]
+	l.cores.Store(&cores)
 }
 
 func (l *lockedMultiCore) ReplaceCore(original, replacement zapcore.Core) {
 	l.mu.Lock()
 	defer l.mu.Unlock()
 
-	for i := 0; i < len(l.cores); i++ {
-		if reflect.DeepEqual(l.cores[i], original) {
-			l.cores[i] = replacement
+	cores := append([]zapcore.Core(nil), l.load()...)
+	for i := range cores {
+		if reflect.DeepEqual(cores[i], original) {
+			cores[i] = replacement
 		}
 	}
+	l.cores.Store(&cores)
 }
 
 func newCore(format LogFormat, ws zapcore.WriteSyncer, level LogLevel) zapcore.Core {
+	return zapcore.NewCore(newEncoder(format), ws, zap.NewAtomicLevelAt(zapcore.Level(level)))
+}
+
+// newConfiguredCore is like newCore, but also applies the rendering options
+// of cfg.
+func newConfiguredCore(cfg Config, ws zapcore.WriteSyncer, level LogLevel) zapcore.Core {
+	enc := newEncoderFromConfig(cfg.Format, encoderConfig(cfg))
+	// Only the single line console formats render labels and multiline
+	// fields specially.
+	console := cfg.Format == ColorizedOutput || cfg.Format == PlaintextOutput || cfg.Format == GitHubActionsOutput
+
+	var fields []zapcore.Field
+	if len(cfg.Labels) > 0 {
+		// Structured output receives the labels as regular fields.
+		if console {
+			enc = &labelsEncoder{Encoder: enc, labels: formatLabels(cfg.Labels)}
+		} else {
+			fields = labelFields(cfg.Labels)
+		}
+	}
+	if cfg.MultilineFields && console {
+		enc = &multilineEncoder{Encoder: enc}
+	}
+	if cfg.Format == GitHubActionsOutput {
+		// Last, so that the annotations include the labels and blocks.
+		enc = &githubEncoder{Encoder: enc}
+	}
+
+	var core zapcore.Core = zapcore.NewCore(enc, ws, zap.NewAtomicLevelAt(zapcore.Level(level)))
+	if len(fields) > 0 {
+		core = core.With(fields)
+	}
+	if len(cfg.SubsystemLabels) > 0 {
+		core = newSubsystemLabelsCore(core, cfg.SubsystemLabels)
+	}
+	return core
+}
+
+// NewEncoder returns the encoder the outputs of format use by default, e.g.
+// to render entries the same way elsewhere.
+func NewEncoder(format LogFormat) zapcore.Encoder {
+	return newEncoder(format)
+}
+
+func newEncoder(format LogFormat) zapcore.Encoder {
+	enc := newEncoderFromConfig(format, encoderConfig(Config{Format: format}))
+	if format == GitHubActionsOutput {
+		enc = &githubEncoder{Encoder: enc}
+	}
+	return enc
+}
+
+// encoderConfig returns the encoder configuration for the timestamp, caller
+// and color options of cfg.
+func encoderConfig(cfg Config) zapcore.EncoderConfig {
 	encCfg := zap.NewProductionEncoderConfig()
 	encCfg.EncodeTime = zapcore.ISO8601TimeEncoder
+	if cfg.DisableTimestamp {
+		encCfg.TimeKey = zapcore.OmitKey
+	}
 
-	var encoder zapcore.Encoder
+	switch {
+	case cfg.DisableCaller:
+		encCfg.CallerKey = zapcore.OmitKey
+	case cfg.CallerFormat == CallerFull:
+		encCfg.EncodeCaller = zapcore.FullCallerEncoder
+	case cfg.CallerFormat == CallerFunction:
+		encCfg.FunctionKey = "func"
+	}
+	if (cfg.Format == ColorizedOutput || cfg.Format == PrettyOutput) && cfg.SubsystemColors {
+		encCfg.EncodeName = colorNameEncoder
+	}
+	return encCfg
+}
+
+func newEncoderFromConfig(format LogFormat, encCfg zapcore.EncoderConfig) zapcore.Encoder {
 	switch format {
-	case PlaintextOutput:
-		encCfg.EncodeLevel = zapcore.CapitalLevelEncoder
-		encoder = zapcore.NewConsoleEncoder(encCfg)
+	case PlaintextOutput, GitHubActionsOutput:
+		encCfg.EncodeLevel = capitalLevelEncoder
+		return zapcore.NewConsoleEncoder(encCfg)
 	case JSONOutput:
-		encoder = zapcore.NewJSONEncoder(encCfg)
+		encCfg.EncodeLevel = lowercaseLevelEncoder
+		return zapcore.NewJSONEncoder(encCfg)
+	case PrettyOutput:
+		return newPrettyEncoder(encCfg)
 	default:
-		encCfg.EncodeLevel = zapcore.CapitalColorLevelEncoder
-		encoder = zapcore.NewConsoleEncoder(encCfg)
+		encCfg.EncodeLevel = capitalColorLevelEncoder
+		return zapcore.NewConsoleEncoder(encCfg)
+	}
+}
+
+// subsystemCore is the core of a subsystem's logger. It forwards the entries
+// enabled by the subsystem's level, or by a field filter, to the outputs and,
+// while the flight recorder is running, all entries to the recorder. Fatal
+// entries are finally passed to the fatal hooks. Fields are redacted on the
+// way, if configured.
+type subsystemCore struct {
+	level     zapcore.LevelEnabler
+	out       zapcore.Core
+	recorder  *flightRecorder
+	rec       zapcore.Core // the recorder's core, with fields bound via With
+	fatal     *fatalHooks
+	redaction *redaction
+	filters   *fieldFilters
+	startup   *atomic.Pointer[startupBuffer] // set while entries are held back
+	bound     []zapcore.Field                // the fields bound via With, for the filters and startup
+}
+
+var _ zapcore.Core = (*subsystemCore)(nil)
+
+func newSubsystemCore(level zapcore.LevelEnabler, out zapcore.Core, recorder *flightRecorder, fatal *fatalHooks, redaction *redaction, filters *fieldFilters, startup *atomic.Pointer[startupBuffer]) *subsystemCore {
+	return &subsystemCore{
+		level:     level,
+		out:       out,
+		recorder:  recorder,
+		rec:       recorder.core(),
+		fatal:     fatal,
+		redaction: redaction,
+		filters:   filters,
+		startup:   startup,
+	}
+}
+
+func (c *subsystemCore) Enabled(lvl zapcore.Level) bool {
+	return c.level.Enabled(lvl) || c.recorder.running() || c.filters.load().enables(lvl) || c.startup.Load() != nil
+}
+
+// With binds fields to the logger. They are redacted with the configuration
+// in effect now.
+func (c *subsystemCore) With(fields []zapcore.Field) zapcore.Core {
+	if red := c.redaction.load(); red != nil {
+		fields = red.fields(fields)
+	}
+	return &subsystemCore{
+		level:     c.level,
+		out:       c.out.With(fields),
+		recorder:  c.recorder,
+		rec:       c.rec.With(fields),
+		fatal:     c.fatal,
+		redaction: c.redaction,
+		filters:   c.filters,
+		startup:   c.startup,
+		bound:     append(c.bound[:len(c.bound):len(c.bound)], fields...),
+	}
+}
+
+func (c *subsystemCore) Check(ent zapcore.Entry, ce *zapcore.CheckedEntry) *zapcore.CheckedEntry {
+	red := c.redaction.load()
+	if c.level.Enabled(ent.Level) {
+		if red != nil {
+			ce = ce.AddCore(ent, &redactCore{core: c.out, redactor: red})
+		} else {
+			ce = c.out.Check(ent, ce)
+		}
+	} else if buf := c.startup.Load(); buf != nil {
+		// Entries are held back regardless of the levels, which are only
+		// final once logging is configured, and filtered on replay.
+		var out zapcore.Core = &bufferCore{buf: buf, fields: c.bound}
+		if red != nil {
+			out = &redactCore{core: out, redactor: red}
+		}
+		ce = ce.AddCore(ent, out)
+	} else if set := c.filters.load(); set.enables(ent.Level) {
+		var out zapcore.Core = &fieldFilterCore{core: c.out, set: set, bound: c.bound}
+		if red != nil {
+			out = &redactCore{core: out, redactor: red}
+		}
+		ce = ce.AddCore(ent, out)
+	}
+	if c.recorder.running() {
+		if red != nil {
+			ce = ce.AddCore(ent, &redactCore{core: c.rec, redactor: red})
+		} else {
+			ce = c.rec.Check(ent, ce)
+		}
+	}
+	return c.fatal.Check(ent, ce)
+}
+
+func (c *subsystemCore) Write(ent zapcore.Entry, fields []zapcore.Field) error {
+	if red := c.redaction.load(); red != nil {
+		fields = red.fields(fields)
+	}
+	var err error
+	if c.level.Enabled(ent.Level) {
+		err = c.out.Write(ent, fields)
+	} else if buf := c.startup.Load(); buf != nil {
+		err = (&bufferCore{buf: buf, fields: c.bound}).Write(ent, fields)
+	} else if set := c.filters.load(); set.enables(ent.Level) && set.matches(ent.Level, c.bound, fields) {
+		err = c.out.Write(ent, fields)
+	}
+	if c.recorder.running() {
+		err = multierr.Append(err, c.rec.Write(ent, fields))
+	}
+	return err
+}
+
+func (c *subsystemCore) Sync() error {
+	return c.out.Sync()
+}
+
+// outputsCore is a primary core writing to the outputs opened by
+// SetupLogging. Loggers derived with With and entries already checked keep
+// using it after it was replaced, so once retired it forwards their entries
+// to its replacement instead, letting its outputs be closed.
+type outputsCore struct {
+	core   zapcore.Core
+	fields []zapcore.Field // bound with With, for the replacement
+	state  *outputsState   // shared with the cores derived with With
+}
+
+type outputsState struct {
+	mu   sync.RWMutex // held for reading while writing to the outputs
+	next zapcore.Core // the replacement, once retired
+}
+
+var _ zapcore.Core = (*outputsCore)(nil)
+
+func newOutputsCore(core zapcore.Core) *outputsCore {
+	return &outputsCore{core: core, state: &outputsState{}}
+}
+
+// retire forwards the entries to next from now on, once those being written
+// to the outputs are done.
+func (c *outputsCore) retire(next zapcore.Core) {
+	c.state.mu.Lock()
+	defer c.state.mu.Unlock()
+	c.state.next = next
+}
+
+// replacement returns the core the entries are forwarded to, nil if c
+// isn't retired. If not nil, c.state.mu is held for reading on return.
+func (c *outputsCore) replacement() zapcore.Core {
+	c.state.mu.RLock()
+	next := c.state.next
+	if next == nil {
+		return nil
+	}
+	c.state.mu.RUnlock()
+	if len(c.fields) > 0 {
+		next = next.With(c.fields)
+	}
+	return next
+}
+
+func (c *outputsCore) Enabled(lvl zapcore.Level) bool {
+	return c.core.Enabled(lvl)
+}
+
+func (c *outputsCore) With(fields []zapcore.Field) zapcore.Core {
+	return &outputsCore{
+		core:   c.core.With(fields),
+		fields: append(c.fields[:len(c.fields):len(c.fields)], fields...),
+		state:  c.state,
+	}
+}
+
+func (c *outputsCore) Check(ent zapcore.Entry, ce *zapcore.CheckedEntry) *zapcore.CheckedEntry {
+	if c.Enabled(ent.Level) {
+		return ce.AddCore(ent, c)
 	}
+	return ce
+}
+
+func (c *outputsCore) Write(ent zapcore.Entry, fields []zapcore.Field) error {
+	if next := c.replacement(); next != nil {
+		return next.Write(ent, fields)
+	}
+	defer c.state.mu.RUnlock()
+	return c.core.Write(ent, fields)
+}
+
+func (c *outputsCore) Sync() error {
+	if next := c.replacement(); next != nil {
+		return next.Sync()
+	}
+	defer c.state.mu.RUnlock()
+	return c.core.Sync()
+}
+
+// computedFieldCore adds a field computed for each entry it is given, like
+// the ID of the logging goroutine, before writing it to core, honoring its
+// levels.
+type computedFieldCore struct {
+	core  zapcore.Core
+	field func(zapcore.Entry) zapcore.Field
+}
+
+var _ zapcore.Core = (*computedFieldCore)(nil)
+
+func (c *computedFieldCore) Enabled(lvl zapcore.Level) bool {
+	return c.core.Enabled(lvl)
+}
+
+func (c *computedFieldCore) With(fields []zapcore.Field) zapcore.Core {
+	return &computedFieldCore{core: c.core.With(fields), field: c.field}
+}
+
+func (c *computedFieldCore) Check(ent zapcore.Entry, ce *zapcore.CheckedEntry) *zapcore.CheckedEntry {
+	if c.Enabled(ent.Level) {
+		return ce.AddCore(ent, c)
+	}
+	return ce
+}
+
+func (c *computedFieldCore) Write(ent zapcore.Entry, fields []zapcore.Field) error {
+	fields = append(fields[:len(fields):len(fields)], c.field(ent))
+	return c.core.Write(ent, fields)
+}
 
-	return zapcore.NewCore(encoder, ws, zap.NewAtomicLevelAt(zapcore.Level(level)))
+func (c *computedFieldCore) Sync() error {
+	return c.core.Sync()
 }
@@ -2,9 +2,11 @@ package log
 
 import (
 	"bytes"
+	"io"
 	"testing"
 	"time"
 
+	"go.uber.org/zap"
 	"go.uber.org/zap/zapcore"
 )
 
@@ -187,3 +189,152 @@ func TestLockedMultiCoreReplaceCore(t *testing.T) {
 	}
 
 }
+
+func TestNewConfiguredCoreLabels(t *testing.T) {
+	entry := zapcore.Entry{
+		LoggerName: "main",
+		Level:      zapcore.InfoLevel,
+		Message:    "scooby",
+		Time:       time.Date(2010, 5, 23, 15, 14, 0, 0, time.UTC),
+	}
+	labels := map[string]string{"dc": "sjc-1", "app": "mystery machine"}
+
+	testCases := []struct {
+		format LogFormat
+		want   string
+	}{
+		{
+			format: ColorizedOutput,
+			want:   "2010-05-23T15:14:00.000Z\t\x1b[34mINFO\x1b[0m\tmain\tscooby\t{\"k\": \"v\"}\tapp=\"mystery machine\" dc=sjc-1\n",
+		},
+		{
+			format: JSONOutput,
+			want:   `{"level":"info","ts":"2010-05-23T15:14:00.000Z","logger":"main","msg":"scooby","app":"mystery machine","dc":"sjc-1","k":"v"}` + "\n",
+		},
+		{
+			format: PlaintextOutput,
+			want:   "2010-05-23T15:14:00.000Z\tINFO\tmain\tscooby\t{\"k\": \"v\"}\tapp=\"mystery machine\" dc=sjc-1\n",
+		},
+	}
+
+	for _, tc := range testCases {
+		buf := &bytes.Buffer{}
+		ws := zapcore.AddSync(buf)
+
+		core := newConfiguredCore(Config{Format: tc.format, Labels: labels}, ws, LevelDebug)
+		if err := core.Write(entry, []zapcore.Field{zap.String("k", "v")}); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+
+		got := buf.String()
+		if got != tc.want {
+			t.Errorf("got %q, want %q", got, tc.want)
+		}
+	}
+
+	// The labels end the first line, not the stack trace.
+	buf := &bytes.Buffer{}
+	core := newConfiguredCore(Config{Format: PlaintextOutput, Labels: map[string]string{"dc": "sjc-1"}}, zapcore.AddSync(buf), LevelDebug)
+	entry.Stack = "main.main\n\t/src/main.go:42"
+	if err := core.Write(entry, nil); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	want := "2010-05-23T15:14:00.000Z\tINFO\tmain\tscooby\tdc=sjc-1\nmain.main\n\t/src/main.go:42\n"
+	if got := buf.String(); got != want {
+		t.Errorf("got %q, want %q", got, want)
+	}
+}
+
+func TestNewConfiguredCoreMultiline(t *testing.T) {
+	entry := zapcore.Entry{
+		LoggerName: "main",
+		Level:      zapcore.ErrorLevel,
+		Message:    "scooby",
+		Time:       time.Date(2010, 5, 23, 15, 14, 0, 0, time.UTC),
+	}
+	fields := []zapcore.Field{
+		zap.String("stack", "goroutine 1 [running]:\nmain.main()\n"),
+		zap.String("k", "v"),
+	}
+
+	buf := &bytes.Buffer{}
+	core := newConfiguredCore(Config{
+		Format:          PlaintextOutput,
+		Labels:          map[string]string{"dc": "sjc-1"},
+		MultilineFields: true,
+	}, zapcore.AddSync(buf), LevelDebug)
+	core = core.With([]zapcore.Field{zap.String("yaml", "a: 1\nb: 2")})
+
+	if err := core.Write(entry, fields); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	want := "2010-05-23T15:14:00.000Z\tERROR\tmain\tscooby\t{\"k\": \"v\"}\tdc=sjc-1\n" +
+		"    yaml:\n" +
+		"        a: 1\n" +
+		"        b: 2\n" +
+		"    stack:\n" +
+		"        goroutine 1 [running]:\n" +
+		"        main.main()\n"
+	if got := buf.String(); got != want {
+		t.Errorf("got %q, want %q", got, want)
+	}
+}
+
+func TestLockedMultiCoreConcurrentMutation(t *testing.T) {
+	mc := &lockedMultiCore{}
+	entry := zapcore.Entry{LoggerName: "main", Level: zapcore.InfoLevel, Message: "scooby"}
+
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		for i := 0; i < 1000; i++ {
+			if err := mc.Write(entry, nil); err != nil {
+				t.Errorf("unexpected error: %v", err)
+				return
+			}
+		}
+	}()
+	for i := 0; i < 100; i++ {
+		core := newCore(JSONOutput, zapcore.AddSync(io.Discard), LevelDebug)
+		mc.AddCore(core)
+		mc.ReplaceCore(core, core)
+		mc.DeleteCore(core)
+	}
+	<-done
+
+	if n := len(mc.load()); n != 0 {
+		t.Errorf("got %d cores, want 0", n)
+	}
+}
+
+func TestLockedMultiCoreWriteLevels(t *testing.T) {
+	var info, warn bytes.Buffer
+	mc := newLockedMultiCore(
+		newCore(PlaintextOutput, zapcore.AddSync(&info), LevelInfo),
+		newCore(PlaintextOutput, zapcore.AddSync(&warn), LevelWarn),
+	)
+
+	entry := zapcore.Entry{LoggerName: "main", Level: zapcore.InfoLevel, Message: "scooby"}
+	if err := mc.Write(entry, nil); err != nil {
+		t.Fatal(err)
+	}
+	if info.Len() == 0 || warn.Len() != 0 {
+		t.Errorf("got %q and %q, wanted the entry written to the info core only", info.String(), warn.String())
+	}
+}
+
+func TestWrappingCoresReturnWriteErrors(t *testing.T) {
+	out := newCore(PlaintextOutput, &brokenWriteSyncer{broken: true}, LevelDebug)
+	entry := zapcore.Entry{LoggerName: "main", Level: zapcore.InfoLevel, Message: "scooby"}
+
+	for name, core := range map[string]zapcore.Core{
+		"redact":   &redactCore{core: out, redactor: newRedactor(Config{Redact: []string{"password"}})},
+		"seq":      &seqCore{core: out, state: newSeqState()},
+		"computed": &computedFieldCore{core: out, field: uptimeField},
+	} {
+		if err := core.Write(entry, nil); err == nil {
+			t.Errorf("%s: got no error writing to a broken output", name)
+		}
+	}
+}
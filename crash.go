@@ -0,0 +1,135 @@
+package log
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"os/signal"
+	"runtime"
+	"runtime/debug"
+	"sync"
+	"time"
+
+	"go.uber.org/multierr"
+)
+
+var crashFileMutex sync.Mutex // guards crashFile
+
+// crashFile is where crash reports are written, stderr if empty
+var crashFile = os.Getenv(envCrashFile)
+
+// SetCrashReportFile sets the path of the file crash reports are appended to.
+// An empty path, the default unless GOLOG_CRASH_FILE is set, writes them to
+// stderr.
+func SetCrashReportFile(path string) {
+	crashFileMutex.Lock()
+	defer crashFileMutex.Unlock()
+
+	crashFile = path
+}
+
+// RecoverAndReport writes a crash report and re-panics if the calling
+// goroutine is panicking. It must be deferred directly:
+//
+//	defer log.RecoverAndReport()
+//
+// The report contains the panic value and stack followed by the contents of
+// the flight recorder (see StartFlightRecorder), so that crashes in the field
+// come with log context.
+func RecoverAndReport() {
+	if r := recover(); r != nil {
+		reportCrash(fmt.Sprintf("panic: %v", r), debug.Stack())
+		panic(r)
+	}
+}
+
+// ReportOnSignal writes a crash report, with the stacks of all goroutines,
+// whenever the process receives one of the given signals, until stop is
+// called. The process keeps running. At least one signal must be given, it
+// does nothing otherwise.
+func ReportOnSignal(sigs ...os.Signal) (stop func()) {
+	if len(sigs) == 0 {
+		// signal.Notify would relay all signals, including those the
+		// application shuts down on.
+		return func() {}
+	}
+
+	ch := make(chan os.Signal, 1)
+	done := make(chan struct{})
+	signal.Notify(ch, sigs...)
+
+	var wg sync.WaitGroup
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		for {
+			select {
+			case sig := <-ch:
+				reportCrash(fmt.Sprintf("received signal: %v", sig), allStacks())
+			case <-done:
+				return
+			}
+		}
+	}()
+
+	var once sync.Once
+	return func() {
+		once.Do(func() {
+			signal.Stop(ch)
+			close(done)
+			wg.Wait()
+		})
+	}
+}
+
+// WriteCrashReport writes a crash report with the given reason and stack to
+// w. See RecoverAndReport.
+func WriteCrashReport(w io.Writer, reason string, stack []byte) error {
+	return defaultRegistry.WriteCrashReport(w, reason, stack)
+}
+
+// WriteCrashReport writes a crash report including the flight recorder of
+// this registry to w.
+func (r *Registry) WriteCrashReport(w io.Writer, reason string, stack []byte) error {
+	entries := r.recorder.entries()
+
+	_, err := fmt.Fprintf(w, "=== crash report %s ===\n%s\n\n%s\n--- flight recorder (%d entries) ---\n",
+		FormatRFC3339(time.Now()), reason, stack, len(entries))
+	if err != nil {
+		return err
+	}
+	return r.DumpFlightRecorder(w)
+}
+
+func reportCrash(reason string, stack []byte) {
+	// Make sure whatever was logged so far reaches the outputs.
+	_ = defaultRegistry.loggerCore.Sync()
+
+	crashFileMutex.Lock()
+	defer crashFileMutex.Unlock()
+
+	var err error
+	if crashFile == "" {
+		err = WriteCrashReport(os.Stderr, reason, stack)
+	} else if f, ferr := os.OpenFile(crashFile, os.O_CREATE|os.O_APPEND|os.O_WRONLY, 0o600); ferr != nil {
+		fmt.Fprintf(os.Stderr, "failed to open crash report file %q: %s\n", crashFile, ferr)
+		err = WriteCrashReport(os.Stderr, reason, stack)
+	} else {
+		err = multierr.Combine(WriteCrashReport(f, reason, stack), f.Sync(), f.Close())
+	}
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "failed to write crash report: %s\n", err)
+	}
+}
+
+// allStacks returns the stacks of all goroutines.
+func allStacks() []byte {
+	buf := make([]byte, 1<<16)
+	for {
+		n := runtime.Stack(buf, true)
+		if n < len(buf) {
+			return buf[:n]
+		}
+		buf = make([]byte, 2*len(buf))
+	}
+}
@@ -0,0 +1,39 @@
+package log
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestRecoverAndReport(t *testing.T) {
+	crashPath := filepath.Join(t.TempDir(), "crash.log")
+	SetCrashReportFile(crashPath)
+	defer SetCrashReportFile("")
+
+	StartFlightRecorder(10)
+	defer StopFlightRecorder()
+
+	getLogger("test-crash").Debug("scooby")
+
+	func() {
+		defer func() {
+			if r := recover(); r != "doo" {
+				t.Errorf("got %v, wanted the panic to be propagated", r)
+			}
+		}()
+		defer RecoverAndReport()
+		panic("doo")
+	}()
+
+	report, err := os.ReadFile(crashPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, want := range []string{"panic: doo", "TestRecoverAndReport", "--- flight recorder (1 entries) ---", `"msg":"scooby"`} {
+		if !strings.Contains(string(report), want) {
+			t.Errorf("got %q, wanted it to contain %q", report, want)
+		}
+	}
+}
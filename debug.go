@@ -0,0 +1,38 @@
+//go:build !golog_nodebug
+
+package log
+
+import (
+	"fmt"
+)
+
+// Trace logs a message at trace level, below debug. It is meant for very
+// chatty logging, such as wire-level messages, that would make debug logs
+// unusable.
+func (logger *ZapEventLogger) Trace(args ...interface{}) {
+	l := logger.skipLogger.Desugar()
+	if !l.Core().Enabled(traceLevel) {
+		return
+	}
+	if ce := l.Check(traceLevel, fmt.Sprint(args...)); ce != nil {
+		ce.Write()
+	}
+}
+
+// Tracef logs a formatted message at trace level.
+func (logger *ZapEventLogger) Tracef(format string, args ...interface{}) {
+	l := logger.skipLogger.Desugar()
+	if !l.Core().Enabled(traceLevel) {
+		return
+	}
+	if ce := l.Check(traceLevel, fmt.Sprintf(format, args...)); ce != nil {
+		ce.Write()
+	}
+}
+
+// Tracew logs a message at trace level with some additional context.
+func (logger *ZapEventLogger) Tracew(msg string, keysAndValues ...interface{}) {
+	if ce := logger.skipLogger.Desugar().Check(traceLevel, msg); ce != nil {
+		ce.Write(sweetenFields(keysAndValues)...)
+	}
+}
@@ -0,0 +1,9 @@
+//go:build !golog_nodebug
+
+package log
+
+import "testing"
+
+// skipWithoutDebug skips tests relying on debug logging when it is compiled
+// out with the golog_nodebug tag. It does nothing here.
+func skipWithoutDebug(testing.TB) {}
@@ -0,0 +1,61 @@
+package log
+
+import (
+	"os"
+	"os/signal"
+	"sync"
+	"time"
+)
+
+// DebugOnSignal enables debug logging for d whenever the process receives
+// SIGUSR1, until stop is called, for live triage of incidents. It applies to
+// the given subsystems, or to all existing ones if none are given. Their
+// previous levels are restored once d has elapsed, unless they were changed
+// meanwhile; another signal in the meantime extends the period.
+//
+// A message is logged at error level, so that it shows with the default
+// levels, every time debug logging is enabled. It does nothing outside Unix,
+// where there is no SIGUSR1.
+func DebugOnSignal(d time.Duration, subsystems ...string) (stop func()) {
+	return defaultRegistry.DebugOnSignal(d, subsystems...)
+}
+
+// DebugOnSignal enables debug logging on signals for the subsystems of this
+// registry. See the package-level DebugOnSignal.
+func (r *Registry) DebugOnSignal(d time.Duration, subsystems ...string) (stop func()) {
+	if debugSignal == nil {
+		return func() {}
+	}
+
+	ch := make(chan os.Signal, 1)
+	done := make(chan struct{})
+	signal.Notify(ch, debugSignal)
+
+	var wg sync.WaitGroup
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		for {
+			select {
+			case <-ch:
+				names := subsystems
+				if len(names) == 0 {
+					names = r.GetSubsystems()
+				}
+				r.raiseLevelsFor(names, LevelDebug, d)
+				r.getLogger("setup-logger").Errorw("debug logging enabled temporarily", "duration", d, "subsystems", names)
+			case <-done:
+				return
+			}
+		}
+	}()
+
+	var once sync.Once
+	return func() {
+		once.Do(func() {
+			signal.Stop(ch)
+			close(done)
+			wg.Wait()
+		})
+	}
+}
@@ -0,0 +1,10 @@
+//go:build !unix
+
+package log
+
+import (
+	"os"
+)
+
+// debugSignal is nil as there is no SIGUSR1 outside Unix, see DebugOnSignal.
+var debugSignal os.Signal
@@ -0,0 +1,54 @@
+package log
+
+import (
+	"os"
+	"runtime"
+	"testing"
+	"time"
+)
+
+func TestDebugOnSignal(t *testing.T) {
+	if debugSignal == nil {
+		t.Skip("no SIGUSR1 on " + runtime.GOOS)
+	}
+
+	reg := NewRegistry(Config{Level: LevelError})
+	reg.Logger("dht")
+	reg.Logger("bitswap")
+	reg.Logger("chatty")
+	if err := reg.SetLogLevel("chatty", "trace"); err != nil {
+		t.Fatal(err)
+	}
+	stop := reg.DebugOnSignal(200*time.Millisecond, "dht", "chatty")
+	defer stop()
+
+	p, err := os.FindProcess(os.Getpid())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := p.Signal(debugSignal); err != nil {
+		t.Fatal(err)
+	}
+
+	deadline := time.Now().Add(5 * time.Second)
+	for {
+		if lvl, _ := reg.SubsystemLevel("dht"); lvl == LevelDebug {
+			break
+		}
+		if time.Now().After(deadline) {
+			t.Fatal("debug logging was not enabled")
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+	if lvl, _ := reg.SubsystemLevel("bitswap"); lvl != LevelError {
+		t.Errorf("got level %s for bitswap, want it untouched", lvl)
+	}
+	if lvl, _ := reg.SubsystemLevel("chatty"); lvl != LevelTrace {
+		t.Errorf("got level %s for chatty, want it left more verbose", lvl)
+	}
+
+	time.Sleep(400 * time.Millisecond)
+	if lvl, _ := reg.SubsystemLevel("dht"); lvl != LevelError {
+		t.Errorf("got level %s after the period, want it restored", lvl)
+	}
+}
@@ -0,0 +1,11 @@
+//go:build unix
+
+package log
+
+import (
+	"os"
+	"syscall"
+)
+
+// debugSignal enables debug logging temporarily, see DebugOnSignal.
+var debugSignal os.Signal = syscall.SIGUSR1
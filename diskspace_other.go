@@ -0,0 +1,9 @@
+//go:build !linux && !darwin && !freebsd
+
+package log
+
+// freeSpace is not supported on this platform: Config.FileMinFreeSpace is
+// ignored.
+func freeSpace(string) (int64, bool) {
+	return 0, false
+}
@@ -0,0 +1,15 @@
+//go:build linux || darwin || freebsd
+
+package log
+
+import "syscall"
+
+// freeSpace returns the space available to unprivileged users on the file
+// system holding path.
+func freeSpace(path string) (int64, bool) {
+	var st syscall.Statfs_t
+	if err := syscall.Statfs(path, &st); err != nil {
+		return 0, false
+	}
+	return int64(st.Bavail) * int64(st.Bsize), true
+}
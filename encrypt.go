@@ -0,0 +1,112 @@
+package log
+
+import (
+	"bufio"
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"encoding/binary"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+
+	"go.uber.org/zap/zapcore"
+)
+
+// maxEncryptedRecord bounds the size of the records DecryptLog accepts.
+const maxEncryptedRecord = 64 << 20
+
+func newLogAEAD(key []byte) (cipher.AEAD, error) {
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, fmt.Errorf("invalid log file key: %w", err)
+	}
+	return cipher.NewGCM(block)
+}
+
+// encryptingWriteSyncer seals each write with AES-GCM as a record made of
+// its big-endian uint32 length, a random nonce and the ciphertext. zap
+// writes one entry at a time, so that entries can be decrypted on their own
+// and the file survives being rotated or truncated between records.
+type encryptingWriteSyncer struct {
+	zapcore.WriteSyncer
+	aead cipher.AEAD
+}
+
+func (w *encryptingWriteSyncer) Write(p []byte) (int, error) {
+	nonceSize := w.aead.NonceSize()
+	record := make([]byte, 4+nonceSize, 4+nonceSize+len(p)+w.aead.Overhead())
+	if _, err := rand.Read(record[4:]); err != nil {
+		return 0, err
+	}
+	record = w.aead.Seal(record, record[4:], p, nil)
+	binary.BigEndian.PutUint32(record, uint32(len(record)-4))
+
+	if _, err := w.WriteSyncer.Write(record); err != nil {
+		return 0, err
+	}
+	return len(p), nil
+}
+
+// DecryptLog decrypts a log file written with Config.FileKey or
+// GOLOG_FILE_KEY from r to w.
+func DecryptLog(w io.Writer, r io.Reader, key []byte) error {
+	aead, err := newLogAEAD(key)
+	if err != nil {
+		return err
+	}
+
+	br := bufio.NewReader(r)
+	var size [4]byte
+	for {
+		if _, err := io.ReadFull(br, size[:]); err != nil {
+			if err == io.EOF {
+				return nil
+			}
+			return fmt.Errorf("reading encrypted log: %w", err)
+		}
+		n := binary.BigEndian.Uint32(size[:])
+		if n < uint32(aead.NonceSize()) || n > maxEncryptedRecord {
+			return errors.New("reading encrypted log: invalid record size")
+		}
+		record := make([]byte, n)
+		if _, err := io.ReadFull(br, record); err != nil {
+			return fmt.Errorf("reading encrypted log: %w", err)
+		}
+		plain, err := aead.Open(nil, record[:aead.NonceSize()], record[aead.NonceSize():], nil)
+		if err != nil {
+			return fmt.Errorf("decrypting log: %w", err)
+		}
+		if _, err := w.Write(plain); err != nil {
+			return err
+		}
+	}
+}
+
+// fileKeyFromEnv returns the key set with GOLOG_FILE_KEY or
+// GOLOG_FILE_KEY_FILE, if any.
+func fileKeyFromEnv() ([]byte, error) {
+	encoded := getenv(envFileKey)
+	if path := getenv(envFileKeyFile); path != "" {
+		data, err := os.ReadFile(path)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read log file key: %w", err)
+		}
+		encoded = string(data)
+	}
+	if encoded == "" {
+		return nil, nil
+	}
+
+	key, err := hex.DecodeString(strings.TrimSpace(encoded))
+	if err == nil {
+		_, err = aes.NewCipher(key)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("ignoring invalid log file key: %w", err)
+	}
+	return key, nil
+}
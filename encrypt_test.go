@@ -0,0 +1,63 @@
+package log
+
+import (
+	"bytes"
+	"context"
+	"encoding/hex"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestEncryptedFile(t *testing.T) {
+	key := bytes.Repeat([]byte{42}, 32)
+	path := filepath.Join(t.TempDir(), "go-log-test")
+	reg := NewRegistry(Config{Format: JSONOutput, Level: LevelInfo, File: path, FileKey: key})
+
+	reg.Logger("test").Infow("scooby", "peer", "velma")
+	reg.Logger("test").Info("doo")
+	if err := reg.Close(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if bytes.Contains(data, []byte("scooby")) {
+		t.Fatal("log file written in plain text")
+	}
+
+	var out bytes.Buffer
+	if err := DecryptLog(&out, bytes.NewReader(data), key); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	lines := strings.Split(strings.TrimSpace(out.String()), "\n")
+	if len(lines) != 2 || !strings.Contains(lines[0], `"peer":"velma"`) || !strings.Contains(lines[1], `"msg":"doo"`) {
+		t.Errorf("got %q", lines)
+	}
+
+	if err := DecryptLog(&out, bytes.NewReader(data), bytes.Repeat([]byte{7}, 32)); err == nil {
+		t.Error("expected an error with the wrong key")
+	}
+}
+
+func TestFileKeyFromEnv(t *testing.T) {
+	key := bytes.Repeat([]byte{42}, 16)
+	keyFile := filepath.Join(t.TempDir(), "key")
+	if err := os.WriteFile(keyFile, []byte(hex.EncodeToString(key)+"\n"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	t.Setenv(envFileKeyFile, keyFile)
+	if cfg, err := ConfigFromEnv(); err != nil || !bytes.Equal(cfg.FileKey, key) {
+		t.Errorf("got key %x, %v, want %x", cfg.FileKey, err, key)
+	}
+
+	t.Setenv(envFileKeyFile, "")
+	t.Setenv(envFileKey, "abcd")
+	if _, err := ConfigFromEnv(); err == nil {
+		t.Error("expected an error for a key of invalid size")
+	}
+}
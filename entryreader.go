@@ -0,0 +1,153 @@
+package log
+
+import (
+	"sync"
+	"time"
+
+	"go.uber.org/zap/zapcore"
+)
+
+// entryReaderBuffer is the number of entries an EntryReader holds before
+// logging blocks.
+const entryReaderBuffer = 256
+
+// LogEntry is a decoded log entry, as delivered by an EntryReader.
+type LogEntry struct {
+	Time      time.Time
+	Level     LogLevel
+	Subsystem string
+	Message   string
+
+	// Fields are the fields of the entry, including those bound with
+	// With, as encoded by a zapcore.MapObjectEncoder.
+	Fields map[string]interface{}
+}
+
+// An EntryReader delivers the entries of all loggers as LogEntry values,
+// sparing in-process consumers such as GUIs and tests the encoding and
+// decoding of a PipeReader. Like a PipeReader, it is synchronous: once its
+// buffer is full, logging blocks until entries are received.
+type EntryReader struct {
+	ch   chan LogEntry
+	done chan struct{}
+	core *entryCore
+	reg  *Registry
+
+	mu     sync.RWMutex // guards closed, held for reading while sending
+	closed bool
+	once   sync.Once
+}
+
+// NewEntryReader creates a new in-memory reader of the entries of all
+// loggers. The caller must call Close on the returned reader when done.
+//
+// Like NewPipeReader, it receives everything enabled by SetLogLevel; the
+// minimum level can be increased by passing the PipeLevel option. Other
+// options are ignored.
+func NewEntryReader(opts ...PipeReaderOption) *EntryReader {
+	return defaultRegistry.NewEntryReader(opts...)
+}
+
+// NewEntryReader creates a new in-memory reader of the entries of all
+// loggers of this registry. See the package-level NewEntryReader.
+func (r *Registry) NewEntryReader(opts ...PipeReaderOption) *EntryReader {
+	opt := pipeReaderOptions{
+		level: LevelTrace,
+	}
+	for _, o := range opts {
+		o.setOption(&opt)
+	}
+
+	e := &EntryReader{
+		ch:   make(chan LogEntry, entryReaderBuffer),
+		done: make(chan struct{}),
+		reg:  r,
+	}
+	e.core = &entryCore{LevelEnabler: zapcore.Level(opt.level), reader: e}
+
+	r.trackPipe(e)
+	r.loggerCore.AddCore(e.core)
+
+	return e
+}
+
+// Entries returns the channel the entries are delivered on. It is closed by
+// Close.
+func (e *EntryReader) Entries() <-chan LogEntry {
+	return e.ch
+}
+
+// Close unregisters the reader from the logger and closes its channel.
+func (e *EntryReader) Close() error {
+	e.once.Do(func() {
+		e.reg.loggerCore.DeleteCore(e.core)
+		e.reg.untrackPipe(e)
+
+		// Unblock the pending sends before closing the channel.
+		close(e.done)
+		e.mu.Lock()
+		e.closed = true
+		close(e.ch)
+		e.mu.Unlock()
+	})
+	return nil
+}
+
+func (e *EntryReader) send(entry LogEntry) {
+	e.mu.RLock()
+	defer e.mu.RUnlock()
+
+	if e.closed {
+		return
+	}
+	select {
+	case e.ch <- entry:
+	case <-e.done:
+	}
+}
+
+// entryCore decodes entries for an EntryReader.
+type entryCore struct {
+	zapcore.LevelEnabler
+	reader *EntryReader
+	bound  []zapcore.Field
+}
+
+var _ zapcore.Core = (*entryCore)(nil)
+
+func (c *entryCore) With(fields []zapcore.Field) zapcore.Core {
+	return &entryCore{
+		LevelEnabler: c.LevelEnabler,
+		reader:       c.reader,
+		bound:        append(c.bound[:len(c.bound):len(c.bound)], fields...),
+	}
+}
+
+func (c *entryCore) Check(ent zapcore.Entry, ce *zapcore.CheckedEntry) *zapcore.CheckedEntry {
+	if c.Enabled(ent.Level) {
+		return ce.AddCore(ent, c)
+	}
+	return ce
+}
+
+func (c *entryCore) Write(ent zapcore.Entry, fields []zapcore.Field) error {
+	enc := zapcore.NewMapObjectEncoder()
+	for _, f := range c.bound {
+		f.AddTo(enc)
+	}
+	for _, f := range fields {
+		f.AddTo(enc)
+	}
+	c.reader.send(LogEntry{
+		Time:      ent.Time,
+		Level:     LogLevel(ent.Level),
+		Subsystem: ent.LoggerName,
+		Message:   ent.Message,
+		Fields:    enc.Fields,
+	})
+	return nil
+}
+
+func (c *entryCore) Sync() error {
+	return nil
+}
@@ -0,0 +1,62 @@
+package log
+
+import (
+	"testing"
+	"time"
+)
+
+func TestEntryReader(t *testing.T) {
+	reg := NewRegistry(Config{Level: LevelDebug})
+	log := reg.Logger("bitswap")
+
+	r := reg.NewEntryReader(PipeLevel(LevelInfo))
+	log.Debug("hidden")
+	log.With("peer", "QmScooby").Infow("block received", "size", 42)
+
+	select {
+	case e := <-r.Entries():
+		if e.Subsystem != "bitswap" || e.Level != LevelInfo || e.Message != "block received" {
+			t.Errorf("got %+v", e)
+		}
+		if e.Fields["peer"] != "QmScooby" || e.Fields["size"] != int64(42) {
+			t.Errorf("got fields %v", e.Fields)
+		}
+		if time.Since(e.Time) > time.Minute {
+			t.Errorf("got time %v", e.Time)
+		}
+	case <-time.After(time.Second):
+		t.Fatal("no entry received")
+	}
+
+	if err := r.Close(); err != nil {
+		t.Fatal(err)
+	}
+	log.Info("after close")
+	if _, ok := <-r.Entries(); ok {
+		t.Error("expected the channel to be closed")
+	}
+}
+
+func TestEntryReaderCloseUnblocksLogging(t *testing.T) {
+	reg := NewRegistry(Config{Level: LevelInfo})
+	log := reg.Logger("bitswap")
+	r := reg.NewEntryReader()
+
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		for i := 0; i < entryReaderBuffer+1; i++ {
+			log.Info("flood")
+		}
+	}()
+
+	time.Sleep(10 * time.Millisecond)
+	if err := r.Close(); err != nil {
+		t.Fatal(err)
+	}
+	select {
+	case <-done:
+	case <-time.After(time.Second):
+		t.Fatal("logging still blocked after Close")
+	}
+}
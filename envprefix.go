@@ -0,0 +1,53 @@
+package log
+
+import (
+	"os"
+	"strconv"
+	"strings"
+	"sync/atomic"
+)
+
+// envPrefix, if set, replaces GOLOG in the names of the environment
+// variables. See SetEnvPrefix.
+var envPrefix atomic.Pointer[string]
+
+// SetEnvPrefix makes ConfigFromEnv read the environment variables prefixed
+// with prefix instead of GOLOG, e.g. MYAPP_LOG_LEVEL and MYAPP_LOG_FMT for
+// "MYAPP", so that programs embedding go-log don't pick up each other's
+// settings. The legacy IPFS_LOGGING and IPFS_LOGGING_FMT are ignored then.
+//
+// The package-level loggers are configured again the way they are on
+// import, from the renamed variables and the GOLOG_LOG_CONFIG file, which
+// keeps being watched. This replaces any configuration applied before, so
+// SetEnvPrefix is best called first thing in main. With GOLOG_NO_AUTO_INIT,
+// nothing is configured and applying ConfigFromEnv is left to the caller.
+// The settings only read on import, like GOLOG_NO_AUTO_INIT or
+// GOLOG_LOG_CONFIG, keep their names.
+func SetEnvPrefix(prefix string) {
+	envPrefix.Store(&prefix)
+	if noAutoInit, _ := strconv.ParseBool(os.Getenv(envNoAutoInit)); !noAutoInit {
+		setupFromEnv()
+	}
+}
+
+// envName returns the name of the environment variable name, a GOLOG_ one,
+// with the prefix set with SetEnvPrefix.
+func envName(name string) string {
+	prefix := envPrefix.Load()
+	if prefix == nil || *prefix == "" {
+		return name
+	}
+	if rest, ok := strings.CutPrefix(name, "GOLOG_"); ok {
+		return *prefix + "_" + rest
+	}
+	return ""
+}
+
+// getenv returns the value of the environment variable name, renamed by
+// envName.
+func getenv(name string) string {
+	if name = envName(name); name == "" {
+		return ""
+	}
+	return os.Getenv(name)
+}
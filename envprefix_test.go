@@ -0,0 +1,71 @@
+package log
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestEnvPrefix(t *testing.T) {
+	prefix := "MYAPP"
+	envPrefix.Store(&prefix)
+	defer envPrefix.Store(nil)
+
+	t.Setenv("GOLOG_LOG_LEVEL", "debug")
+	t.Setenv(envIPFSLogging, "debug")
+	t.Setenv("MYAPP_LOG_LEVEL", "warn,dht=info")
+	t.Setenv("MYAPP_LOG_FMT", "json")
+	t.Setenv("MYAPP_MAX_LOGGERS", "many")
+
+	cfg, err := ConfigFromEnv()
+	if err == nil || !strings.Contains(err.Error(), "MYAPP_MAX_LOGGERS") {
+		t.Errorf("got error %v", err)
+	}
+	if cfg.Level != LevelWarn || cfg.SubsystemLevels["dht"] != LevelInfo {
+		t.Errorf("got level %v and subsystem levels %v", cfg.Level, cfg.SubsystemLevels)
+	}
+	if cfg.Format != JSONOutput {
+		t.Errorf("got format %v", cfg.Format)
+	}
+}
+
+func TestEnvName(t *testing.T) {
+	if got := envName(envLogging); got != "GOLOG_LOG_LEVEL" {
+		t.Errorf("got %q without a prefix", got)
+	}
+	prefix := "MYAPP"
+	envPrefix.Store(&prefix)
+	defer envPrefix.Store(nil)
+	if got := envName(envLogging); got != "MYAPP_LOG_LEVEL" {
+		t.Errorf("got %q", got)
+	}
+	if got := envName(envIPFSLogging); got != "" {
+		t.Errorf("got %q for a legacy variable", got)
+	}
+}
+
+func TestSetEnvPrefixKeepsConfigFile(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "logging.json")
+	if err := os.WriteFile(path, []byte(`{"subsystems": {"dht": "debug"}}`), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	t.Setenv(envLoggingConfig, path)
+	t.Setenv("MYAPP_LOG_LEVEL", "warn")
+	defer func() {
+		envPrefix.Store(nil)
+		os.Unsetenv(envLoggingConfig)
+		setupFromEnv()
+	}()
+
+	SetEnvPrefix("MYAPP")
+	if lvl := DefaultLevel(); lvl != LevelWarn {
+		t.Errorf("got default level %s, want the one of MYAPP_LOG_LEVEL", lvl)
+	}
+	if lvl, _ := SubsystemLevel("dht"); lvl != LevelDebug {
+		t.Errorf("got level %s, want the one of the configuration file", lvl)
+	}
+	if stopEnvConfigWatch == nil {
+		t.Error("configuration file isn't watched")
+	}
+}
@@ -0,0 +1,72 @@
+package log
+
+import (
+	"errors"
+	"fmt"
+	"reflect"
+)
+
+// WithError returns a copy of the logger adding err to every entry as the
+// standard error fields: "error" holds the message, "error_type" the type of
+// the innermost wrapped error and, if an error in the chain carries a stack
+// trace (like those of github.com/pkg/errors), "stacktrace" holds it.
+func (logger *ZapEventLogger) WithError(err error) *ZapEventLogger {
+	return logger.With(errorFields(err)...)
+}
+
+// Errorw logs a message at error level with some additional context. Errors
+// passed under the "error" key are expanded into the standard error fields,
+// see WithError.
+func (logger *ZapEventLogger) Errorw(msg string, keysAndValues ...interface{}) {
+	logger.skipLogger.Errorw(msg, expandErrors(keysAndValues)...)
+}
+
+// expandErrors replaces errors under the "error" key with their fields.
+func expandErrors(kv []interface{}) []interface{} {
+	for i := 0; i+1 < len(kv); i += 2 {
+		if key, ok := kv[i].(string); !ok || key != "error" {
+			continue
+		}
+		err, ok := kv[i+1].(error)
+		if !ok || err == nil {
+			continue
+		}
+		expanded := make([]interface{}, 0, len(kv)+4)
+		expanded = append(expanded, kv[:i]...)
+		expanded = append(expanded, errorFields(err)...)
+		return append(expanded, expandErrors(kv[i+2:])...)
+	}
+	return kv
+}
+
+func errorFields(err error) []interface{} {
+	if err == nil {
+		return nil
+	}
+
+	root := err
+	var stack string
+	for e := err; e != nil; e = errors.Unwrap(e) {
+		root = e
+		if stack == "" {
+			stack = errorStack(e)
+		}
+	}
+
+	fields := []interface{}{"error", err.Error(), "error_type", fmt.Sprintf("%T", root)}
+	if stack != "" {
+		fields = append(fields, "stacktrace", stack)
+	}
+	return fields
+}
+
+// errorStack returns the stack trace carried by err, if it has a
+// StackTrace method as do the errors of github.com/pkg/errors and their
+// descendants.
+func errorStack(err error) string {
+	m := reflect.ValueOf(err).MethodByName("StackTrace")
+	if !m.IsValid() || m.Type().NumIn() != 0 || m.Type().NumOut() != 1 {
+		return ""
+	}
+	return fmt.Sprintf("%+v", m.Call(nil)[0].Interface())
+}
@@ -0,0 +1,58 @@
+package log
+
+import (
+	"fmt"
+	"io/fs"
+	"strings"
+	"testing"
+
+	"go.uber.org/zap/zapcore"
+	"go.uber.org/zap/zaptest/observer"
+)
+
+type stackError struct{}
+
+func (stackError) Error() string      { return "scooby" }
+func (stackError) StackTrace() string { return "main.go:42" }
+
+func TestWithError(t *testing.T) {
+	reg := NewRegistry(Config{Level: LevelInfo})
+	core, logs := observer.New(zapcore.DebugLevel)
+	reg.SetPrimaryCore(core)
+	log := reg.Logger("test")
+
+	log.WithError(fmt.Errorf("opening: %w", fs.ErrNotExist)).Info("doo")
+	log.Errorw("velma", "error", fmt.Errorf("wrapped: %w", stackError{}), "snacks", 2)
+	log.Errorw("daphne", "error", "not an error")
+
+	entries := logs.All()
+	if len(entries) != 3 {
+		t.Fatalf("got %d entries, want 3", len(entries))
+	}
+
+	fields := entries[0].ContextMap()
+	if fields["error"] != "opening: file does not exist" || fields["error_type"] != "*errors.errorString" {
+		t.Errorf("got fields %v", fields)
+	}
+	if _, ok := fields["stacktrace"]; ok {
+		t.Errorf("got a stacktrace for an error without one")
+	}
+
+	fields = entries[1].ContextMap()
+	if fields["error"] != "wrapped: scooby" || fields["error_type"] != "log.stackError" || fields["stacktrace"] != "main.go:42" || fields["snacks"] != int64(2) {
+		t.Errorf("got fields %v", fields)
+	}
+	if !strings.HasSuffix(entries[1].Caller.File, "errors_test.go") {
+		t.Errorf("got caller %v, want the test", entries[1].Caller)
+	}
+
+	if fields = entries[2].ContextMap(); fields["error"] != "not an error" {
+		t.Errorf("got fields %v", fields)
+	}
+}
+
+func TestErrorFieldsNil(t *testing.T) {
+	if fields := errorFields(nil); fields != nil {
+		t.Errorf("got %v, want no fields", fields)
+	}
+}
@@ -0,0 +1,123 @@
+package log
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"time"
+
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+)
+
+// EventSchemaVersion is the version of the layout of the entries written by
+// Event, carried in their "event_version" field. It is incremented whenever
+// that layout changes in a way consumers may need to adapt to.
+const EventSchemaVersion = 1
+
+// Event emits a structured event named name, e.g. "block.received", with the
+// fields attached to ctx with ContextWithFields and the given key-value pairs
+// (as accepted by Infow and friends). The name is carried in an "event"
+// field, alongside the schema version in "event_version".
+//
+// When an event file is configured with Config.EventFile or GOLOG_EVENT_FILE,
+// events go there, as JSON lines with UTC RFC 3339 timestamps, whatever the
+// level of the subsystem. Otherwise they are logged at info level.
+func (logger *ZapEventLogger) Event(ctx context.Context, name string, keysAndValues ...interface{}) {
+	ctxFields := contextFields(ctx)
+	kv := make([]interface{}, 0, 4+len(ctxFields)+len(keysAndValues))
+	kv = append(kv, "event", name, "event_version", EventSchemaVersion)
+	kv = append(kv, ctxFields...)
+	kv = append(kv, keysAndValues...)
+
+	if logger.reg != nil && logger.reg.events.write(logger.system, name, kv, logger.reg.redaction.load()) {
+		return
+	}
+	logger.skipLogger.Infow(name, kv...)
+}
+
+// eventSink is the event file of a registry.
+type eventSink struct {
+	clock zapcore.Clock // timestamps the events
+
+	mu           sync.RWMutex // guards the fields below
+	path         string
+	core         zapcore.Core
+	closeOutputs func()
+}
+
+// setFile switches to the event file at path. An empty path closes the
+// current file.
+func (s *eventSink) setFile(path string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if path == s.path && (path == "" || s.core != nil) {
+		return nil
+	}
+
+	var core zapcore.Core
+	var closeOutputs func()
+	if path != "" {
+		ws, closeFile, err := openSink(path, nil)
+		if err != nil {
+			return fmt.Errorf("failed to open event file: %w", err)
+		}
+		core = zapcore.NewCore(zapcore.NewJSONEncoder(eventEncoderConfig()), ws, zap.LevelEnablerFunc(func(zapcore.Level) bool { return true }))
+		closeOutputs = closeFile
+	}
+
+	if s.closeOutputs != nil {
+		_ = s.core.Sync()
+		s.closeOutputs()
+	}
+	s.path, s.core, s.closeOutputs = path, core, closeOutputs
+	return nil
+}
+
+func (s *eventSink) close() error {
+	return s.setFile("")
+}
+
+// write writes an event to the file, if one is configured.
+func (s *eventSink) write(system, name string, kv []interface{}, red *redactor) bool {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	if s.core == nil {
+		return false
+	}
+	fields := sweetenFields(kv)
+	if red != nil {
+		fields = red.fields(fields)
+	}
+	ent := zapcore.Entry{
+		LoggerName: system,
+		Level:      zapcore.InfoLevel,
+		Time:       s.clock.Now(),
+		Message:    name,
+	}
+	// Errors are not reported, like those of the other outputs.
+	_ = s.core.Write(ent, fields)
+	return true
+}
+
+// eventEncoderConfig encodes events with a fixed layout, independent of the
+// configured format: the name is in the "event" field, so there is neither
+// message nor level.
+func eventEncoderConfig() zapcore.EncoderConfig {
+	return zapcore.EncoderConfig{
+		TimeKey:       "ts",
+		NameKey:       "logger",
+		MessageKey:    zapcore.OmitKey,
+		LevelKey:      zapcore.OmitKey,
+		CallerKey:     zapcore.OmitKey,
+		StacktraceKey: zapcore.OmitKey,
+		LineEnding:    zapcore.DefaultLineEnding,
+		EncodeTime: func(t time.Time, enc zapcore.PrimitiveArrayEncoder) {
+			enc.AppendString(t.UTC().Format(time.RFC3339Nano))
+		},
+		EncodeDuration: zapcore.StringDurationEncoder,
+		EncodeName:     zapcore.FullNameEncoder,
+	}
+}
@@ -0,0 +1,98 @@
+package log
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"go.uber.org/zap/zapcore"
+	"go.uber.org/zap/zaptest/observer"
+)
+
+func TestEventFile(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "events")
+	reg := NewRegistry(Config{Level: LevelError, EventFile: path, Redact: []string{"secret"}})
+	log := reg.Logger("bitswap")
+
+	ctx := ContextWithFields(context.Background(), "peer", "QmScooby")
+	log.Event(ctx, "block.received", "cid", "QmDoo", "secret", "hunter2")
+	if err := reg.Close(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+
+	b, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	lines := bytes.Split(bytes.TrimSpace(b), []byte("\n"))
+	if len(lines) != 1 {
+		t.Fatalf("got %d events, want 1: %s", len(lines), b)
+	}
+	var ev map[string]interface{}
+	if err := json.Unmarshal(lines[0], &ev); err != nil {
+		t.Fatal(err)
+	}
+	for k, want := range map[string]interface{}{
+		"event":         "block.received",
+		"event_version": float64(EventSchemaVersion),
+		"logger":        "bitswap",
+		"peer":          "QmScooby",
+		"cid":           "QmDoo",
+		"secret":        redactedValue,
+	} {
+		if ev[k] != want {
+			t.Errorf("got %s %v, want %v", k, ev[k], want)
+		}
+	}
+	if _, err := time.Parse(time.RFC3339Nano, ev["ts"].(string)); err != nil {
+		t.Errorf("invalid timestamp: %v", err)
+	}
+	if _, ok := ev["msg"]; ok {
+		t.Error("expected no msg field")
+	}
+}
+
+func TestEventFileClock(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "events")
+	reg := NewRegistry(Config{Level: LevelError, EventFile: path, Clock: fixedClock(scoobyTime)})
+	reg.Logger("bitswap").Event(context.Background(), "block.received")
+	if err := reg.Close(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+
+	b, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var ev struct {
+		TS time.Time `json:"ts"`
+	}
+	if err := json.Unmarshal(b, &ev); err != nil {
+		t.Fatal(err)
+	}
+	if !ev.TS.Equal(scoobyTime) {
+		t.Errorf("got timestamp %s, want the one of the configured clock", ev.TS)
+	}
+}
+
+func TestEventWithoutFile(t *testing.T) {
+	reg := newRegistry()
+	core, logs := observer.New(zapcore.DebugLevel)
+	reg.SetPrimaryCore(core)
+	log := reg.Logger("bitswap")
+	reg.SetAllLoggers(LevelInfo)
+
+	log.Event(context.Background(), "block.received", "cid", "QmDoo")
+
+	entries := logs.All()
+	if len(entries) != 1 {
+		t.Fatalf("got %d entries, want 1", len(entries))
+	}
+	if e := entries[0]; e.Message != "block.received" || e.Level != zapcore.InfoLevel || e.ContextMap()["event"] != "block.received" {
+		t.Errorf("got %+v", e)
+	}
+}
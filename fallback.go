@@ -0,0 +1,106 @@
+package log
+
+import (
+	"fmt"
+	"os"
+	"sync"
+	"time"
+
+	"go.uber.org/multierr"
+	"go.uber.org/zap/zapcore"
+)
+
+const (
+	// fallbackThreshold is the number of consecutive failed writes after
+	// which the outputs are considered down and entries go straight to the
+	// fallback.
+	fallbackThreshold = 3
+
+	// fallbackRetryInterval is how often the outputs are tried again while
+	// they are down.
+	fallbackRetryInterval = 10 * time.Second
+)
+
+// openFallback wraps ws so that entries failing to be written to it go to
+// the output named by cfg.Fallback instead. Unless strict is set, a
+// fallback that can't be opened is skipped with a warning on stderr.
+func openFallback(ws zapcore.WriteSyncer, closeOutputs func(), cfg Config, strict bool, m *metrics) (zapcore.WriteSyncer, func(), error) {
+	if cfg.Fallback == "" {
+		return ws, closeOutputs, nil
+	}
+	fallback, closeFallback, err := openSink(cfg.Fallback, m)
+	if err != nil {
+		err = fmt.Errorf("unable to open fallback logging output: %w", err)
+		if strict {
+			return nil, nil, err
+		}
+		fmt.Fprintln(os.Stderr, err)
+		return ws, closeOutputs, nil
+	}
+	return newFallbackWriteSyncer(ws, fallback, cfg.Fallback, time.Now), closeAll(closeOutputs, closeFallback), nil
+}
+
+// fallbackWriteSyncer writes to its primary WriteSyncer, redirecting the
+// writes that fail to its fallback. Once the primary failed
+// fallbackThreshold times in a row, it is only tried again every
+// fallbackRetryInterval, and used again as soon as a write succeeds.
+type fallbackWriteSyncer struct {
+	primary  zapcore.WriteSyncer
+	fallback zapcore.WriteSyncer
+	name     string // of the fallback, for the warnings
+	now      func() time.Time
+
+	mu        sync.Mutex
+	failures  int
+	down      bool
+	nextRetry time.Time
+}
+
+func newFallbackWriteSyncer(primary, fallback zapcore.WriteSyncer, name string, now func() time.Time) *fallbackWriteSyncer {
+	return &fallbackWriteSyncer{
+		primary:  primary,
+		fallback: fallback,
+		name:     name,
+		now:      now,
+	}
+}
+
+func (w *fallbackWriteSyncer) Write(p []byte) (int, error) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	if w.down && w.now().Before(w.nextRetry) {
+		return w.fallback.Write(p)
+	}
+	n, err := w.primary.Write(p)
+	if err == nil {
+		if w.down {
+			fmt.Fprintln(os.Stderr, "logging outputs recovered, leaving fallback", w.name)
+		}
+		w.failures = 0
+		w.down = false
+		return n, nil
+	}
+
+	w.failures++
+	if !w.down && w.failures >= fallbackThreshold {
+		fmt.Fprintf(os.Stderr, "logging outputs failing, falling back to %s: %s\n", w.name, err)
+		w.down = true
+	}
+	if w.down {
+		w.nextRetry = w.now().Add(fallbackRetryInterval)
+	}
+	return w.fallback.Write(p)
+}
+
+func (w *fallbackWriteSyncer) Sync() error {
+	w.mu.Lock()
+	down := w.down
+	w.mu.Unlock()
+
+	if down {
+		return w.fallback.Sync()
+	}
+	// The fallback may hold entries from before a recovery.
+	return multierr.Combine(w.primary.Sync(), w.fallback.Sync())
+}
@@ -0,0 +1,82 @@
+package log
+
+import (
+	"bytes"
+	"errors"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"go.uber.org/zap/zapcore"
+)
+
+// brokenWriteSyncer fails all writes while broken is set.
+type brokenWriteSyncer struct {
+	bytes.Buffer
+	broken bool
+}
+
+func (w *brokenWriteSyncer) Write(p []byte) (int, error) {
+	if w.broken {
+		return 0, errors.New("read-only file system")
+	}
+	return w.Buffer.Write(p)
+}
+
+func (w *brokenWriteSyncer) Sync() error {
+	return nil
+}
+
+func TestFallback(t *testing.T) {
+	primary := &brokenWriteSyncer{}
+	var fallback bytes.Buffer
+	now := time.Now()
+	w := newFallbackWriteSyncer(primary, zapcore.AddSync(&fallback), "buffer", func() time.Time { return now })
+	write := func(s string) {
+		t.Helper()
+		if _, err := w.Write([]byte(s)); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	write("scooby\n")
+	primary.broken = true
+	for i := 0; i < fallbackThreshold; i++ {
+		write("shaggy\n")
+	}
+	if !w.down {
+		t.Fatal("expected the outputs to be down")
+	}
+
+	primary.broken = false
+	write("velma\n") // before the next retry
+	now = now.Add(fallbackRetryInterval)
+	write("daphne\n")
+	if w.down {
+		t.Error("expected the outputs to have recovered")
+	}
+
+	if got, want := primary.String(), "scooby\ndaphne\n"; got != want {
+		t.Errorf("got %q in the outputs, want %q", got, want)
+	}
+	if got, want := fallback.String(), "shaggy\nshaggy\nshaggy\nvelma\n"; got != want {
+		t.Errorf("got %q in the fallback, want %q", got, want)
+	}
+}
+
+func TestFallbackConfig(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "fallback.log")
+	t.Setenv(envFallback, path)
+	cfg, err := ConfigFromEnv()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if cfg.Fallback != path {
+		t.Errorf("got fallback %q", cfg.Fallback)
+	}
+
+	cfg.Fallback = "bogus://nowhere"
+	if err := newRegistry().SetupLoggingWithError(cfg); err == nil {
+		t.Error("expected an error for an unknown fallback scheme")
+	}
+}
@@ -0,0 +1,114 @@
+package log
+
+import (
+	"sync"
+	"sync/atomic"
+
+	"go.uber.org/zap/zapcore"
+)
+
+// OnFatal registers hook to be called when an entry is logged at fatal level,
+// after it was written to the outputs and before the process exits. This is
+// the place to flush buffers, close databases or push final metrics.
+//
+// Hooks run in the order they were registered. A panicking hook does not
+// prevent the others from running. The returned function unregisters the
+// hook.
+func OnFatal(hook func()) (cancel func()) {
+	return defaultRegistry.OnFatal(hook)
+}
+
+// OnFatal registers a fatal hook with this registry. See the package-level
+// OnFatal.
+func (r *Registry) OnFatal(hook func()) (cancel func()) {
+	return r.fatal.add(hook)
+}
+
+// SetPanicOnFatal makes entries logged at fatal level panic with the entry's
+// message instead of exiting the process, once the fatal hooks ran. This is
+// meant for tests exercising code paths that call Fatal.
+func SetPanicOnFatal(enabled bool) {
+	defaultRegistry.SetPanicOnFatal(enabled)
+}
+
+// SetPanicOnFatal sets whether fatal entries of this registry's loggers
+// panic. See the package-level SetPanicOnFatal.
+func (r *Registry) SetPanicOnFatal(enabled bool) {
+	var v uint32
+	if enabled {
+		v = 1
+	}
+	atomic.StoreUint32(&r.fatal.panics, v)
+}
+
+// fatalHooks is the core added to the entries logged at fatal level. Being
+// added last, it is written once the entry reached the outputs.
+type fatalHooks struct {
+	panics uint32 // atomic; non-zero if fatal entries panic
+
+	mu    sync.Mutex // guards hooks
+	hooks []*func()
+}
+
+var _ zapcore.Core = (*fatalHooks)(nil)
+
+func (f *fatalHooks) add(hook func()) (cancel func()) {
+	h := &hook
+
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	f.hooks = append(f.hooks, h)
+	return func() {
+		f.mu.Lock()
+		defer f.mu.Unlock()
+
+		for i, other := range f.hooks {
+			if other == h {
+				f.hooks = append(f.hooks[:i:i], f.hooks[i+1:]...)
+				return
+			}
+		}
+	}
+}
+
+func (f *fatalHooks) Enabled(lvl zapcore.Level) bool {
+	return lvl >= zapcore.FatalLevel
+}
+
+func (f *fatalHooks) With([]zapcore.Field) zapcore.Core {
+	return f
+}
+
+func (f *fatalHooks) Check(ent zapcore.Entry, ce *zapcore.CheckedEntry) *zapcore.CheckedEntry {
+	if f.Enabled(ent.Level) {
+		return ce.AddCore(ent, f)
+	}
+	return ce
+}
+
+func (f *fatalHooks) Write(ent zapcore.Entry, _ []zapcore.Field) error {
+	f.mu.Lock()
+	hooks := f.hooks
+	f.mu.Unlock()
+
+	for _, hook := range hooks {
+		runFatalHook(*hook)
+	}
+
+	if atomic.LoadUint32(&f.panics) != 0 {
+		panic(ent.Message)
+	}
+	return nil
+}
+
+func (f *fatalHooks) Sync() error {
+	return nil
+}
+
+func runFatalHook(hook func()) {
+	defer func() {
+		_ = recover()
+	}()
+	hook()
+}
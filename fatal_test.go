@@ -0,0 +1,48 @@
+package log
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+
+	"go.uber.org/zap/zapcore"
+)
+
+func TestOnFatal(t *testing.T) {
+	reg := NewRegistry(Config{Level: LevelError})
+	var buf bytes.Buffer
+	reg.SetPrimaryCore(zapcore.NewCore(zapcore.NewJSONEncoder(zapcore.EncoderConfig{MessageKey: "msg"}), zapcore.AddSync(&buf), zapcore.DebugLevel))
+	reg.SetPanicOnFatal(true)
+
+	var got []string
+	reg.OnFatal(func() {
+		if !strings.Contains(buf.String(), "scooby") {
+			t.Error("fatal hook ran before the entry was written")
+		}
+		got = append(got, "first")
+	})
+	reg.OnFatal(func() {
+		panic("ignored")
+	})
+	cancel := reg.OnFatal(func() {
+		got = append(got, "cancelled")
+	})
+	reg.OnFatal(func() {
+		got = append(got, "last")
+	})
+	cancel()
+
+	func() {
+		defer func() {
+			if r := recover(); r != "scooby" {
+				t.Errorf("got %v, want a panic with the message", r)
+			}
+		}()
+		reg.Logger("test").Fatal("scooby")
+		t.Error("Fatal returned")
+	}()
+
+	if len(got) != 2 || got[0] != "first" || got[1] != "last" {
+		t.Errorf("got %q, want [first last]", got)
+	}
+}
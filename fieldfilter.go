@@ -0,0 +1,205 @@
+package log
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"sync/atomic"
+
+	"go.uber.org/zap/zapcore"
+)
+
+// FieldFilter enables the entries carrying a field with a given value down to
+// a level, whatever the level of their subsystem. This allows debugging the
+// traffic of a single peer, for instance, without turning on debug logging
+// globally.
+type FieldFilter struct {
+	// Key and Value are the field to match. Values are compared to the
+	// string form of string, stringer, integer and boolean fields.
+	Key   string
+	Value string
+
+	// Level is the minimum level of the matching entries to log.
+	Level LogLevel
+}
+
+// ParseFieldFilter parses a field filter of the form "key=value:level", e.g.
+// "peer=QmScooby:debug", as accepted by GOLOG_FIELD_FILTERS.
+func ParseFieldFilter(s string) (FieldFilter, error) {
+	i := strings.LastIndexByte(s, ':')
+	if i < 0 {
+		return FieldFilter{}, fmt.Errorf("invalid field filter %q: missing level", s)
+	}
+	lvl, err := LevelFromString(s[i+1:])
+	if err != nil {
+		return FieldFilter{}, fmt.Errorf("invalid field filter %q: %w", s, err)
+	}
+	key, value, ok := strings.Cut(s[:i], "=")
+	if !ok || key == "" {
+		return FieldFilter{}, fmt.Errorf("invalid field filter %q: expected key=value:level", s)
+	}
+	return FieldFilter{Key: key, Value: value, Level: lvl}, nil
+}
+
+// String returns the filter in the form accepted by ParseFieldFilter.
+func (f FieldFilter) String() string {
+	return f.Key + "=" + f.Value + ":" + f.Level.String()
+}
+
+// SetFieldFilters replaces the field filters of the package-level loggers,
+// initially those of Config.FieldFilters. Passing none removes them.
+func SetFieldFilters(filters ...FieldFilter) {
+	defaultRegistry.SetFieldFilters(filters...)
+}
+
+// SetFieldFilters replaces the field filters of this registry. See the
+// package-level SetFieldFilters.
+func (r *Registry) SetFieldFilters(filters ...FieldFilter) {
+	r.filters.store(newFieldFilterSet(filters))
+}
+
+// fieldFiltersFromEnv parses the comma-separated field filters of
+// GOLOG_FIELD_FILTERS.
+func fieldFiltersFromEnv() ([]FieldFilter, error) {
+	env := getenv(envFieldFilters)
+	if env == "" {
+		return nil, nil
+	}
+	var filters []FieldFilter
+	for _, s := range strings.Split(env, ",") {
+		f, err := ParseFieldFilter(strings.TrimSpace(s))
+		if err != nil {
+			return nil, err
+		}
+		filters = append(filters, f)
+	}
+	return filters, nil
+}
+
+// fieldFilterSet is an immutable set of field filters.
+type fieldFilterSet struct {
+	filters []FieldFilter
+	min     zapcore.Level // lowest level of the filters
+}
+
+// newFieldFilterSet returns the set of filters, or nil if there are none.
+func newFieldFilterSet(filters []FieldFilter) *fieldFilterSet {
+	if len(filters) == 0 {
+		return nil
+	}
+	s := &fieldFilterSet{
+		filters: append([]FieldFilter(nil), filters...),
+		min:     zapcore.Level(filters[0].Level),
+	}
+	for _, f := range filters[1:] {
+		if zapcore.Level(f.Level) < s.min {
+			s.min = zapcore.Level(f.Level)
+		}
+	}
+	return s
+}
+
+// enables reports whether an entry at lvl could be enabled by a filter.
+func (s *fieldFilterSet) enables(lvl zapcore.Level) bool {
+	return s != nil && lvl >= s.min
+}
+
+// matches reports whether a filter enables an entry at lvl carrying the
+// given fields.
+func (s *fieldFilterSet) matches(lvl zapcore.Level, bound, fields []zapcore.Field) bool {
+	for _, f := range s.filters {
+		if lvl < zapcore.Level(f.Level) {
+			continue
+		}
+		if hasField(bound, f.Key, f.Value) || hasField(fields, f.Key, f.Value) {
+			return true
+		}
+	}
+	return false
+}
+
+func hasField(fields []zapcore.Field, key, value string) bool {
+	for _, f := range fields {
+		if f.Key != key {
+			continue
+		}
+		if s, ok := fieldValue(f); ok && s == value {
+			return true
+		}
+	}
+	return false
+}
+
+// fieldValue returns the string form of the value of f, if it has a simple
+// one.
+func fieldValue(f zapcore.Field) (string, bool) {
+	switch f.Type {
+	case zapcore.StringType:
+		return f.String, true
+	case zapcore.StringerType:
+		return fmt.Sprint(f.Interface), true
+	case zapcore.ByteStringType:
+		return string(f.Interface.([]byte)), true
+	case zapcore.Int64Type, zapcore.Int32Type, zapcore.Int16Type, zapcore.Int8Type:
+		return strconv.FormatInt(f.Integer, 10), true
+	case zapcore.Uint64Type, zapcore.Uint32Type, zapcore.Uint16Type, zapcore.Uint8Type, zapcore.UintptrType:
+		return strconv.FormatUint(uint64(f.Integer), 10), true
+	case zapcore.BoolType:
+		return strconv.FormatBool(f.Integer == 1), true
+	default:
+		return "", false
+	}
+}
+
+// fieldFilters holds the field filters of a registry, nil if there are none.
+type fieldFilters struct {
+	v atomic.Pointer[fieldFilterSet]
+}
+
+func (f *fieldFilters) load() *fieldFilterSet {
+	return f.v.Load()
+}
+
+func (f *fieldFilters) store(s *fieldFilterSet) {
+	f.v.Store(s)
+}
+
+// fieldFilterCore writes the entries it is given only if they match a field
+// filter, taking into account the fields bound to the logger.
+type fieldFilterCore struct {
+	core  zapcore.Core
+	set   *fieldFilterSet
+	bound []zapcore.Field
+}
+
+var _ zapcore.Core = (*fieldFilterCore)(nil)
+
+func (c *fieldFilterCore) Enabled(lvl zapcore.Level) bool {
+	return c.set.enables(lvl) && c.core.Enabled(lvl)
+}
+
+func (c *fieldFilterCore) With(fields []zapcore.Field) zapcore.Core {
+	return &fieldFilterCore{
+		core:  c.core.With(fields),
+		set:   c.set,
+		bound: append(c.bound[:len(c.bound):len(c.bound)], fields...),
+	}
+}
+
+func (c *fieldFilterCore) Check(ent zapcore.Entry, ce *zapcore.CheckedEntry) *zapcore.CheckedEntry {
+	if c.Enabled(ent.Level) {
+		return ce.AddCore(ent, c)
+	}
+	return ce
+}
+
+func (c *fieldFilterCore) Write(ent zapcore.Entry, fields []zapcore.Field) error {
+	if !c.set.matches(ent.Level, c.bound, fields) {
+		return nil
+	}
+	return c.core.Write(ent, fields)
+}
+
+func (c *fieldFilterCore) Sync() error {
+	return c.core.Sync()
+}
@@ -0,0 +1,79 @@
+package log
+
+import (
+	"testing"
+
+	"go.uber.org/zap/zapcore"
+	"go.uber.org/zap/zaptest/observer"
+)
+
+func TestParseFieldFilter(t *testing.T) {
+	f, err := ParseFieldFilter("addr=/ip6/::1/tcp/4001:debug")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if f.Key != "addr" || f.Value != "/ip6/::1/tcp/4001" || f.Level != LevelDebug {
+		t.Errorf("got %+v", f)
+	}
+	if f.String() != "addr=/ip6/::1/tcp/4001:debug" {
+		t.Errorf("got %q", f.String())
+	}
+
+	for _, s := range []string{"peer=QmScooby", "peer=QmScooby:loud", "QmScooby:debug", "=QmScooby:debug"} {
+		if _, err := ParseFieldFilter(s); err == nil {
+			t.Errorf("expected an error for %q", s)
+		}
+	}
+}
+
+func TestFieldFilters(t *testing.T) {
+	skipWithoutDebug(t)
+
+	reg := newRegistry()
+	core, logs := observer.New(zapcore.DebugLevel)
+	reg.SetPrimaryCore(core)
+	log := reg.Logger("bitswap")
+	reg.SetAllLoggers(LevelInfo)
+	reg.SetFieldFilters(FieldFilter{Key: "peer", Value: "QmScooby", Level: LevelDebug})
+
+	log.Debugw("call-site field", "peer", "QmScooby")
+	log.Debugw("other peer", "peer", "QmShaggy")
+	log.With("peer", "QmScooby").Debug("bound field")
+	log.Infow("info", "peer", "QmShaggy")
+	log.Desugar().With(zapcore.Field{Key: "peer", Type: zapcore.StringerType, Interface: stringer("QmScooby")}).Debug("stringer")
+
+	var got []string
+	for _, e := range logs.All() {
+		got = append(got, e.Message)
+	}
+	want := []string{"call-site field", "bound field", "info", "stringer"}
+	if len(got) != len(want) {
+		t.Fatalf("got %q, want %q", got, want)
+	}
+	for i := range want {
+		if got[i] != want[i] {
+			t.Errorf("got %q, want %q", got, want)
+		}
+	}
+
+	reg.SetFieldFilters()
+	log.Debugw("call-site field", "peer", "QmScooby")
+	if n := logs.Len(); n != len(want) {
+		t.Errorf("got %d entries after removing the filters, want %d", n, len(want))
+	}
+}
+
+func TestFieldFiltersFromEnv(t *testing.T) {
+	t.Setenv(envFieldFilters, "peer=QmScooby:debug, conn=42:trace")
+	cfg, err := ConfigFromEnv()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(cfg.FieldFilters) != 2 || cfg.FieldFilters[1] != (FieldFilter{Key: "conn", Value: "42", Level: LevelTrace}) {
+		t.Errorf("got %+v", cfg.FieldFilters)
+	}
+}
+
+type stringer string
+
+func (s stringer) String() string { return string(s) }
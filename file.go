@@ -0,0 +1,749 @@
+package log
+
+import (
+	"compress/gzip"
+	"fmt"
+	"io"
+	"math"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"go.uber.org/multierr"
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+)
+
+// FileOutput is an additional log file, with its own level and format. See
+// Config.Files.
+type FileOutput struct {
+	// Path is the path of the file. Like Config.File, it may contain date
+	// directives.
+	Path string
+
+	// Level, if set, is the minimum level of the entries written to the
+	// file. Entries must be enabled by the level of their subsystem in the
+	// first place.
+	Level *LogLevel
+
+	// Format, if set, overrides Config.Format for this file.
+	Format *LogFormat
+
+	// MaxSize, if positive, is the size in bytes past which the file is
+	// rotated: it is renamed with a ".1" suffix, shifting older backups to
+	// ".2" and so on, and a new file is started.
+	MaxSize int64
+
+	// MaxBackups, if positive, is the number of rotated files to keep.
+	// Older ones are deleted. Zero keeps them all.
+	MaxBackups int
+
+	// Retention, if positive, is how long the files of a dated Path are
+	// kept. See Config.FileRetention.
+	Retention time.Duration
+
+	// Compression is the compression the file is written with.
+	Compression Compression
+
+	// CompressBackups gzips the rotated files, and the previous files of
+	// a dated Path, adding a ".gz" suffix. It is ignored if the file is
+	// compressed already.
+	CompressBackups bool
+
+	// Mode, if set, is the permissions of the file, applied to existing
+	// files too. Defaults to 0666 before the umask.
+	Mode os.FileMode
+
+	// CreateDirs creates the missing parent directories of the file. See
+	// Config.FileCreateDirs.
+	CreateDirs bool
+
+	// MaxTotalSize and MinFreeSpace, if positive, guard the disk. See
+	// Config.FileMaxTotalSize and Config.FileMinFreeSpace.
+	MaxTotalSize int64
+	MinFreeSpace int64
+}
+
+// Compression is the compression of a log file.
+type Compression int
+
+const (
+	// NoCompression writes the file as is.
+	NoCompression Compression = iota
+	// GzipCompression writes the file as a gzip stream, flushed whenever
+	// the outputs are synced. Appending to an existing file starts a new
+	// gzip member, which gzip readers handle transparently.
+	GzipCompression
+)
+
+// parseCompression parses the name of a compression as accepted by
+// GOLOG_FILE_COMPRESSION.
+func parseCompression(s string) (Compression, error) {
+	switch s {
+	case "", "none":
+		return NoCompression, nil
+	case "gzip":
+		return GzipCompression, nil
+	default:
+		return NoCompression, fmt.Errorf("unrecognized compression %q", s)
+	}
+}
+
+// parseSize parses a size in bytes as accepted by GOLOG_FILE_MAX_TOTAL_SIZE,
+// optionally suffixed with K, M, G or T for binary multiples, e.g. "512M".
+func parseSize(s string) (int64, error) {
+	if s == "" {
+		return 0, fmt.Errorf("empty size")
+	}
+	shift := 0
+	switch strings.ToUpper(s[len(s)-1:]) {
+	case "K":
+		shift = 10
+	case "M":
+		shift = 20
+	case "G":
+		shift = 30
+	case "T":
+		shift = 40
+	}
+	if shift > 0 {
+		s = s[:len(s)-1]
+	}
+	n, err := strconv.ParseInt(s, 10, 64)
+	if err != nil || n < 0 || n > math.MaxInt64>>shift {
+		return 0, fmt.Errorf("invalid size %q", s)
+	}
+	return n << shift, nil
+}
+
+// openedFile is an opened FileOutput.
+type openedFile struct {
+	path   string
+	ws     zapcore.WriteSyncer
+	level  LogLevel
+	format LogFormat
+}
+
+// openFiles opens the additional files of cfg. Unless strict is set, files
+// that can't be opened are skipped with a warning on stderr. The returned
+// function closes them.
+func openFiles(cfg Config, strict bool, m *metrics) ([]openedFile, func(), error) {
+	var files []openedFile
+	var closers []func()
+	for _, out := range cfg.Files {
+		f, err := openRotatingFile(out, m)
+		if err != nil {
+			err = fmt.Errorf("failed to open log file: %w", err)
+			if strict {
+				closeAll(closers...)()
+				return nil, nil, err
+			}
+			fmt.Fprintln(os.Stderr, err)
+			continue
+		}
+		opened := openedFile{
+			path:   out.Path,
+			ws:     &countingWriteSyncer{WriteSyncer: f, m: m},
+			level:  LevelTrace,
+			format: cfg.Format,
+		}
+		if out.Level != nil {
+			opened.level = *out.Level
+		}
+		if out.Format != nil {
+			opened.format = *out.Format
+		}
+		files = append(files, opened)
+		closers = append(closers, func() { _ = f.Close() })
+	}
+	return files, closeAll(closers...), nil
+}
+
+// openFile adds cfg.File, encrypted if cfg.FileKey is set, to the outputs
+// opened so far.
+func openFile(ws zapcore.WriteSyncer, closeOutputs func(), cfg Config, m *metrics) (zapcore.WriteSyncer, func(), error) {
+	f, err := openRotatingFile(FileOutput{
+		Path:         cfg.File,
+		Retention:    cfg.FileRetention,
+		Compression:  cfg.FileCompression,
+		Mode:         cfg.FileMode,
+		CreateDirs:   cfg.FileCreateDirs,
+		MaxTotalSize: cfg.FileMaxTotalSize,
+		MinFreeSpace: cfg.FileMinFreeSpace,
+	}, m)
+	if err != nil {
+		closeOutputs()
+		return nil, nil, err
+	}
+	var file zapcore.WriteSyncer = f
+	if len(cfg.FileKey) > 0 {
+		aead, err := newLogAEAD(cfg.FileKey)
+		if err != nil {
+			f.Close()
+			closeOutputs()
+			return nil, nil, err
+		}
+		file = &encryptingWriteSyncer{WriteSyncer: f, aead: aead}
+	}
+	return zapcore.NewMultiWriteSyncer(ws, file), func() {
+		closeOutputs()
+		_ = f.Close()
+	}, nil
+}
+
+// openSink opens stdout, stderr, a URL with a scheme registered with
+// zap.RegisterSink, or else a file path. Files are opened like cfg.File
+// rather than with zap.Open, which would take the drive of a Windows path
+// like C:\logs\ipfs.log for a URL scheme.
+func openSink(target string, m *metrics) (zapcore.WriteSyncer, func(), error) {
+	if target == "stdout" || target == "stderr" || strings.Contains(target, "://") {
+		return zap.Open(target)
+	}
+	f, err := openRotatingFile(FileOutput{Path: target}, m)
+	if err != nil {
+		return nil, nil, err
+	}
+	return f, func() { _ = f.Close() }, nil
+}
+
+// defaultFileMode is the mode of log files unless configured otherwise,
+// before the umask is applied, like the files opened by zap.
+const defaultFileMode os.FileMode = 0o666
+
+// fileCheckInterval is how often a log file is checked for having been
+// moved, deleted or truncated by an external tool like logrotate.
+const fileCheckInterval = time.Second
+
+// dirMode returns the mode of the directories created for files with the
+// given mode: the same permissions, plus search where reading is allowed.
+func dirMode(mode os.FileMode) os.FileMode {
+	return mode | (mode&0o444)>>2
+}
+
+// rotatingFile is a log file rotated by size and, if its path is a date
+// template, by time.
+type rotatingFile struct {
+	template string // the path with its date directives, if any
+	opts     FileOutput
+	m        *metrics // nil in tests
+	now      func() time.Time
+
+	mu        sync.Mutex // guards the fields below
+	path      string     // the current path
+	nextRoll  time.Time  // when the path of a dated file changes
+	f         *os.File
+	gz        *gzip.Writer // writes to f, with GzipCompression
+	size      int64        // bytes written to f
+	lastCheck time.Time    // when f was last checked, see checkReplaced and checkSpace
+	suspended bool         // set while the disk guard is tripped
+}
+
+var _ zapcore.WriteSyncer = (*rotatingFile)(nil)
+
+func openRotatingFile(out FileOutput, m *metrics) (*rotatingFile, error) {
+	return openRotatingFileAt(out, m, time.Now)
+}
+
+func openRotatingFileAt(out FileOutput, m *metrics, now func() time.Time) (*rotatingFile, error) {
+	path, err := filepath.Abs(out.Path)
+	if err != nil {
+		return nil, err
+	}
+	r := &rotatingFile{
+		path: path,
+		opts: out,
+		m:    m,
+		now:  now,
+	}
+	if isDateTemplate(path) {
+		r.template = path
+		t := now()
+		r.path = expandDate(path, t)
+		r.nextRoll = nextRoll(path, t)
+		r.removeExpired(t)
+	}
+	if err := r.open(); err != nil {
+		return nil, err
+	}
+	return r, nil
+}
+
+// mode returns the permissions of the file and its backups.
+func (r *rotatingFile) mode() os.FileMode {
+	if mode := r.opts.Mode.Perm(); mode != 0 {
+		return mode
+	}
+	return defaultFileMode
+}
+
+// open opens the file for appending. r.mu must be held, or r not shared yet.
+func (r *rotatingFile) open() error {
+	mode := r.mode()
+	if r.opts.CreateDirs {
+		if err := os.MkdirAll(filepath.Dir(r.path), dirMode(mode)); err != nil {
+			return err
+		}
+	}
+	f, err := os.OpenFile(r.path, os.O_WRONLY|os.O_APPEND|os.O_CREATE, mode)
+	if err != nil {
+		return err
+	}
+	if r.opts.Mode != 0 {
+		// The file may predate the setting; errors, e.g. because the
+		// file belongs to another user, are not worth failing for.
+		_ = f.Chmod(mode)
+	}
+	info, err := f.Stat()
+	if err != nil {
+		f.Close()
+		return err
+	}
+	r.f, r.size = f, info.Size()
+	if r.opts.Compression == GzipCompression {
+		r.gz = gzip.NewWriter(sizeWriter{r})
+	}
+	return nil
+}
+
+// closeFile closes the current file. r.mu must be held.
+func (r *rotatingFile) closeFile() error {
+	var err error
+	if r.gz != nil {
+		err = r.gz.Close()
+		r.gz = nil
+	}
+	err = multierr.Append(err, r.f.Close())
+	r.f = nil
+	return err
+}
+
+// sizeWriter writes to the file of a rotatingFile, counting the bytes
+// written.
+type sizeWriter struct {
+	r *rotatingFile
+}
+
+func (w sizeWriter) Write(p []byte) (int, error) {
+	n, err := w.r.f.Write(p)
+	w.r.size += int64(n)
+	return n, err
+}
+
+func (r *rotatingFile) Write(p []byte) (int, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if r.f == nil {
+		return 0, os.ErrClosed
+	}
+	now := r.now()
+	if r.template != "" && !now.Before(r.nextRoll) {
+		if err := r.roll(now); err != nil {
+			return 0, err
+		}
+	}
+	if now.Sub(r.lastCheck) >= fileCheckInterval {
+		r.lastCheck = now
+		if err := r.checkReplaced(); err != nil {
+			return 0, err
+		}
+		r.checkSpace()
+	}
+	if r.suspended {
+		if r.m != nil {
+			r.m.addDropped(1)
+		}
+		return len(p), nil
+	}
+	if r.opts.MaxSize > 0 && r.size > 0 && r.size+int64(len(p)) > r.opts.MaxSize {
+		if err := r.rotate(); err != nil {
+			return 0, err
+		}
+	}
+
+	n, err := r.write(p)
+	if err != nil {
+		// The file may have gone stale, e.g. on NFS: retry once with a
+		// fresh one.
+		if reopenErr := r.reopen(); reopenErr == nil {
+			n, err = r.write(p)
+		}
+	}
+	return n, err
+}
+
+// write writes to the current file. r.mu must be held.
+func (r *rotatingFile) write(p []byte) (int, error) {
+	if r.gz != nil {
+		return r.gz.Write(p)
+	}
+	return sizeWriter{r}.Write(p)
+}
+
+// checkReplaced reopens the file if it was moved or deleted, and accounts
+// for it having been truncated, as external tools like logrotate do. r.mu
+// must be held.
+func (r *rotatingFile) checkReplaced() error {
+	info, err := os.Stat(r.path)
+	if os.IsNotExist(err) {
+		return r.reopen()
+	} else if err != nil {
+		return nil // keep writing to the file we have
+	}
+	current, err := r.f.Stat()
+	if err != nil {
+		return nil
+	}
+	if !os.SameFile(info, current) {
+		return r.reopen()
+	}
+	if info.Size() < r.size {
+		r.size = info.Size()
+	}
+	return nil
+}
+
+// checkSpace suspends writing while the files take more than MaxTotalSize
+// or the disk has less than MinFreeSpace available, and resumes once they
+// don't. Both transitions are reported on stderr. r.mu must be held.
+func (r *rotatingFile) checkSpace() {
+	if r.opts.MaxTotalSize <= 0 && r.opts.MinFreeSpace <= 0 {
+		return
+	}
+	var reason string
+	if r.opts.MaxTotalSize > 0 {
+		if total := r.totalSize(); total > r.opts.MaxTotalSize {
+			reason = fmt.Sprintf("log files take %d bytes, more than the maximum of %d", total, r.opts.MaxTotalSize)
+		}
+	}
+	if r.opts.MinFreeSpace > 0 {
+		if free, ok := freeSpace(filepath.Dir(r.path)); ok && free < r.opts.MinFreeSpace {
+			reason = fmt.Sprintf("%d bytes free on disk, less than the minimum of %d", free, r.opts.MinFreeSpace)
+		}
+	}
+
+	switch suspend := reason != ""; {
+	case suspend && !r.suspended:
+		fmt.Fprintf(os.Stderr, "suspending logging to %s: %s\n", r.path, reason)
+		if r.m != nil {
+			r.m.addSuspension()
+		}
+	case !suspend && r.suspended:
+		fmt.Fprintf(os.Stderr, "resuming logging to %s\n", r.path)
+	}
+	r.suspended = reason != ""
+}
+
+// totalSize returns the size of the file along with its backups and, if
+// dated, the files of other dates.
+func (r *rotatingFile) totalSize() int64 {
+	var total int64
+	for _, m := range r.files() {
+		if info, err := os.Stat(m); err == nil && info.Mode().IsRegular() {
+			total += info.Size()
+		}
+	}
+	return total
+}
+
+// files returns the paths of the file and its backups and, if dated, of the
+// files of other dates and their backups.
+func (r *rotatingFile) files() []string {
+	template := r.template
+	if template == "" {
+		// Not a template: any % in the path is literal.
+		template = strings.ReplaceAll(r.path, "%", "%%")
+	}
+	return dateFiles(template)
+}
+
+// reopen closes and reopens the file at its path. r.mu must be held.
+func (r *rotatingFile) reopen() error {
+	if r.f != nil {
+		_ = r.closeFile()
+	}
+	return r.open()
+}
+
+// rotate renames the file and its backups and starts a new file. r.mu must
+// be held.
+func (r *rotatingFile) rotate() error {
+	if err := r.closeFile(); err != nil {
+		return err
+	}
+
+	if r.opts.MaxBackups > 0 {
+		_ = os.Remove(r.backup(r.opts.MaxBackups))
+	}
+	last := r.opts.MaxBackups
+	if last <= 0 {
+		// Keep them all: shift up to the first free slot.
+		for last = 1; ; last++ {
+			if _, err := os.Lstat(r.backup(last)); os.IsNotExist(err) {
+				break
+			}
+		}
+	}
+	for i := last - 1; i >= 1; i-- {
+		if err := os.Rename(r.backup(i), r.backup(i+1)); err != nil && !os.IsNotExist(err) {
+			return err
+		}
+	}
+	if err := r.archive(r.path, r.backup(1)); err != nil {
+		return err
+	}
+	return r.open()
+}
+
+// roll switches a dated file to the path for now, and removes the expired
+// files. r.mu must be held.
+func (r *rotatingFile) roll(now time.Time) error {
+	r.nextRoll = nextRoll(r.template, now)
+	path := expandDate(r.template, now)
+	if path == r.path {
+		return nil
+	}
+	if err := r.closeFile(); err != nil {
+		return err
+	}
+	if r.compressesBackups() {
+		if err := r.archive(r.path, r.path+".gz"); err != nil {
+			return err
+		}
+	}
+	r.path = path
+	r.removeExpired(now)
+	return r.open()
+}
+
+// compressesBackups reports whether the files are compressed once rotated,
+// which is pointless if they are compressed already.
+func (r *rotatingFile) compressesBackups() bool {
+	return r.opts.CompressBackups && r.opts.Compression == NoCompression
+}
+
+// archive moves the file at path to dst, compressing it if configured.
+func (r *rotatingFile) archive(path, dst string) error {
+	if !r.compressesBackups() {
+		if err := os.Rename(path, dst); err != nil && !os.IsNotExist(err) {
+			return err
+		}
+		return nil
+	}
+
+	src, err := os.Open(path)
+	if os.IsNotExist(err) {
+		return nil
+	} else if err != nil {
+		return err
+	}
+	defer src.Close()
+	out, err := os.OpenFile(dst, os.O_WRONLY|os.O_CREATE|os.O_TRUNC, r.mode())
+	if err != nil {
+		return err
+	}
+	if r.opts.Mode != 0 {
+		// dst may predate the setting, like the file in open.
+		_ = out.Chmod(r.mode())
+	}
+	gz := gzip.NewWriter(out)
+	_, err = io.Copy(gz, src)
+	err = multierr.Combine(err, gz.Close(), out.Close())
+	if err != nil {
+		_ = os.Remove(dst)
+		return err
+	}
+	return os.Remove(path)
+}
+
+// removeExpired removes the files of a dated file, and their backups, last
+// modified longer than the retention period ago.
+func (r *rotatingFile) removeExpired(now time.Time) {
+	if r.opts.Retention <= 0 {
+		return
+	}
+	for _, m := range dateFiles(r.template) {
+		if m == filepath.Clean(r.path) {
+			continue
+		}
+		if info, err := os.Stat(m); err == nil && info.Mode().IsRegular() && now.Sub(info.ModTime()) > r.opts.Retention {
+			_ = os.Remove(m)
+		}
+	}
+}
+
+func (r *rotatingFile) backup(i int) string {
+	name := r.path + "." + strconv.Itoa(i)
+	if r.compressesBackups() {
+		name += ".gz"
+	}
+	return name
+}
+
+func (r *rotatingFile) Sync() error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if r.f == nil {
+		return os.ErrClosed
+	}
+	if r.gz != nil {
+		if err := r.gz.Flush(); err != nil {
+			return err
+		}
+	}
+	return r.f.Sync()
+}
+
+func (r *rotatingFile) Close() error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if r.f == nil {
+		return nil
+	}
+	return r.closeFile()
+}
+
+// dateDirectives are the strftime-like directives expanded in date
+// templates: %Y (year), %m (month), %d (day), %H (hour) and %M (minute).
+const dateDirectives = "YmdHM"
+
+// hasDateDirective reports whether template contains any of directives,
+// not counting escaped %% sequences.
+func hasDateDirective(template, directives string) bool {
+	for i := 0; i+1 < len(template); i++ {
+		if template[i] != '%' {
+			continue
+		}
+		if template[i+1] == '%' {
+			i++
+			continue
+		}
+		if strings.IndexByte(directives, template[i+1]) >= 0 {
+			return true
+		}
+	}
+	return false
+}
+
+// isDateTemplate reports whether path contains date directives. Paths
+// without any are used verbatim, whatever other '%' they contain.
+func isDateTemplate(path string) bool {
+	return hasDateDirective(path, dateDirectives)
+}
+
+// expandDate replaces the date directives of template with the date t and
+// %% with %. Any other '%' is kept as is.
+func expandDate(template string, t time.Time) string {
+	var b strings.Builder
+	for i := 0; i < len(template); i++ {
+		c := template[i]
+		if c != '%' || i+1 == len(template) {
+			b.WriteByte(c)
+			continue
+		}
+		switch template[i+1] {
+		case 'Y':
+			fmt.Fprintf(&b, "%04d", t.Year())
+		case 'm':
+			fmt.Fprintf(&b, "%02d", int(t.Month()))
+		case 'd':
+			fmt.Fprintf(&b, "%02d", t.Day())
+		case 'H':
+			fmt.Fprintf(&b, "%02d", t.Hour())
+		case 'M':
+			fmt.Fprintf(&b, "%02d", t.Minute())
+		case '%':
+			b.WriteByte('%')
+		default:
+			b.WriteByte(c)
+			continue
+		}
+		i++
+	}
+	return b.String()
+}
+
+// dateGlob returns a glob pattern matching the expansions of template.
+func dateGlob(template string) string {
+	var b strings.Builder
+	for i := 0; i < len(template); i++ {
+		c := template[i]
+		if c != '%' || i+1 == len(template) {
+			b.WriteByte(c)
+			continue
+		}
+		switch {
+		case template[i+1] == '%':
+			b.WriteByte('%')
+		case strings.IndexByte(dateDirectives, template[i+1]) >= 0:
+			b.WriteByte('*')
+		default:
+			b.WriteByte(c)
+			continue
+		}
+		i++
+	}
+	return b.String()
+}
+
+// dateRegexp returns a regular expression matching the expansions of the
+// cleaned template exactly, along with their numbered and compressed
+// backups, so that unrelated files next to them are left alone.
+func dateRegexp(template string) *regexp.Regexp {
+	template = filepath.Clean(template)
+	var b strings.Builder
+	b.WriteByte('^')
+	for i := 0; i < len(template); i++ {
+		c := template[i]
+		if c == '%' && i+1 < len(template) {
+			switch template[i+1] {
+			case 'Y':
+				b.WriteString(`\d{4}`)
+				i++
+				continue
+			case 'm', 'd', 'H', 'M':
+				b.WriteString(`\d{2}`)
+				i++
+				continue
+			case '%':
+				i++
+			}
+		}
+		b.WriteString(regexp.QuoteMeta(string(c)))
+	}
+	b.WriteString(`(\.\d+)?(\.gz)?$`)
+	return regexp.MustCompile(b.String())
+}
+
+// dateFiles returns the paths of the expansions of template that exist,
+// along with their backups.
+func dateFiles(template string) []string {
+	re := dateRegexp(template)
+	candidates, _ := filepath.Glob(dateGlob(template) + "*")
+	var files []string
+	for _, c := range candidates {
+		if re.MatchString(c) {
+			files = append(files, c)
+		}
+	}
+	return files
+}
+
+// nextRoll returns when the expansion of template changes after t: at the
+// next minute or hour if it has such directives, at midnight otherwise.
+func nextRoll(template string, t time.Time) time.Time {
+	switch {
+	case hasDateDirective(template, "M"):
+		return t.Truncate(time.Minute).Add(time.Minute)
+	case hasDateDirective(template, "H"):
+		return time.Date(t.Year(), t.Month(), t.Day(), t.Hour()+1, 0, 0, 0, t.Location())
+	default:
+		return time.Date(t.Year(), t.Month(), t.Day()+1, 0, 0, 0, 0, t.Location())
+	}
+}
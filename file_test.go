@@ -0,0 +1,501 @@
+package log
+
+import (
+	"compress/gzip"
+	"context"
+	"io"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestFiles(t *testing.T) {
+	skipWithoutDebug(t)
+
+	dir := t.TempDir()
+	errorsLog := filepath.Join(dir, "errors.log")
+	fullLog := filepath.Join(dir, "full.log")
+	warn, json := LevelWarn, JSONOutput
+
+	reg := NewRegistry(Config{
+		Format: PlaintextOutput,
+		Level:  LevelDebug,
+		Files: []FileOutput{
+			{Path: errorsLog, Level: &warn, Format: &json},
+			{Path: fullLog},
+		},
+	})
+	log := reg.Logger("bitswap")
+	log.Debug("debug")
+	log.Warn("warning")
+	if outputs := reg.GetSnapshot().Outputs; len(outputs) != 2 || outputs[1] != fullLog {
+		t.Errorf("got outputs %v", outputs)
+	}
+	if err := reg.Close(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+
+	errs := readLines(t, errorsLog)
+	if len(errs) != 1 || !strings.HasPrefix(errs[0], "{") || !strings.Contains(errs[0], "warning") {
+		t.Errorf("got %q, want the warning as JSON", errs)
+	}
+	full := readLines(t, fullLog)
+	if len(full) != 2 || !strings.Contains(full[0], "DEBUG") || strings.HasPrefix(full[0], "{") {
+		t.Errorf("got %q, want both entries as plain text", full)
+	}
+}
+
+func TestRotatingFile(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "ipfs.log")
+	f, err := openRotatingFile(FileOutput{Path: path, MaxSize: 10, MaxBackups: 2}, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, s := range []string{"scooby\n", "shaggy\n", "velma\n", "daphne\n"} {
+		if _, err := f.Write([]byte(s)); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := f.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	for name, want := range map[string]string{
+		path:        "daphne\n",
+		path + ".1": "velma\n",
+		path + ".2": "shaggy\n",
+	} {
+		b, err := os.ReadFile(name)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if string(b) != want {
+			t.Errorf("got %q in %s, want %q", b, name, want)
+		}
+	}
+	if _, err := os.Stat(path + ".3"); !os.IsNotExist(err) {
+		t.Error("expected the oldest backup to be deleted")
+	}
+}
+
+func TestDatedFile(t *testing.T) {
+	dir := t.TempDir()
+	now := time.Date(2021, 3, 14, 23, 59, 0, 0, time.Local)
+
+	expired := filepath.Join(dir, "ipfs-2021-03-01.log")
+	kept := filepath.Join(dir, "ipfs-2021-03-10.log")
+	for _, p := range []string{expired, expired + ".1", kept} {
+		if err := os.WriteFile(p, nil, 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	for _, p := range []string{expired, expired + ".1"} {
+		if err := os.Chtimes(p, now.AddDate(0, 0, -13), now.AddDate(0, 0, -13)); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := os.Chtimes(kept, now.AddDate(0, 0, -4), now.AddDate(0, 0, -4)); err != nil {
+		t.Fatal(err)
+	}
+
+	f, err := openRotatingFileAt(FileOutput{
+		Path:      filepath.Join(dir, "ipfs-%Y-%m-%d.log"),
+		Retention: 7 * 24 * time.Hour,
+	}, nil, func() time.Time { return now })
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := f.Write([]byte("scooby\n")); err != nil {
+		t.Fatal(err)
+	}
+	now = now.Add(2 * time.Minute)
+	if _, err := f.Write([]byte("shaggy\n")); err != nil {
+		t.Fatal(err)
+	}
+	if err := f.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	for name, want := range map[string]string{
+		"ipfs-2021-03-14.log": "scooby\n",
+		"ipfs-2021-03-15.log": "shaggy\n",
+	} {
+		b, err := os.ReadFile(filepath.Join(dir, name))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if string(b) != want {
+			t.Errorf("got %q in %s, want %q", b, name, want)
+		}
+	}
+	for _, p := range []string{expired, expired + ".1"} {
+		if _, err := os.Stat(p); !os.IsNotExist(err) {
+			t.Errorf("expected %s to be removed", p)
+		}
+	}
+	if _, err := os.Stat(kept); err != nil {
+		t.Errorf("expected %s to be kept: %v", kept, err)
+	}
+}
+
+func TestDatedFileRetentionKeepsOtherFiles(t *testing.T) {
+	dir := t.TempDir()
+	now := time.Date(2021, 3, 14, 12, 0, 0, 0, time.Local)
+	old := now.AddDate(0, 0, -30)
+
+	expired := filepath.Join(dir, "20210201.log")
+	unrelated := []string{
+		filepath.Join(dir, "syslog.log"),
+		filepath.Join(dir, "syslog.log.1"),
+		filepath.Join(dir, "2021020.log"),
+		filepath.Join(dir, "20210201.log.old"),
+	}
+	for _, p := range append([]string{expired, expired + ".2.gz"}, unrelated...) {
+		if err := os.WriteFile(p, nil, 0o644); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.Chtimes(p, old, old); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	f, err := openRotatingFileAt(FileOutput{
+		Path:      filepath.Join(dir, "%Y%m%d.log"),
+		Retention: 7 * 24 * time.Hour,
+	}, nil, func() time.Time { return now })
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := f.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	for _, p := range []string{expired, expired + ".2.gz"} {
+		if _, err := os.Stat(p); !os.IsNotExist(err) {
+			t.Errorf("expected %s to be removed", p)
+		}
+	}
+	for _, p := range unrelated {
+		if _, err := os.Stat(p); err != nil {
+			t.Errorf("expected unrelated %s to be kept: %v", p, err)
+		}
+	}
+}
+
+func TestExpandDate(t *testing.T) {
+	ts := time.Date(2021, 3, 4, 5, 6, 7, 0, time.UTC)
+	if got, want := expandDate("/var/log/%Y/%m-%d_%H%M-100%%.log", ts), "/var/log/2021/03-04_0506-100%.log"; got != want {
+		t.Errorf("got %q, want %q", got, want)
+	}
+	if got, want := expandDate("/var/log/50%off/%Y-%Q-%", ts), "/var/log/50%off/2021-%Q-%"; got != want {
+		t.Errorf("got %q, want unrecognized directives kept, %q", got, want)
+	}
+	if glob := dateGlob("/var/log/%Y-%m-100%%-%Q.log"); glob != "/var/log/*-*-100%-%Q.log" {
+		t.Errorf("got glob %q", glob)
+	}
+	for path, want := range map[string]bool{
+		"/var/log/%Y.log":     true,
+		"/var/log/50%off.log": false,
+		"/var/log/100%%Y.log": false,
+		"/var/log/ipfs-%":     false,
+	} {
+		if got := isDateTemplate(path); got != want {
+			t.Errorf("isDateTemplate(%q) = %t, want %t", path, got, want)
+		}
+	}
+}
+
+func TestPercentInFilePath(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "50%off.log")
+	reg := NewRegistry(Config{Format: PlaintextOutput, Level: LevelInfo, File: path})
+	reg.Logger("bitswap").Info("scooby")
+	if err := reg.Close(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+
+	if lines := readLines(t, path); len(lines) != 1 || !strings.Contains(lines[0], "scooby") {
+		t.Errorf("got %q", lines)
+	}
+}
+
+func TestDatedConfigFile(t *testing.T) {
+	dir := t.TempDir()
+	reg := NewRegistry(Config{Format: PlaintextOutput, Level: LevelInfo, File: filepath.Join(dir, "ipfs-%Y-%m-%d.log")})
+	reg.Logger("bitswap").Info("scooby")
+	if err := reg.Close(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+
+	path := filepath.Join(dir, time.Now().Format("ipfs-2006-01-02.log"))
+	if lines := readLines(t, path); len(lines) != 1 || !strings.Contains(lines[0], "scooby") {
+		t.Errorf("got %q", lines)
+	}
+}
+
+func TestCompressedFile(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "ipfs.log.gz")
+	for _, msg := range []string{"scooby", "shaggy"} {
+		reg := NewRegistry(Config{Format: PlaintextOutput, Level: LevelInfo, File: path, FileCompression: GzipCompression})
+		reg.Logger("bitswap").Info(msg)
+		if err := reg.Close(context.Background()); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	lines := strings.Split(strings.TrimSpace(readGzip(t, path)), "\n")
+	if len(lines) != 2 || !strings.Contains(lines[0], "scooby") || !strings.Contains(lines[1], "shaggy") {
+		t.Errorf("got %q", lines)
+	}
+}
+
+func TestCompressedBackups(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "ipfs.log")
+	f, err := openRotatingFile(FileOutput{Path: path, MaxSize: 10, MaxBackups: 2, CompressBackups: true}, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, s := range []string{"scooby\n", "shaggy\n", "velma\n"} {
+		if _, err := f.Write([]byte(s)); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := f.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	if got := readGzip(t, path+".1.gz"); got != "shaggy\n" {
+		t.Errorf("got %q in the first backup", got)
+	}
+	if got := readGzip(t, path+".2.gz"); got != "scooby\n" {
+		t.Errorf("got %q in the second backup", got)
+	}
+	if _, err := os.Stat(path + ".1"); !os.IsNotExist(err) {
+		t.Error("expected the uncompressed backup to be removed")
+	}
+}
+
+func readGzip(t *testing.T, path string) string {
+	t.Helper()
+	f, err := os.Open(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+	gz, err := gzip.NewReader(f)
+	if err != nil {
+		t.Fatal(err)
+	}
+	b, err := io.ReadAll(gz)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return string(b)
+}
+
+func TestFileModeAndDirs(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("file modes are not supported on windows")
+	}
+	path := filepath.Join(t.TempDir(), "missing", "dir", "ipfs.log")
+
+	reg := newRegistry()
+	if err := reg.SetupLoggingWithError(Config{File: path}); err == nil {
+		t.Error("expected an error without FileCreateDirs")
+	}
+	if err := reg.SetupLoggingWithError(Config{File: path, FileMode: 0o600, FileCreateDirs: true}); err != nil {
+		t.Fatal(err)
+	}
+	if err := reg.Close(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+
+	info, err := os.Stat(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if mode := info.Mode().Perm(); mode != 0o600 {
+		t.Errorf("got file mode %o, want 600", mode)
+	}
+	info, err = os.Stat(filepath.Dir(path))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if mode := info.Mode().Perm(); mode != 0o700 {
+		t.Errorf("got directory mode %o, want 700", mode)
+	}
+}
+
+func TestCompressedBackupsMode(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("file modes are not supported on windows")
+	}
+	path := filepath.Join(t.TempDir(), "ipfs.log")
+	f, err := openRotatingFile(FileOutput{Path: path, MaxSize: 10, CompressBackups: true, Mode: 0o600}, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, s := range []string{"scooby\n", "shaggy\n"} {
+		if _, err := f.Write([]byte(s)); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := f.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	info, err := os.Stat(path + ".1.gz")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if mode := info.Mode().Perm(); mode != 0o600 {
+		t.Errorf("got backup mode %o, want 600", mode)
+	}
+}
+
+func TestFileModeFromEnv(t *testing.T) {
+	t.Setenv(envFileMode, "0640")
+	t.Setenv(envFileCreateDirs, "true")
+	cfg, err := ConfigFromEnv()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if cfg.FileMode != 0o640 || !cfg.FileCreateDirs {
+		t.Errorf("got mode %o, create dirs %v", cfg.FileMode, cfg.FileCreateDirs)
+	}
+
+	t.Setenv(envFileMode, "rw-------")
+	if _, err := ConfigFromEnv(); err == nil {
+		t.Error("expected an error for an invalid mode")
+	}
+}
+
+func TestFileReplacedExternally(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "ipfs.log")
+	now := time.Now()
+	f, err := openRotatingFileAt(FileOutput{Path: path, MaxSize: 100}, nil, func() time.Time { return now })
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+	write := func(s string) {
+		t.Helper()
+		if _, err := f.Write([]byte(s)); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	write("scooby\n")
+	if err := os.Rename(path, path+".old"); err != nil {
+		t.Fatal(err)
+	}
+	write("shaggy\n") // before the next check
+	now = now.Add(fileCheckInterval)
+	write("velma\n")
+
+	if got := readLines(t, path+".old"); len(got) != 2 {
+		t.Errorf("got %q in the moved file", got)
+	}
+	if got := readLines(t, path); len(got) != 1 || got[0] != "velma" {
+		t.Errorf("got %q in the new file", got)
+	}
+
+	// copytruncate
+	if err := os.Truncate(path, 0); err != nil {
+		t.Fatal(err)
+	}
+	now = now.Add(fileCheckInterval)
+	write("daphne\n")
+	if f.size != int64(len("daphne\n")) {
+		t.Errorf("got size %d after truncation", f.size)
+	}
+}
+
+func TestFileDiskGuard(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "ipfs.log")
+	now := time.Now()
+	var m metrics
+	f, err := openRotatingFileAt(FileOutput{Path: path, MaxTotalSize: 10}, &m, func() time.Time { return now })
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+	write := func(s string) {
+		t.Helper()
+		if _, err := f.Write([]byte(s)); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	write("scooby\n")
+	write("shaggy\n") // before the next check
+	now = now.Add(fileCheckInterval)
+	write("velma\n")
+	write("daphne\n")
+	if got := readLines(t, path); len(got) != 2 {
+		t.Errorf("got %q while suspended", got)
+	}
+	if got := m.snapshot(); got.DiskGuardTrips != 1 || got.Dropped != 2 {
+		t.Errorf("got %d trips and %d dropped entries", got.DiskGuardTrips, got.Dropped)
+	}
+
+	if err := os.Truncate(path, 0); err != nil {
+		t.Fatal(err)
+	}
+	now = now.Add(fileCheckInterval)
+	write("fred\n")
+	if got := readLines(t, path); len(got) != 1 || got[0] != "fred" {
+		t.Errorf("got %q after resuming", got)
+	}
+}
+
+func TestParseSize(t *testing.T) {
+	for s, want := range map[string]int64{"0": 0, "512": 512, "4k": 4 << 10, "512M": 512 << 20, "1G": 1 << 30} {
+		if got, err := parseSize(s); err != nil || got != want {
+			t.Errorf("parseSize(%q) = %d, %v, want %d", s, got, err, want)
+		}
+	}
+	for _, s := range []string{"", "M", "-1", "1.5G", "8E", "99999999T"} {
+		if _, err := parseSize(s); err == nil {
+			t.Errorf("expected an error for %q", s)
+		}
+	}
+}
+
+func TestOpenSink(t *testing.T) {
+	wd, err := os.Getwd()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := os.Chdir(t.TempDir()); err != nil {
+		t.Fatal(err)
+	}
+	defer os.Chdir(wd)
+
+	// zap.Open takes "c" for a URL scheme, a valid file name on Unix stands
+	// for a Windows path here.
+	for _, target := range []string{`C:\logs\events.json`, "events.json"} {
+		ws, closeSink, err := openSink(target, nil)
+		if err != nil {
+			t.Fatalf("failed to open %q: %s", target, err)
+		}
+		if _, err := ws.Write([]byte("scooby\n")); err != nil {
+			t.Fatal(err)
+		}
+		closeSink()
+
+		if b, err := os.ReadFile(target); err != nil || string(b) != "scooby\n" {
+			t.Errorf("got %q, %v in %q, want the entry", b, err, target)
+		}
+	}
+
+	if _, closeSink, err := openSink("stderr", nil); err != nil {
+		t.Error(err)
+	} else {
+		closeSink()
+	}
+}
@@ -0,0 +1,115 @@
+package log
+
+import (
+	"io"
+	"sync"
+	"sync/atomic"
+
+	"go.uber.org/multierr"
+	"go.uber.org/zap/zapcore"
+)
+
+// defaultFlightRecorderSize is the number of entries kept by the flight
+// recorder when started from the environment.
+const defaultFlightRecorderSize = 4096
+
+// flightRecorder keeps the most recent entries of all subsystems, at any
+// level.
+type flightRecorder struct {
+	on uint32 // atomic; non-zero while recording
+
+	mu   sync.Mutex // guards ring
+	ring *entryRing
+}
+
+func (f *flightRecorder) running() bool {
+	return atomic.LoadUint32(&f.on) != 0
+}
+
+func (f *flightRecorder) start(size int) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	f.ring = newEntryRing(size)
+	atomic.StoreUint32(&f.on, 1)
+}
+
+func (f *flightRecorder) stop() {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	atomic.StoreUint32(&f.on, 0)
+	f.ring = nil
+}
+
+func (f *flightRecorder) core() zapcore.Core {
+	return &hookCore{LevelEnabler: traceLevel, hook: f.record}
+}
+
+func (f *flightRecorder) record(ent zapcore.Entry, fields []zapcore.Field) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	if f.ring != nil {
+		// The fields slice may be reused by the caller.
+		f.ring.add(RecordedEntry{Entry: ent, Fields: append([]zapcore.Field(nil), fields...)})
+	}
+	return nil
+}
+
+func (f *flightRecorder) entries() []RecordedEntry {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	if f.ring == nil {
+		return nil
+	}
+	return f.ring.last(0)
+}
+
+// StartFlightRecorder starts recording the last size entries of all
+// subsystems in memory, at all levels, regardless of the levels set for the
+// outputs. Use DumpFlightRecorder to retrieve them, e.g. when an incident
+// occurs, to get debug context without writing debug logs to disk.
+//
+// Note that while recording, debug entries are formatted even if they aren't
+// written anywhere. Starting a running recorder discards what it holds.
+//
+// The flight recorder can also be started by setting GOLOG_FLIGHT_RECORDER to
+// true, or to the number of entries to keep.
+func StartFlightRecorder(size int) {
+	defaultRegistry.StartFlightRecorder(size)
+}
+
+// StopFlightRecorder stops recording and discards the recorded entries.
+func StopFlightRecorder() {
+	defaultRegistry.StopFlightRecorder()
+}
+
+// DumpFlightRecorder writes the entries held by the flight recorder to w as
+// JSON, one entry per line, oldest first.
+func DumpFlightRecorder(w io.Writer) error {
+	return defaultRegistry.DumpFlightRecorder(w)
+}
+
+// StartFlightRecorder starts the flight recorder of this registry. See the
+// package-level StartFlightRecorder.
+func (r *Registry) StartFlightRecorder(size int) {
+	r.recorder.start(size)
+}
+
+// StopFlightRecorder stops the flight recorder of this registry.
+func (r *Registry) StopFlightRecorder() {
+	r.recorder.stop()
+}
+
+// DumpFlightRecorder writes the entries held by the flight recorder of this
+// registry to w. See the package-level DumpFlightRecorder.
+func (r *Registry) DumpFlightRecorder(w io.Writer) error {
+	core := newCore(JSONOutput, zapcore.AddSync(w), LevelTrace)
+	var err error
+	for _, e := range r.recorder.entries() {
+		err = multierr.Append(err, core.Write(e.Entry, e.Fields))
+	}
+	return err
+}
@@ -0,0 +1,61 @@
+package log
+
+import (
+	"bytes"
+	"encoding/json"
+	"strings"
+	"testing"
+
+	"go.uber.org/zap/zapcore"
+)
+
+func TestFlightRecorder(t *testing.T) {
+	reg := NewRegistry(Config{Level: LevelError})
+	logs := &bytes.Buffer{}
+	reg.SetPrimaryCore(newCore(PlaintextOutput, zapcore.AddSync(logs), LevelDebug))
+	log := reg.Logger("test")
+
+	log.Debug("before")
+
+	reg.StartFlightRecorder(2)
+	log.Debug("scooby")
+	log.Infow("doo", "snacks", 2)
+	log.Error("velma")
+
+	if strings.Contains(logs.String(), "scooby") || strings.Contains(logs.String(), "doo") {
+		t.Errorf("got %q, wanted recorded entries to not be written", logs.String())
+	}
+	if !strings.Contains(logs.String(), "velma") {
+		t.Errorf("got %q, wanted enabled entries to be written", logs.String())
+	}
+
+	buf := &bytes.Buffer{}
+	if err := reg.DumpFlightRecorder(buf); err != nil {
+		t.Fatal(err)
+	}
+	lines := strings.Split(strings.TrimSpace(buf.String()), "\n")
+	if len(lines) != 2 {
+		t.Fatalf("got %q, wanted 2 entries", buf.String())
+	}
+	var entry struct {
+		Level   string `json:"level"`
+		Message string `json:"msg"`
+		Logger  string `json:"logger"`
+		Snacks  int    `json:"snacks"`
+	}
+	if err := json.Unmarshal([]byte(lines[0]), &entry); err != nil {
+		t.Fatal(err)
+	}
+	if entry.Level != "info" || entry.Message != "doo" || entry.Logger != "test" || entry.Snacks != 2 {
+		t.Errorf("got %+v", entry)
+	}
+
+	reg.StopFlightRecorder()
+	buf.Reset()
+	if err := reg.DumpFlightRecorder(buf); err != nil {
+		t.Fatal(err)
+	}
+	if buf.Len() != 0 {
+		t.Errorf("got %q, wanted nothing after stop", buf.String())
+	}
+}
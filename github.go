@@ -0,0 +1,90 @@
+package log
+
+import (
+	"os"
+	"strconv"
+	"strings"
+
+	"go.uber.org/zap/buffer"
+	"go.uber.org/zap/zapcore"
+)
+
+// inGitHubActions reports whether the process runs in a GitHub Actions
+// workflow.
+func inGitHubActions() bool {
+	return os.Getenv("GITHUB_ACTIONS") == "true"
+}
+
+// githubEncoder wraps a console encoder and turns the lines of warn and
+// error entries into workflow commands, which GitHub Actions shows as
+// annotations on the run and the pull request:
+//
+//	::error title=bitswap,file=bitswap/client.go,line=42::2010-05-23T15:14:00.000Z	ERROR	bitswap	...
+//
+// Other entries are left as they are.
+type githubEncoder struct {
+	zapcore.Encoder
+}
+
+func (e *githubEncoder) Clone() zapcore.Encoder {
+	return &githubEncoder{Encoder: e.Encoder.Clone()}
+}
+
+func (e *githubEncoder) EncodeEntry(ent zapcore.Entry, fields []zapcore.Field) (*buffer.Buffer, error) {
+	buf, err := e.Encoder.EncodeEntry(ent, fields)
+	if err != nil || ent.Level < zapcore.WarnLevel {
+		return buf, err
+	}
+
+	command := "error"
+	if ent.Level == zapcore.WarnLevel {
+		command = "warning"
+	}
+	line := bufferPool.Get()
+	line.AppendString("::")
+	line.AppendString(command)
+	sep := " "
+	if ent.LoggerName != "" {
+		line.AppendString(sep + "title=")
+		appendGitHubEscaped(line, ent.LoggerName, true)
+		sep = ","
+	}
+	if ent.Caller.Defined {
+		// Annotations only link to files given relative to the repository.
+		file := ent.Caller.File
+		if workspace := os.Getenv("GITHUB_WORKSPACE"); workspace != "" {
+			file = strings.TrimPrefix(file, workspace+"/")
+		}
+		line.AppendString(sep + "file=")
+		appendGitHubEscaped(line, file, true)
+		line.AppendString(",line=")
+		line.AppendString(strconv.Itoa(ent.Caller.Line))
+	}
+	line.AppendString("::")
+	appendGitHubEscaped(line, strings.TrimRight(buf.String(), "\r\n"), false)
+	line.AppendByte('\n')
+	buf.Free()
+	return line, nil
+}
+
+// appendGitHubEscaped appends s to buf, escaped as the message of a
+// workflow command or, if property is set, as the value of one of its
+// properties.
+func appendGitHubEscaped(buf *buffer.Buffer, s string, property bool) {
+	for i := 0; i < len(s); i++ {
+		switch c := s[i]; {
+		case c == '%':
+			buf.AppendString("%25")
+		case c == '\r':
+			buf.AppendString("%0D")
+		case c == '\n':
+			buf.AppendString("%0A")
+		case property && c == ':':
+			buf.AppendString("%3A")
+		case property && c == ',':
+			buf.AppendString("%2C")
+		default:
+			buf.AppendByte(c)
+		}
+	}
+}
@@ -0,0 +1,53 @@
+package log
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+
+	"go.uber.org/zap/zapcore"
+)
+
+func TestGitHubActionsOutput(t *testing.T) {
+	t.Setenv("GITHUB_ACTIONS", "true")
+	t.Setenv("GITHUB_WORKSPACE", "/home/runner/work/kubo")
+	cfg, err := ConfigFromEnv()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if cfg.Format != GitHubActionsOutput {
+		t.Fatalf("got format %v", cfg.Format)
+	}
+
+	var buf bytes.Buffer
+	core := newConfiguredCore(cfg, zapcore.AddSync(&buf), LevelInfo)
+	caller := zapcore.NewEntryCaller(0, "/home/runner/work/kubo/core/node.go", 42, true)
+	for _, ent := range []zapcore.Entry{
+		{Level: zapcore.InfoLevel, LoggerName: "core", Message: "started"},
+		{Level: zapcore.WarnLevel, LoggerName: "core", Message: "50% full"},
+		{Level: zapcore.ErrorLevel, LoggerName: "dht:routing", Message: "lookup failed\nno peers", Caller: caller},
+	} {
+		if err := core.Write(ent, nil); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	lines := strings.Split(strings.TrimSpace(buf.String()), "\n")
+	if len(lines) != 3 {
+		t.Fatalf("got %q, want 3 lines", lines)
+	}
+	if strings.HasPrefix(lines[0], "::") || !strings.Contains(lines[0], "started") {
+		t.Errorf("got %q for an info entry", lines[0])
+	}
+	if !strings.HasPrefix(lines[1], "::warning title=core::") || !strings.HasSuffix(lines[1], "50%25 full") {
+		t.Errorf("got %q for a warning", lines[1])
+	}
+	if !strings.HasPrefix(lines[2], "::error title=dht%3Arouting,file=core/node.go,line=42::") ||
+		!strings.Contains(lines[2], "lookup failed%0Ano peers") {
+		t.Errorf("got %q for an error", lines[2])
+	}
+
+	if f, err := parseLogFormat("github"); err != nil || f != GitHubActionsOutput || f.String() != "github" {
+		t.Errorf("got %v, %v", f, err)
+	}
+}
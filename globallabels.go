@@ -0,0 +1,55 @@
+package log
+
+// SetGlobalLabels adds labels, given as alternating keys and values, to all
+// entries, replacing the values of existing labels. Unlike GOLOG_LOG_LABELS
+// and Config.Labels, it can be used at any time, e.g. to flag the current
+// leader of a cluster. A trailing key without value is ignored.
+//
+// Labels only apply to the outputs configured with SetupLogging, not to a
+// primary core set with SetPrimaryCore.
+func SetGlobalLabels(kv ...string) {
+	defaultRegistry.SetGlobalLabels(kv...)
+}
+
+// SetGlobalLabels adds labels to the entries of this registry. See the
+// package-level SetGlobalLabels.
+func (r *Registry) SetGlobalLabels(kv ...string) {
+	r.updateLabels(func(labels map[string]string) {
+		for i := 0; i+1 < len(kv); i += 2 {
+			labels[kv[i]] = kv[i+1]
+		}
+	})
+}
+
+// RemoveGlobalLabel removes a label set with SetGlobalLabels, GOLOG_LOG_LABELS
+// or Config.Labels.
+func RemoveGlobalLabel(key string) {
+	defaultRegistry.RemoveGlobalLabel(key)
+}
+
+// RemoveGlobalLabel removes a label from the entries of this registry. See
+// the package-level RemoveGlobalLabel.
+func (r *Registry) RemoveGlobalLabel(key string) {
+	r.updateLabels(func(labels map[string]string) {
+		delete(labels, key)
+	})
+}
+
+// updateLabels applies update to a copy of the configured labels and swaps
+// the primary core for one rendering them, on the same outputs.
+func (r *Registry) updateLabels(update func(map[string]string)) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	// The map may be shared with the Config passed to SetupLogging.
+	labels := make(map[string]string, len(r.config.Labels))
+	for k, v := range r.config.Labels {
+		labels[k] = v
+	}
+	update(labels)
+	r.config.Labels = labels
+
+	if r.outputs != nil {
+		r.setOutputsCore(r.newPrimaryCore(r.config, r.outputs))
+	}
+}
@@ -0,0 +1,78 @@
+package log
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestGlobalLabels(t *testing.T) {
+	f := filepath.Join(t.TempDir(), "go-log-test")
+	reg := NewRegistry(Config{Format: PlaintextOutput, Level: LevelInfo, File: f, Labels: map[string]string{"dc": "sjc-1"}})
+	log := reg.Logger("test")
+
+	log.Info("scooby")
+	reg.SetGlobalLabels("leader", "true", "dangling")
+	log.Info("doo")
+	reg.RemoveGlobalLabel("dc")
+	log.Info("velma")
+
+	if err := reg.Close(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	out, err := os.ReadFile(f)
+	if err != nil {
+		t.Fatal(err)
+	}
+	lines := strings.Split(strings.TrimSpace(string(out)), "\n")
+	if len(lines) != 3 {
+		t.Fatalf("got %q, want 3 lines", lines)
+	}
+	for i, want := range []string{"\tdc=sjc-1", "\tdc=sjc-1 leader=true", "\tleader=true"} {
+		if !strings.HasSuffix(lines[i], want) {
+			t.Errorf("got %q, want labels %q", lines[i], want)
+		}
+	}
+	if labels := reg.GetConfig().Labels; len(labels) != 1 || labels["leader"] != "true" {
+		t.Errorf("got config labels %v", labels)
+	}
+}
+
+func TestSubsystemLabels(t *testing.T) {
+	f := filepath.Join(t.TempDir(), "go-log-test")
+	cfg := Config{
+		Format:          JSONOutput,
+		Level:           LevelInfo,
+		File:            f,
+		SubsystemLabels: map[string]map[string]string{"dht": {"component": "routing"}},
+	}
+	reg := NewRegistry(cfg)
+	dht := reg.Logger("dht")
+	other := reg.Logger("other")
+
+	dht.Info("scooby")
+	other.Info("doo")
+	cfg.SubsystemLabels = map[string]map[string]string{"dht": {"component": "dht"}}
+	reg.SetupLogging(cfg)
+	dht.With("peer", "velma").Info("daphne")
+
+	if err := reg.Close(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	out, err := os.ReadFile(f)
+	if err != nil {
+		t.Fatal(err)
+	}
+	lines := strings.Split(strings.TrimSpace(string(out)), "\n")
+	if len(lines) != 3 {
+		t.Fatalf("got %q, want 3 lines", lines)
+	}
+	if !strings.Contains(lines[0], `"component":"routing"`) || strings.Contains(lines[1], "component") {
+		t.Errorf("got %q, want only the dht entry labeled", lines[:2])
+	}
+	if !strings.Contains(lines[2], `"component":"dht"`) || !strings.Contains(lines[2], `"peer":"velma"`) {
+		t.Errorf("got %q, want the new labels", lines[2])
+	}
+}
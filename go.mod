@@ -1,14 +1,16 @@
 module github.com/ipfs/go-log/v2
 
 require (
+	github.com/fsnotify/fsnotify v1.7.0
 	github.com/mattn/go-isatty v0.0.14
 	go.uber.org/multierr v1.6.0
 	go.uber.org/zap v1.19.1
+	gopkg.in/yaml.v3 v3.0.1
 )
 
 require (
 	go.uber.org/atomic v1.7.0 // indirect
-	golang.org/x/sys v0.0.0-20210630005230-0f9fa26af87c // indirect
+	golang.org/x/sys v0.4.0 // indirect
 )
 
 go 1.21
@@ -0,0 +1,48 @@
+package log
+
+import (
+	"bytes"
+	"context"
+	"runtime"
+	"strconv"
+
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+)
+
+const (
+	// GoroutineKey is the key of the goroutine ID added to entries with
+	// Config.GoroutineID.
+	GoroutineKey = "goroutine"
+
+	// TaskKey is the key of the task labels set with ContextWithTask.
+	TaskKey = "task"
+)
+
+// ContextWithTask returns a copy of ctx labelling the entries of the logging
+// helpers taking a context with task under TaskKey, like fields added with
+// ContextWithFields, e.g. to tell apart the entries of concurrent DHT
+// queries.
+func ContextWithTask(ctx context.Context, task string) context.Context {
+	return ContextWithFields(ctx, TaskKey, task)
+}
+
+// goroutineID returns the ID of the calling goroutine, as shown in stack
+// traces, or 0 if it can't be determined.
+func goroutineID() uint64 {
+	var buf [64]byte
+	b := buf[:runtime.Stack(buf[:], false)]
+	// The trace starts with "goroutine 42 [running]:".
+	b = bytes.TrimPrefix(b, []byte("goroutine "))
+	if i := bytes.IndexByte(b, ' '); i >= 0 {
+		b = b[:i]
+	}
+	id, _ := strconv.ParseUint(string(b), 10, 64)
+	return id
+}
+
+// goroutineField returns the ID of the logging goroutine as a field.
+// Entries are written by the goroutine logging them.
+func goroutineField(zapcore.Entry) zapcore.Field {
+	return zap.Uint64(GoroutineKey, goroutineID())
+}
@@ -0,0 +1,53 @@
+package log
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"strings"
+	"testing"
+
+	"go.uber.org/zap/zapcore"
+)
+
+func TestGoroutineID(t *testing.T) {
+	reg := NewRegistry(Config{Level: LevelInfo})
+	var buf bytes.Buffer
+	cfg := Config{Format: JSONOutput, GoroutineID: true}
+	reg.SetPrimaryCore(reg.newPrimaryCore(cfg, zapcore.AddSync(&buf)))
+	log := reg.Logger("dht")
+
+	log.Debug("hidden")
+	log.Info("scooby")
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		log.Info("doo")
+	}()
+	<-done
+
+	lines := strings.Split(strings.TrimSpace(buf.String()), "\n")
+	if len(lines) != 2 {
+		t.Fatalf("got %q, want 2 lines", lines)
+	}
+	var ids [2]uint64
+	for i, line := range lines {
+		var entry struct {
+			Goroutine uint64 `json:"goroutine"`
+		}
+		if err := json.Unmarshal([]byte(line), &entry); err != nil {
+			t.Fatal(err)
+		}
+		ids[i] = entry.Goroutine
+	}
+	if ids[0] != goroutineID() || ids[1] == 0 || ids[1] == ids[0] {
+		t.Errorf("got goroutines %v, running in %d", ids, goroutineID())
+	}
+}
+
+func TestContextWithTask(t *testing.T) {
+	ctx := ContextWithTask(context.Background(), "query-7")
+	if fields := contextFields(ctx); len(fields) != 2 || fields[0] != TaskKey || fields[1] != "query-7" {
+		t.Errorf("got fields %v", fields)
+	}
+}
@@ -0,0 +1,67 @@
+package log
+
+import (
+	"go.uber.org/zap/zapcore"
+)
+
+// Hook is called for every entry logged at or above the level it was
+// registered with. fields include the fields bound with With. Errors are
+// reported like write errors of the outputs.
+type Hook func(ent zapcore.Entry, fields []zapcore.Field) error
+
+// RegisterHook registers hook to be called for every entry at or above level
+// that is enabled by the level of its subsystem. This allows custom metrics,
+// alerting or forwarding without implementing a zapcore.Core.
+//
+// Hooks are called synchronously from the logging goroutine, so they should
+// be fast. The returned function unregisters the hook.
+func RegisterHook(level LogLevel, hook Hook) (unregister func()) {
+	return defaultRegistry.RegisterHook(level, hook)
+}
+
+// RegisterHook registers a hook with this registry. See the package-level
+// RegisterHook.
+func (r *Registry) RegisterHook(level LogLevel, hook Hook) (unregister func()) {
+	hc := &hookCore{
+		LevelEnabler: zapcore.Level(level),
+		hook:         hook,
+	}
+	r.loggerCore.AddCore(hc)
+	return func() {
+		r.loggerCore.DeleteCore(hc)
+	}
+}
+
+type hookCore struct {
+	zapcore.LevelEnabler
+	hook   Hook
+	fields []zapcore.Field
+}
+
+var _ zapcore.Core = (*hookCore)(nil)
+
+func (c *hookCore) With(fields []zapcore.Field) zapcore.Core {
+	return &hookCore{
+		LevelEnabler: c.LevelEnabler,
+		hook:         c.hook,
+		fields:       append(append([]zapcore.Field(nil), c.fields...), fields...),
+	}
+}
+
+func (c *hookCore) Check(ent zapcore.Entry, ce *zapcore.CheckedEntry) *zapcore.CheckedEntry {
+	if c.Enabled(ent.Level) {
+		return ce.AddCore(ent, c)
+	}
+	return ce
+}
+
+func (c *hookCore) Write(ent zapcore.Entry, fields []zapcore.Field) error {
+	if len(c.fields) > 0 {
+		fields = append(append([]zapcore.Field(nil), c.fields...), fields...)
+	}
+	return c.hook(ent, fields)
+}
+
+func (c *hookCore) Sync() error {
+	return nil
+}
@@ -0,0 +1,31 @@
+package log
+
+import (
+	"testing"
+
+	"go.uber.org/zap/zapcore"
+)
+
+func TestRegisterHook(t *testing.T) {
+	reg := NewRegistry(Config{Level: LevelInfo})
+	log := reg.Logger("test")
+
+	var got []string
+	unregister := reg.RegisterHook(LevelWarn, func(ent zapcore.Entry, fields []zapcore.Field) error {
+		got = append(got, ent.Message)
+		if ent.Message == "doo" && (len(fields) != 2 || fields[0].Key != "mystery" || fields[1].Key != "snacks") {
+			t.Errorf("got fields %v", fields)
+		}
+		return nil
+	})
+
+	log.Info("hidden")
+	log.Warn("scooby")
+	log.With("mystery", "machine").Errorw("doo", "snacks", 2)
+	unregister()
+	log.Error("velma")
+
+	if len(got) != 2 || got[0] != "scooby" || got[1] != "doo" {
+		t.Errorf("got %q, want [scooby doo]", got)
+	}
+}
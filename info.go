@@ -0,0 +1,77 @@
+package log
+
+import (
+	"sort"
+	"time"
+)
+
+// SubsystemDetails describes a subsystem, as returned by SubsystemInfo.
+type SubsystemDetails struct {
+	Name string `json:"name"`
+
+	// Description and Owner are those given to LoggerWithInfo, if any.
+	Description string `json:"description,omitempty"`
+	Owner       string `json:"owner,omitempty"`
+
+	// Level is the current level of the subsystem.
+	Level LogLevel `json:"level"`
+
+	// Created is when the logger of the subsystem was created.
+	Created time.Time `json:"created"`
+}
+
+type subsystemInfo struct {
+	description string
+	owner       string
+	created     time.Time
+}
+
+// LoggerWithInfo is like Logger, but also records a description of the
+// subsystem and its owner (e.g. a team or a repository), for tools listing
+// the subsystems with SubsystemInfo.
+func LoggerWithInfo(system, description, owner string) *ZapEventLogger {
+	return defaultRegistry.LoggerWithInfo(system, description, owner)
+}
+
+// LoggerWithInfo retrieves a logger of this registry and describes its
+// subsystem. See the package-level LoggerWithInfo.
+func (r *Registry) LoggerWithInfo(system, description, owner string) *ZapEventLogger {
+	logger := r.Logger(system)
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if info, ok := r.info[logger.system]; ok {
+		info.description = description
+		info.owner = owner
+	}
+	return logger
+}
+
+// SubsystemInfo returns the details of all subsystems with a logger, sorted
+// by name.
+func SubsystemInfo() []SubsystemDetails {
+	return defaultRegistry.SubsystemInfo()
+}
+
+// SubsystemInfo returns the details of the subsystems of this registry. See
+// the package-level SubsystemInfo.
+func (r *Registry) SubsystemInfo() []SubsystemDetails {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	details := make([]SubsystemDetails, 0, len(r.info))
+	for name, info := range r.info {
+		details = append(details, SubsystemDetails{
+			Name:        name,
+			Description: info.description,
+			Owner:       info.owner,
+			Level:       LogLevel(r.levels[name].Level()),
+			Created:     info.created,
+		})
+	}
+	sort.Slice(details, func(i, j int) bool {
+		return details[i].Name < details[j].Name
+	})
+	return details
+}
@@ -0,0 +1,31 @@
+package log
+
+import (
+	"testing"
+	"time"
+)
+
+func TestSubsystemInfo(t *testing.T) {
+	reg := NewRegistry(Config{Level: LevelError})
+	before := time.Now()
+	reg.LoggerWithInfo("dht", "Kademlia routing", "libp2p")
+	reg.Logger("bitswap")
+	if err := reg.SetLogLevel("dht", "debug"); err != nil {
+		t.Fatal(err)
+	}
+
+	details := reg.SubsystemInfo()
+	if len(details) != 2 {
+		t.Fatalf("got %d subsystems, want 2: %+v", len(details), details)
+	}
+	if d := details[0]; d.Name != "bitswap" || d.Description != "" || d.Level != LevelError {
+		t.Errorf("got %+v", d)
+	}
+	d := details[1]
+	if d.Name != "dht" || d.Description != "Kademlia routing" || d.Owner != "libp2p" || d.Level != LevelDebug {
+		t.Errorf("got %+v", d)
+	}
+	if d.Created.Before(before) || d.Created.After(time.Now()) {
+		t.Errorf("got creation time %v", d.Created)
+	}
+}
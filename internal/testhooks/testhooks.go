@@ -0,0 +1,9 @@
+// Package testhooks gives the logtest package access to internals of the
+// log package that are not part of its public API.
+package testhooks
+
+import "go.uber.org/zap/zapcore"
+
+// ReplacePrimaryCore replaces the primary core of the default registry until
+// restore is called. It is set by the log package.
+var ReplacePrimaryCore func(core zapcore.Core) (restore func())
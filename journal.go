@@ -0,0 +1,27 @@
+package log
+
+import (
+	"os"
+	"strconv"
+	"strings"
+)
+
+// underJournal reports whether f is connected to the systemd journal, which
+// timestamps the lines it receives. systemd sets JOURNAL_STREAM to the
+// device and inode numbers of the stream for the services it starts.
+func underJournal(f *os.File) bool {
+	devStr, inoStr, ok := strings.Cut(os.Getenv("JOURNAL_STREAM"), ":")
+	if !ok {
+		return false
+	}
+	dev, err := strconv.ParseUint(devStr, 10, 64)
+	if err != nil {
+		return false
+	}
+	ino, err := strconv.ParseUint(inoStr, 10, 64)
+	if err != nil {
+		return false
+	}
+	fdev, fino, ok := fileID(f)
+	return ok && fdev == dev && fino == ino
+}
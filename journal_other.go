@@ -0,0 +1,10 @@
+//go:build !unix
+
+package log
+
+import "os"
+
+// fileID is not supported on this platform: the journal is never detected.
+func fileID(*os.File) (dev, ino uint64, ok bool) {
+	return 0, 0, false
+}
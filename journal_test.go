@@ -0,0 +1,69 @@
+package log
+
+import (
+	"bytes"
+	"fmt"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"testing"
+
+	"go.uber.org/zap/zapcore"
+)
+
+func TestUnderJournal(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("no journal on windows")
+	}
+	f, err := os.Create(filepath.Join(t.TempDir(), "stream"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+	dev, ino, _ := fileID(f)
+
+	t.Setenv("JOURNAL_STREAM", "")
+	if underJournal(f) {
+		t.Error("expected no journal without JOURNAL_STREAM")
+	}
+	t.Setenv("JOURNAL_STREAM", fmt.Sprintf("%d:%d", dev, ino+1))
+	if underJournal(f) {
+		t.Error("expected no journal for another stream")
+	}
+	t.Setenv("JOURNAL_STREAM", fmt.Sprintf("%d:%d", dev, ino))
+	if !underJournal(f) {
+		t.Error("expected the journal")
+	}
+}
+
+func TestDisableTimestamp(t *testing.T) {
+	t.Setenv(envTimestamp, "false")
+	cfg, err := ConfigFromEnv()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !cfg.DisableTimestamp {
+		t.Fatal("expected timestamps to be disabled")
+	}
+
+	var buf bytes.Buffer
+	cfg.Format = JSONOutput
+	core := newConfiguredCore(cfg, zapcore.AddSync(&buf), LevelInfo)
+	if err := core.Write(zapcore.Entry{Level: zapcore.InfoLevel, Message: "scooby"}, nil); err != nil {
+		t.Fatal(err)
+	}
+	if got := buf.String(); strings.Contains(got, `"ts"`) {
+		t.Errorf("got %q", got)
+	}
+
+	t.Setenv(envTimestamp, "auto")
+	t.Setenv("JOURNAL_STREAM", "")
+	if cfg, _ := ConfigFromEnv(); cfg.DisableTimestamp {
+		t.Error("expected timestamps outside of the journal")
+	}
+	t.Setenv(envTimestamp, "sometimes")
+	if _, err := ConfigFromEnv(); err == nil {
+		t.Error("expected an error for an invalid value")
+	}
+}
@@ -0,0 +1,125 @@
+package log
+
+import (
+	"bytes"
+	"sort"
+	"strconv"
+	"strings"
+
+	"go.uber.org/zap"
+	"go.uber.org/zap/buffer"
+	"go.uber.org/zap/zapcore"
+)
+
+// labelFields returns labels as fields, sorted by key.
+func labelFields(labels map[string]string) []zapcore.Field {
+	fields := make([]zapcore.Field, 0, len(labels))
+	for _, k := range sortedKeys(labels) {
+		fields = append(fields, zap.String(k, labels[k]))
+	}
+	return fields
+}
+
+// formatLabels renders labels as space separated key=value pairs, sorted by
+// key. Values that would be ambiguous are quoted.
+func formatLabels(labels map[string]string) string {
+	var sb strings.Builder
+	for i, k := range sortedKeys(labels) {
+		if i > 0 {
+			sb.WriteByte(' ')
+		}
+		sb.WriteString(k)
+		sb.WriteByte('=')
+		v := labels[k]
+		if v == "" || strings.ContainsAny(v, " \t\n\"=") {
+			v = strconv.Quote(v)
+		}
+		sb.WriteString(v)
+	}
+	return sb.String()
+}
+
+func sortedKeys(m map[string]string) []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+// labelsEncoder wraps a console encoder and appends the pre-rendered labels
+// as the last tab-separated column of the first line of every entry, so that humans tailing the
+// logs can spot them next to the message.
+type labelsEncoder struct {
+	zapcore.Encoder
+	labels string
+}
+
+func (e *labelsEncoder) Clone() zapcore.Encoder {
+	return &labelsEncoder{
+		Encoder: e.Encoder.Clone(),
+		labels:  e.labels,
+	}
+}
+
+func (e *labelsEncoder) EncodeEntry(ent zapcore.Entry, fields []zapcore.Field) (*buffer.Buffer, error) {
+	buf, err := e.Encoder.EncodeEntry(ent, fields)
+	if err != nil {
+		return buf, err
+	}
+
+	// Insert the labels at the end of the first line, before the stack
+	// trace if any.
+	b := buf.Bytes()
+	end := bytes.IndexByte(b, '\n')
+	if end < 0 {
+		end = len(b)
+	}
+	for end > 0 && b[end-1] == '\r' {
+		end--
+	}
+
+	line := bufferPool.Get()
+	_, _ = line.Write(b[:end])
+	line.AppendByte('\t')
+	line.AppendString(e.labels)
+	_, _ = line.Write(b[end:])
+	buf.Free()
+	return line, nil
+}
+
+// subsystemLabelsCore adds the labels of the subsystem of each entry as
+// fields.
+type subsystemLabelsCore struct {
+	zapcore.Core
+	labels map[string][]zapcore.Field
+}
+
+var _ zapcore.Core = (*subsystemLabelsCore)(nil)
+
+func newSubsystemLabelsCore(core zapcore.Core, labels map[string]map[string]string) *subsystemLabelsCore {
+	fields := make(map[string][]zapcore.Field, len(labels))
+	for name, l := range labels {
+		fields[name] = labelFields(l)
+	}
+	return &subsystemLabelsCore{Core: core, labels: fields}
+}
+
+func (c *subsystemLabelsCore) With(fields []zapcore.Field) zapcore.Core {
+	return &subsystemLabelsCore{Core: c.Core.With(fields), labels: c.labels}
+}
+
+func (c *subsystemLabelsCore) Check(ent zapcore.Entry, ce *zapcore.CheckedEntry) *zapcore.CheckedEntry {
+	if c.Enabled(ent.Level) {
+		return ce.AddCore(ent, c)
+	}
+	return ce
+}
+
+func (c *subsystemLabelsCore) Write(ent zapcore.Entry, fields []zapcore.Field) error {
+	if labels := c.labels[ent.LoggerName]; len(labels) > 0 {
+		fields = append(append([]zapcore.Field(nil), labels...), fields...)
+	}
+	return c.Core.Write(ent, fields)
+}
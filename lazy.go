@@ -0,0 +1,30 @@
+package log
+
+import (
+	"encoding/json"
+	"sync"
+)
+
+// Lazy returns a field value for Infow and friends that calls f only when
+// the entry is encoded, so expensive values cost nothing unless the entry is
+// actually enabled:
+//
+//	logger.Debugw("block", "dump", log.Lazy(func() interface{} { return blk.Dump() }))
+//
+// f is called at most once and its result is encoded like any other value.
+func Lazy(f func() interface{}) json.Marshaler {
+	return &lazyValue{f: f}
+}
+
+type lazyValue struct {
+	once sync.Once
+	f    func() interface{}
+	v    interface{}
+}
+
+func (l *lazyValue) MarshalJSON() ([]byte, error) {
+	l.once.Do(func() {
+		l.v = l.f()
+	})
+	return json.Marshal(l.v)
+}
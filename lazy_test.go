@@ -0,0 +1,38 @@
+package log
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+
+	"go.uber.org/zap/zapcore"
+)
+
+func TestLazy(t *testing.T) {
+	skipWithoutDebug(t)
+
+	reg := NewRegistry(Config{Level: LevelInfo})
+	var buf bytes.Buffer
+	reg.SetPrimaryCore(zapcore.NewCore(zapcore.NewJSONEncoder(zapcore.EncoderConfig{MessageKey: "msg"}), zapcore.AddSync(&buf), zapcore.DebugLevel))
+	log := reg.Logger("test")
+
+	calls := 0
+	value := func() interface{} {
+		calls++
+		return map[string]int{"snacks": 2}
+	}
+
+	log.Debugw("hidden", "dump", Lazy(value))
+	if calls != 0 {
+		t.Errorf("lazy value evaluated for a disabled entry")
+	}
+
+	reg.SetLogLevel("test", "debug")
+	log.Debugw("scooby", "dump", Lazy(value))
+	if calls != 1 {
+		t.Errorf("lazy value evaluated %d times, want 1", calls)
+	}
+	if got := buf.String(); !strings.Contains(got, `"dump":{"snacks":2}`) {
+		t.Errorf("got %q, want the evaluated value", got)
+	}
+}
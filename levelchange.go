@@ -0,0 +1,102 @@
+package log
+
+import (
+	"sort"
+
+	"go.uber.org/zap/zapcore"
+)
+
+// LevelChangeFunc is called with the previous and the new level of a
+// subsystem whose level changed. See OnLevelChange.
+type LevelChangeFunc func(subsystem string, old, new LogLevel)
+
+type levelListener struct {
+	f LevelChangeFunc
+}
+
+// OnLevelChange registers f to be called whenever the level of a subsystem
+// changes, through SetLogLevel, SetupLogging or any other means. This allows
+// applications to mirror level changes to metrics, persist them or propagate
+// them to worker processes.
+//
+// f is called synchronously by whoever changed the level, once per changed
+// subsystem. Subsystems that get their first level of their own are reported
+// as changing from the default level. The returned function unregisters f.
+func OnLevelChange(f LevelChangeFunc) (cancel func()) {
+	return defaultRegistry.OnLevelChange(f)
+}
+
+// OnLevelChange registers a level change callback with this registry. See
+// the package-level OnLevelChange.
+func (r *Registry) OnLevelChange(f LevelChangeFunc) (cancel func()) {
+	l := &levelListener{f: f}
+
+	r.levelChangeMu.Lock()
+	if len(r.levelListeners) == 0 {
+		r.lastLevels, _ = r.currentLevels()
+	}
+	r.levelListeners[l] = struct{}{}
+	r.levelChangeMu.Unlock()
+
+	return func() {
+		r.levelChangeMu.Lock()
+		defer r.levelChangeMu.Unlock()
+
+		delete(r.levelListeners, l)
+		if len(r.levelListeners) == 0 {
+			r.lastLevels = nil
+		}
+	}
+}
+
+// currentLevels returns the levels of all subsystems along with the default
+// level.
+func (r *Registry) currentLevels() (map[string]zapcore.Level, zapcore.Level) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	levels := make(map[string]zapcore.Level, len(r.levels))
+	for name, level := range r.levels {
+		levels[name] = level.Level()
+	}
+	return levels, zapcore.Level(r.defaultLevel)
+}
+
+// notifyLevelChanges calls the OnLevelChange callbacks for the levels that
+// changed since the last call. It must be called without holding r.mu.
+func (r *Registry) notifyLevelChanges() {
+	type change struct {
+		name     string
+		old, new LogLevel
+	}
+
+	r.levelChangeMu.Lock()
+	if len(r.levelListeners) == 0 {
+		r.levelChangeMu.Unlock()
+		return
+	}
+	levels, defaultLevel := r.currentLevels()
+	var changes []change
+	for name, level := range levels {
+		old, ok := r.lastLevels[name]
+		if !ok {
+			old = defaultLevel
+		}
+		if old != level {
+			changes = append(changes, change{name: name, old: LogLevel(old), new: LogLevel(level)})
+		}
+	}
+	r.lastLevels = levels
+	listeners := make([]*levelListener, 0, len(r.levelListeners))
+	for l := range r.levelListeners {
+		listeners = append(listeners, l)
+	}
+	r.levelChangeMu.Unlock()
+
+	sort.Slice(changes, func(i, j int) bool { return changes[i].name < changes[j].name })
+	for _, c := range changes {
+		for _, l := range listeners {
+			l.f(c.name, c.old, c.new)
+		}
+	}
+}
@@ -0,0 +1,43 @@
+package log
+
+import (
+	"fmt"
+	"reflect"
+	"testing"
+)
+
+func TestOnLevelChange(t *testing.T) {
+	reg := NewRegistry(Config{Level: LevelError})
+	reg.Logger("dht")
+	reg.Logger("bitswap")
+
+	var changes []string
+	cancel := reg.OnLevelChange(func(subsystem string, old, new LogLevel) {
+		changes = append(changes, fmt.Sprintf("%s:%s->%s", subsystem, old, new))
+	})
+
+	if err := reg.SetLogLevel("dht", "debug"); err != nil {
+		t.Fatal(err)
+	}
+	if err := reg.SetLogLevel("dht", "debug"); err != nil {
+		t.Fatal(err)
+	}
+	reg.SetAllLoggers(LevelInfo)
+	reg.Logger("new")
+
+	want := []string{
+		"dht:error->debug",
+		"bitswap:error->info",
+		"dht:debug->info",
+	}
+	if !reflect.DeepEqual(changes, want) {
+		t.Errorf("got changes %v, want %v", changes, want)
+	}
+
+	cancel()
+	changes = nil
+	reg.SetAllLoggers(LevelWarn)
+	if len(changes) != 0 {
+		t.Errorf("got changes %v after cancel", changes)
+	}
+}
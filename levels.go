@@ -1,12 +1,24 @@
 package log
 
-import "go.uber.org/zap/zapcore"
+import (
+	"strings"
+
+	"go.uber.org/zap/zapcore"
+)
+
+// traceLevel is below zap's debug level, for very chatty logging.
+const traceLevel = zapcore.DebugLevel - 1
+
+// offLevel is above zap's fatal level, so that nothing is logged. Fatal
+// and panic entries still exit and panic.
+const offLevel = zapcore.FatalLevel + 1
 
 // LogLevel represents a log severity level. Use the package variables as an
 // enum.
 type LogLevel zapcore.Level
 
 var (
+	LevelTrace  = LogLevel(traceLevel)
 	LevelDebug  = LogLevel(zapcore.DebugLevel)
 	LevelInfo   = LogLevel(zapcore.InfoLevel)
 	LevelWarn   = LogLevel(zapcore.WarnLevel)
@@ -14,17 +26,50 @@ var (
 	LevelDPanic = LogLevel(zapcore.DPanicLevel)
 	LevelPanic  = LogLevel(zapcore.PanicLevel)
 	LevelFatal  = LogLevel(zapcore.FatalLevel)
+	LevelOff    = LogLevel(offLevel)
 )
 
 // LevelFromString parses a string-based level and returns the corresponding
 // LogLevel.
 //
-// Supported strings are: DEBUG, INFO, WARN, ERROR, DPANIC, PANIC, FATAL, and
-// their lower-case forms.
+// Supported strings are: TRACE, DEBUG, INFO, WARN, ERROR, DPANIC, PANIC,
+// FATAL, OFF (or NONE), and their lower-case forms.
 //
 // The returned LogLevel must be discarded if error is not nil.
 func LevelFromString(level string) (LogLevel, error) {
+	switch {
+	case strings.EqualFold(level, "trace"):
+		return LevelTrace, nil
+	case strings.EqualFold(level, "off"), strings.EqualFold(level, "none"):
+		return LevelOff, nil
+	}
 	lvl := zapcore.InfoLevel // zero value
 	err := lvl.Set(level)
 	return LogLevel(lvl), err
 }
+
+// String returns the lower-case name of the level.
+func (l LogLevel) String() string {
+	switch zapcore.Level(l) {
+	case traceLevel:
+		return "trace"
+	case offLevel:
+		return "off"
+	}
+	return zapcore.Level(l).String()
+}
+
+// MarshalText encodes the level as its name, e.g. in JSON.
+func (l LogLevel) MarshalText() ([]byte, error) {
+	return []byte(l.String()), nil
+}
+
+// UnmarshalText parses a level name as accepted by LevelFromString.
+func (l *LogLevel) UnmarshalText(text []byte) error {
+	lvl, err := LevelFromString(string(text))
+	if err != nil {
+		return err
+	}
+	*l = lvl
+	return nil
+}
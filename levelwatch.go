@@ -0,0 +1,108 @@
+package log
+
+import (
+	"sync"
+
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+)
+
+type levelActivation struct {
+	mu     sync.Mutex // serializes start/stop
+	active bool
+
+	level   zapcore.Level
+	enabler zap.AtomicLevel
+	start   func()
+	stop    func()
+}
+
+// OnLevelActive arranges for start to be called whenever the given subsystem
+// becomes at least as verbose as level, and for stop to be called when it no
+// longer is. This allows libraries to attach expensive instrumentation (e.g.
+// periodic state dumps) only while, say, debug logging is in effect.
+//
+// If the subsystem is already verbose enough, start is called before
+// OnLevelActive returns. Either callback may be nil. Callbacks are invoked
+// synchronously by whoever changes the level and must not themselves change
+// the level of the subsystem.
+//
+// The returned function unregisters the callbacks, calling stop first if the
+// level is active.
+func OnLevelActive(subsystem string, level LogLevel, start func(), stop func()) (cancel func()) {
+	return defaultRegistry.OnLevelActive(subsystem, level, start, stop)
+}
+
+// OnLevelActive registers level-gated callbacks with this registry. See the
+// package-level OnLevelActive.
+func (r *Registry) OnLevelActive(subsystem string, level LogLevel, start func(), stop func()) (cancel func()) {
+	r.mu.Lock()
+	enabler, ok := r.levels[subsystem]
+	if !ok {
+		enabler = zap.NewAtomicLevelAt(zapcore.Level(r.defaultLevel))
+		r.levels[subsystem] = enabler
+	}
+	r.mu.Unlock()
+
+	a := &levelActivation{
+		level:   zapcore.Level(level),
+		enabler: enabler,
+		start:   start,
+		stop:    stop,
+	}
+
+	r.activationsMutex.Lock()
+	r.activations[a] = struct{}{}
+	r.activationsMutex.Unlock()
+
+	a.update()
+
+	return func() {
+		r.activationsMutex.Lock()
+		delete(r.activations, a)
+		r.activationsMutex.Unlock()
+
+		a.mu.Lock()
+		defer a.mu.Unlock()
+		a.set(false)
+	}
+}
+
+// updateLevelActivations re-evaluates all registered activations against the
+// current levels, and reports level changes to the OnLevelChange callbacks.
+// It must be called without holding r.mu.
+func (r *Registry) updateLevelActivations() {
+	defer r.notifyLevelChanges()
+
+	r.activationsMutex.Lock()
+	as := make([]*levelActivation, 0, len(r.activations))
+	for a := range r.activations {
+		as = append(as, a)
+	}
+	r.activationsMutex.Unlock()
+
+	for _, a := range as {
+		a.update()
+	}
+}
+
+// update reads the live level, so concurrent updates converge on the latest
+// state regardless of the order in which they run.
+func (a *levelActivation) update() {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	a.set(a.enabler.Enabled(a.level))
+}
+
+func (a *levelActivation) set(active bool) {
+	if active == a.active {
+		return
+	}
+	a.active = active
+	if active && a.start != nil {
+		a.start()
+	} else if !active && a.stop != nil {
+		a.stop()
+	}
+}
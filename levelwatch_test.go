@@ -0,0 +1,54 @@
+package log
+
+import (
+	"testing"
+)
+
+func TestOnLevelActive(t *testing.T) {
+	SetupLogging(Config{Level: LevelInfo})
+
+	var starts, stops int
+	cancel := OnLevelActive("test-activation", LevelDebug, func() { starts++ }, func() { stops++ })
+
+	if starts != 0 || stops != 0 {
+		t.Fatalf("got %d starts, %d stops, wanted none", starts, stops)
+	}
+
+	// The subsystem doesn't have a logger yet but its level is tracked.
+	if err := SetLogLevel("test-activation", "debug"); err != nil {
+		t.Fatal(err)
+	}
+	if starts != 1 || stops != 0 {
+		t.Fatalf("got %d starts, %d stops, wanted 1 start", starts, stops)
+	}
+
+	// Changing to another active level is a no-op.
+	SetAllLoggers(LevelDebug)
+	if starts != 1 || stops != 0 {
+		t.Fatalf("got %d starts, %d stops, wanted 1 start", starts, stops)
+	}
+
+	SetAllLoggers(LevelWarn)
+	if starts != 1 || stops != 1 {
+		t.Fatalf("got %d starts, %d stops, wanted 1 each", starts, stops)
+	}
+
+	getLogger("test-activation")
+	if err := SetLogLevelRegex("test-activ.*", "debug"); err != nil {
+		t.Fatal(err)
+	}
+	if starts != 2 || stops != 1 {
+		t.Fatalf("got %d starts, %d stops, wanted 2 starts, 1 stop", starts, stops)
+	}
+
+	cancel()
+	if starts != 2 || stops != 2 {
+		t.Fatalf("got %d starts, %d stops, wanted 2 each", starts, stops)
+	}
+
+	SetAllLoggers(LevelWarn)
+	SetAllLoggers(LevelDebug)
+	if starts != 2 || stops != 2 {
+		t.Fatalf("callbacks called after cancel")
+	}
+}
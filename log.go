@@ -34,20 +34,7 @@ type EventLogger interface {
 
 // Logger retrieves an event logger by name
 func Logger(system string) *ZapEventLogger {
-	if len(system) == 0 {
-		setuplog := getLogger("setup-logger")
-		setuplog.Error("Missing name parameter")
-		system = "undefined"
-	}
-
-	logger := getLogger(system)
-	skipLogger := logger.Desugar().WithOptions(zap.AddCallerSkip(1)).Sugar()
-
-	return &ZapEventLogger{
-		system:        system,
-		SugaredLogger: *logger,
-		skipLogger:    *skipLogger,
-	}
+	return defaultRegistry.Logger(system)
 }
 
 // ZapEventLogger implements the EventLogger and wraps a go-logging Logger
@@ -56,6 +43,7 @@ type ZapEventLogger struct {
 	// used to fix the caller location when calling Warning and Warningf.
 	skipLogger zap.SugaredLogger
 	system     string
+	reg        *Registry
 }
 
 // Warning is for compatibility
@@ -70,6 +58,32 @@ func (logger *ZapEventLogger) Warningf(format string, args ...interface{}) {
 	logger.skipLogger.Warnf(format, args...)
 }
 
+// With returns a copy of the logger with the given key-value pairs (as
+// accepted by Infow and friends) added to every entry. The copy still reports
+// under the same subsystem and follows its level.
+func (logger *ZapEventLogger) With(kv ...interface{}) *ZapEventLogger {
+	copyLogger := *logger
+	copyLogger.SugaredLogger = *copyLogger.SugaredLogger.With(kv...)
+	copyLogger.skipLogger = *copyLogger.skipLogger.With(kv...)
+	return &copyLogger
+}
+
+// WithOptions returns a copy of the logger with the given zap options
+// applied, e.g. to add hooks or wrap its core. The copy still reports under
+// the same subsystem and follows its level.
+func (logger *ZapEventLogger) WithOptions(opts ...zap.Option) *ZapEventLogger {
+	copyLogger := *logger
+	copyLogger.SugaredLogger = *copyLogger.SugaredLogger.Desugar().WithOptions(opts...).Sugar()
+	copyLogger.skipLogger = *copyLogger.SugaredLogger.Desugar().WithOptions(zap.AddCallerSkip(1)).Sugar()
+	return &copyLogger
+}
+
+// Desugar returns the underlying *zap.Logger, for hot paths logging strongly
+// typed zap.Fields. It shares the logger's subsystem, level and cores.
+func (logger *ZapEventLogger) Desugar() *zap.Logger {
+	return logger.SugaredLogger.Desugar()
+}
+
 // FormatRFC3339 returns the given time in UTC with RFC3999Nano format.
 func FormatRFC3339(t time.Time) string {
 	return t.UTC().Format(time.RFC3339Nano)
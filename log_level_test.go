@@ -1,9 +1,13 @@
 package log
 
 import (
+	"bytes"
 	"encoding/json"
 	"io"
+	"strings"
 	"testing"
+
+	"go.uber.org/zap/zapcore"
 )
 
 func TestLogLevel(t *testing.T) {
@@ -51,3 +55,36 @@ func TestLogLevel(t *testing.T) {
 	}
 	<-done
 }
+
+func TestOffLevel(t *testing.T) {
+	for _, s := range []string{"off", "NONE"} {
+		if lvl, err := LevelFromString(s); err != nil || lvl != LevelOff {
+			t.Errorf("got %v, %v for %q, want the off level", lvl, err, s)
+		}
+	}
+	if s := LevelOff.String(); s != "off" {
+		t.Errorf("got %q, want off", s)
+	}
+
+	t.Setenv(envLogging, "info,noisy=off")
+	cfg, err := ConfigFromEnv()
+	if err != nil {
+		t.Fatal(err)
+	}
+	reg := NewRegistry(cfg)
+	var buf bytes.Buffer
+	reg.SetPrimaryCore(newCore(PlaintextOutput, zapcore.AddSync(&buf), LevelTrace))
+
+	reg.Logger("noisy").Errorw("hidden")
+	reg.Logger("noisy").DPanic("hidden")
+	quiet := reg.Logger("quiet")
+	if err := reg.SetLogLevel("quiet", "off"); err != nil {
+		t.Fatal(err)
+	}
+	quiet.Error("hidden")
+	reg.Logger("other").Info("scooby")
+
+	if got := strings.TrimSpace(buf.String()); strings.Count(got, "\n") != 0 || !strings.Contains(got, "scooby") {
+		t.Errorf("got %q", got)
+	}
+}
@@ -0,0 +1,83 @@
+package log
+
+import (
+	"strings"
+	"testing"
+
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+	"go.uber.org/zap/zaptest/observer"
+)
+
+func TestWithOptions(t *testing.T) {
+	skipWithoutDebug(t)
+
+	reg := NewRegistry(Config{Level: LevelInfo})
+	core, logs := observer.New(zapcore.DebugLevel)
+	reg.SetPrimaryCore(core)
+
+	var hooked []string
+	log := reg.Logger("test").WithOptions(zap.Hooks(func(ent zapcore.Entry) error {
+		hooked = append(hooked, ent.Message)
+		return nil
+	}))
+
+	log.Debug("hidden")
+	log.Info("scooby")
+	reg.SetLogLevel("test", "debug")
+	log.Debug("doo")
+
+	if len(hooked) != 2 || hooked[0] != "scooby" || hooked[1] != "doo" {
+		t.Errorf("got %q, want [scooby doo]", hooked)
+	}
+	if logs.Len() != 2 {
+		t.Fatalf("got %d entries, want 2", logs.Len())
+	}
+	if name := logs.All()[0].LoggerName; name != "test" {
+		t.Errorf("got logger name %q, want test", name)
+	}
+}
+
+func TestDesugar(t *testing.T) {
+	reg := NewRegistry(Config{Level: LevelInfo})
+	core, logs := observer.New(zapcore.DebugLevel)
+	reg.SetPrimaryCore(core)
+
+	log := reg.Logger("test").Desugar()
+	log.Debug("hidden")
+	reg.SetLogLevel("test", "debug")
+	log.Debug("scooby", zap.Int("snacks", 2))
+
+	if logs.Len() != 1 {
+		t.Fatalf("got %d entries, want 1", logs.Len())
+	}
+	if ent := logs.All()[0]; ent.LoggerName != "test" || ent.ContextMap()["snacks"] != int64(2) {
+		t.Errorf("got %+v", ent)
+	}
+}
+
+func TestWith(t *testing.T) {
+	skipWithoutDebug(t)
+
+	reg := NewRegistry(Config{Level: LevelInfo})
+	core, logs := observer.New(zapcore.DebugLevel)
+	reg.SetPrimaryCore(core)
+
+	log := reg.Logger("test").With("peer", "scooby")
+	log.Debug("hidden")
+	reg.SetLogLevel("test", "debug")
+	log.Debugw("doo", "snacks", 2)
+	log.Warning("velma")
+
+	if logs.Len() != 2 {
+		t.Fatalf("got %d entries, want 2", logs.Len())
+	}
+	for _, ent := range logs.All() {
+		if ent.LoggerName != "test" || ent.ContextMap()["peer"] != "scooby" {
+			t.Errorf("got %+v", ent)
+		}
+		if !strings.HasSuffix(ent.Caller.File, "log_test.go") {
+			t.Errorf("got caller %v, want the test", ent.Caller)
+		}
+	}
+}
@@ -1,8 +1,12 @@
 package log
 
 import (
+	"fmt"
+	"io"
 	"sync"
 	"testing"
+
+	"go.uber.org/zap/zapcore"
 )
 
 // To run bencharks:
@@ -66,3 +70,77 @@ func BenchmarkFormatInfoMulti(b *testing.B) {
 	}
 	wg.Wait()
 }
+
+func BenchmarkGetLoggerParallel(b *testing.B) {
+	reg := NewRegistry(Config{Level: LevelError})
+	names := make([]string, 64)
+	for i := range names {
+		names[i] = fmt.Sprintf("bench-%d", i)
+		reg.Logger(names[i])
+	}
+
+	b.ResetTimer()
+	b.ReportAllocs()
+	b.RunParallel(func(pb *testing.PB) {
+		for i := 0; pb.Next(); i++ {
+			reg.getLogger(names[i%len(names)])
+		}
+	})
+}
+
+func BenchmarkSetLogLevelParallel(b *testing.B) {
+	reg := NewRegistry(Config{Level: LevelError})
+	names := make([]string, 64)
+	for i := range names {
+		names[i] = fmt.Sprintf("bench-%d", i)
+		reg.Logger(names[i])
+	}
+
+	b.ResetTimer()
+	b.ReportAllocs()
+	b.RunParallel(func(pb *testing.PB) {
+		for i := 0; pb.Next(); i++ {
+			name := names[i%len(names)]
+			if i%16 == 0 {
+				if err := reg.SetLogLevel(name, "info"); err != nil {
+					b.Fatal(err)
+				}
+			} else {
+				reg.getLogger(name)
+			}
+		}
+	})
+}
+
+func BenchmarkLockedMultiCoreWriteParallel(b *testing.B) {
+	mc := &lockedMultiCore{}
+	mc.AddCore(newCore(JSONOutput, zapcore.AddSync(io.Discard), LevelDebug))
+	mc.AddCore(newCore(JSONOutput, zapcore.AddSync(io.Discard), LevelDebug))
+	entry := zapcore.Entry{LoggerName: "bench", Level: zapcore.InfoLevel, Message: "test"}
+
+	b.ResetTimer()
+	b.ReportAllocs()
+	b.RunParallel(func(pb *testing.PB) {
+		for pb.Next() {
+			_ = mc.Write(entry, nil)
+		}
+	})
+}
+
+func BenchmarkFormats(b *testing.B) {
+	for _, format := range []LogFormat{JSONOutput, PlaintextOutput, ColorizedOutput, PrettyOutput} {
+		cfg := Config{Format: format, Labels: map[string]string{"dc": "sjc-1"}}
+		b.Run(format.String(), func(b *testing.B) {
+			reg := newRegistry()
+			reg.SetPrimaryCore(newConfiguredCore(cfg, zapcore.AddSync(io.Discard), LevelTrace))
+			l := reg.Logger("bench")
+			reg.SetAllLoggers(LevelInfo)
+
+			b.ResetTimer()
+			b.ReportAllocs()
+			for i := 0; i < b.N; i++ {
+				l.Infow("test", "k", "v", "n", i)
+			}
+		})
+	}
+}
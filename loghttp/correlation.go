@@ -0,0 +1,51 @@
+package loghttp
+
+import (
+	"net/http"
+
+	logging "github.com/ipfs/go-log/v2"
+)
+
+// CorrelationIDHeader is the header correlation IDs are read from and
+// returned in.
+const CorrelationIDHeader = "X-Correlation-ID"
+
+// requestIDHeader is also accepted, as set by many proxies.
+const requestIDHeader = "X-Request-ID"
+
+// maxCorrelationIDLength bounds the IDs accepted from clients.
+const maxCorrelationIDLength = 128
+
+// WithCorrelationID wraps next so that the context of each request carries
+// a correlation ID, as set with logging.ContextWithCorrelationID: the one
+// in the X-Correlation-ID or X-Request-ID header of the request if valid,
+// or a new one. The ID is returned in the X-Correlation-ID header of the
+// response, and should be passed along with the requests made on its
+// behalf.
+func WithCorrelationID(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		id := r.Header.Get(CorrelationIDHeader)
+		if id == "" {
+			id = r.Header.Get(requestIDHeader)
+		}
+		if !validCorrelationID(id) {
+			id = logging.NewCorrelationID()
+		}
+		w.Header().Set(CorrelationIDHeader, id)
+		next.ServeHTTP(w, r.WithContext(logging.ContextWithCorrelationID(r.Context(), id)))
+	})
+}
+
+// validCorrelationID reports whether id is short and only made of visible
+// ASCII characters, so that clients can't forge log lines with it.
+func validCorrelationID(id string) bool {
+	if id == "" || len(id) > maxCorrelationIDLength {
+		return false
+	}
+	for i := 0; i < len(id); i++ {
+		if id[i] <= ' ' || id[i] > '~' {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,42 @@
+package loghttp
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	logging "github.com/ipfs/go-log/v2"
+)
+
+func TestWithCorrelationID(t *testing.T) {
+	var got string
+	h := WithCorrelationID(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		got = logging.CorrelationID(r.Context())
+	}))
+
+	for _, tc := range []struct {
+		name, header, value string
+		keep                bool
+	}{
+		{"none", "", "", false},
+		{"correlation", CorrelationIDHeader, "scooby-42", true},
+		{"request", "X-Request-ID", "shaggy-7", true},
+		{"forged", CorrelationIDHeader, "velma\nERROR\tfake", false},
+		{"long", CorrelationIDHeader, strings.Repeat("a", maxCorrelationIDLength+1), false},
+	} {
+		req := httptest.NewRequest(http.MethodGet, "/", nil)
+		if tc.header != "" {
+			req.Header.Set(tc.header, tc.value)
+		}
+		rec := httptest.NewRecorder()
+		h.ServeHTTP(rec, req)
+
+		if got == "" || (got == tc.value) != tc.keep {
+			t.Errorf("%s: got ID %q", tc.name, got)
+		}
+		if echoed := rec.Header().Get(CorrelationIDHeader); echoed != got {
+			t.Errorf("%s: got %q in the response, want %q", tc.name, echoed, got)
+		}
+	}
+}
@@ -0,0 +1,19 @@
+package loghttp
+
+import (
+	"expvar"
+
+	logging "github.com/ipfs/go-log/v2"
+)
+
+// PublishExpvar publishes the snapshot of reg, or of the package-level
+// loggers if reg is nil, as the expvar variable name, served as JSON under
+// /debug/vars. Like expvar.Publish, it panics if name is already taken.
+func PublishExpvar(name string, reg *logging.Registry) {
+	if reg == nil {
+		reg = logging.DefaultRegistry()
+	}
+	expvar.Publish(name, expvar.Func(func() interface{} {
+		return reg.GetSnapshot()
+	}))
+}
@@ -0,0 +1,105 @@
+// Package loghttp exposes the log levels of go-log over HTTP, so that
+// operators can inspect and adjust logging on headless nodes without shell
+// access.
+//
+// The handler serves GET requests with the current levels:
+//
+//	{"default":"error","subsystems":{"dht":"debug","bitswap":"error"}}
+//
+// and PUT requests with a JSON body setting the level of a subsystem, or of
+// all of them when the subsystem is "*":
+//
+//	{"subsystem":"dht","level":"debug"}
+//
+// It performs no authentication: mount it on a listener only trusted
+// parties can reach.
+//
+// NewTailHandler streams the entries as they are logged, and PublishExpvar
+// additionally exposes the whole logging state through expvar.
+// WithCorrelationID tags the requests of a handler with correlation IDs.
+package loghttp
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+
+	logging "github.com/ipfs/go-log/v2"
+)
+
+// Levels is the body of the responses to GET requests.
+type Levels struct {
+	Default    string            `json:"default"`
+	Subsystems map[string]string `json:"subsystems"`
+}
+
+// SetLevel is the body of PUT requests.
+type SetLevel struct {
+	Subsystem string `json:"subsystem"`
+	Level     string `json:"level"`
+}
+
+// maxRequestSize bounds the size of the bodies of PUT requests.
+const maxRequestSize = 4096
+
+type handler struct {
+	reg *logging.Registry
+}
+
+// NewHandler returns an http.Handler reporting and setting the levels of
+// reg, or of the package-level loggers if reg is nil.
+func NewHandler(reg *logging.Registry) http.Handler {
+	if reg == nil {
+		reg = logging.DefaultRegistry()
+	}
+	return &handler{reg: reg}
+}
+
+func (h *handler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	switch r.Method {
+	case http.MethodGet, http.MethodHead:
+		h.get(w)
+	case http.MethodPut:
+		h.put(w, r)
+	default:
+		w.Header().Set("Allow", "GET, HEAD, PUT")
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+	}
+}
+
+func (h *handler) get(w http.ResponseWriter) {
+	levels := Levels{
+		Default:    h.reg.DefaultLevel().String(),
+		Subsystems: make(map[string]string),
+	}
+	for _, name := range h.reg.GetSubsystems() {
+		if lvl, ok := h.reg.SubsystemLevel(name); ok {
+			levels.Subsystems[name] = lvl.String()
+		}
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	_ = json.NewEncoder(w).Encode(levels)
+}
+
+func (h *handler) put(w http.ResponseWriter, r *http.Request) {
+	var req SetLevel
+	if err := json.NewDecoder(http.MaxBytesReader(w, r.Body, maxRequestSize)).Decode(&req); err != nil {
+		http.Error(w, fmt.Sprintf("invalid request: %v", err), http.StatusBadRequest)
+		return
+	}
+	if req.Subsystem == "" {
+		http.Error(w, "invalid request: missing subsystem", http.StatusBadRequest)
+		return
+	}
+
+	switch err := h.reg.SetLogLevel(req.Subsystem, req.Level); {
+	case err == nil:
+		w.WriteHeader(http.StatusNoContent)
+	case errors.Is(err, logging.ErrNoSuchLogger):
+		http.Error(w, err.Error(), http.StatusNotFound)
+	default:
+		http.Error(w, err.Error(), http.StatusBadRequest)
+	}
+}
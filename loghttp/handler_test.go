@@ -0,0 +1,69 @@
+package loghttp
+
+import (
+	"encoding/json"
+	"expvar"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	logging "github.com/ipfs/go-log/v2"
+)
+
+func TestHandler(t *testing.T) {
+	reg := logging.NewRegistry(logging.Config{Level: logging.LevelError})
+	reg.Logger("dht")
+	srv := httptest.NewServer(NewHandler(reg))
+	defer srv.Close()
+
+	put := func(body string) int {
+		req, err := http.NewRequest(http.MethodPut, srv.URL, strings.NewReader(body))
+		if err != nil {
+			t.Fatal(err)
+		}
+		resp, err := http.DefaultClient.Do(req)
+		if err != nil {
+			t.Fatal(err)
+		}
+		resp.Body.Close()
+		return resp.StatusCode
+	}
+
+	if code := put(`{"subsystem":"dht","level":"debug"}`); code != http.StatusNoContent {
+		t.Errorf("got status %d setting a level", code)
+	}
+	if code := put(`{"subsystem":"nope","level":"debug"}`); code != http.StatusNotFound {
+		t.Errorf("got status %d for an unknown subsystem", code)
+	}
+	if code := put(`{"subsystem":"dht","level":"loud"}`); code != http.StatusBadRequest {
+		t.Errorf("got status %d for an invalid level", code)
+	}
+
+	resp, err := http.Get(srv.URL)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer resp.Body.Close()
+	var levels Levels
+	if err := json.NewDecoder(resp.Body).Decode(&levels); err != nil {
+		t.Fatal(err)
+	}
+	if levels.Default != "error" || levels.Subsystems["dht"] != "debug" {
+		t.Errorf("got levels %+v", levels)
+	}
+}
+
+func TestPublishExpvar(t *testing.T) {
+	reg := logging.NewRegistry(logging.Config{Level: logging.LevelError})
+	reg.Logger("dht")
+	PublishExpvar("golog_test", reg)
+
+	var s logging.Snapshot
+	if err := json.Unmarshal([]byte(expvar.Get("golog_test").String()), &s); err != nil {
+		t.Fatal(err)
+	}
+	if s.Subsystems["dht"] != logging.LevelError {
+		t.Errorf("got snapshot %+v", s)
+	}
+}
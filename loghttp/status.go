@@ -0,0 +1,172 @@
+package loghttp
+
+import (
+	"errors"
+	"html/template"
+	"net/http"
+	"net/url"
+	"sort"
+
+	logging "github.com/ipfs/go-log/v2"
+)
+
+var errCustomOutputs = errors.New("outputs were set with SetPrimaryCore and can't be toggled")
+
+// levelNames are the levels offered by the status page.
+var levelNames = []string{"trace", "debug", "info", "warn", "error", "dpanic", "panic", "fatal", "off"}
+
+var statusTemplate = template.Must(template.New("status").Parse(`<!DOCTYPE html>
+<html>
+<head><meta charset="utf-8"><title>Logging</title></head>
+<body>
+<h1>Logging</h1>
+<p>Format: {{.Snapshot.Format}}. Default level: {{.Snapshot.DefaultLevel}}.
+Dropped entries: {{.Snapshot.Metrics.Dropped}}. Write errors: {{.Snapshot.Metrics.WriteErrors}}.</p>
+{{if .Toggles}}
+<h2>Outputs</h2>
+<table>
+{{range .Toggles}}<tr><td>{{.Name}}</td><td><form method="post">
+<input type="hidden" name="output" value="{{.Name}}">
+<input type="hidden" name="enabled" value="{{if .Enabled}}false{{else}}true{{end}}">
+<button type="submit">{{if .Enabled}}Disable{{else}}Enable{{end}}</button>
+</form></td></tr>
+{{end}}</table>
+{{end}}
+<h2>Subsystems</h2>
+<table>
+{{$levels := .Levels}}{{range .Subsystems}}<tr><td>{{.Name}}</td><td><form method="post">
+<input type="hidden" name="subsystem" value="{{.Name}}">
+<select name="level" onchange="this.form.submit()">
+{{$level := .Level}}{{range $levels}}<option{{if eq . $level}} selected{{end}}>{{.}}</option>
+{{end}}</select>
+<noscript><button type="submit">Set</button></noscript>
+</form></td></tr>
+{{end}}</table>
+</body>
+</html>
+`))
+
+type statusPage struct {
+	Snapshot   logging.Snapshot
+	Levels     []string
+	Subsystems []statusSubsystem
+	Toggles    []statusToggle
+}
+
+type statusSubsystem struct {
+	Name  string
+	Level string
+}
+
+type statusToggle struct {
+	Name    string
+	Enabled bool
+}
+
+type statusHandler struct {
+	reg *logging.Registry
+}
+
+// NewStatusHandler returns an http.Handler rendering an HTML page listing
+// the subsystems of reg, or of the package-level loggers if reg is nil, with
+// controls to change their levels and to toggle the standard outputs. It is
+// meant to be mounted on a debug port, and performs no authentication, but
+// rejects changes sent by browsers on behalf of other sites.
+func NewStatusHandler(reg *logging.Registry) http.Handler {
+	if reg == nil {
+		reg = logging.DefaultRegistry()
+	}
+	return &statusHandler{reg: reg}
+}
+
+func (h *statusHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	switch r.Method {
+	case http.MethodGet, http.MethodHead:
+		h.render(w)
+	case http.MethodPost:
+		h.update(w, r)
+	default:
+		w.Header().Set("Allow", "GET, HEAD, POST")
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+	}
+}
+
+func (h *statusHandler) render(w http.ResponseWriter) {
+	page := statusPage{
+		Snapshot: h.reg.GetSnapshot(),
+		Levels:   levelNames,
+	}
+	for name, level := range page.Snapshot.Subsystems {
+		page.Subsystems = append(page.Subsystems, statusSubsystem{Name: name, Level: level.String()})
+	}
+	sort.Slice(page.Subsystems, func(i, j int) bool {
+		return page.Subsystems[i].Name < page.Subsystems[j].Name
+	})
+	if !isCustom(page.Snapshot) {
+		cfg := h.reg.GetConfig()
+		page.Toggles = []statusToggle{
+			{Name: "stderr", Enabled: cfg.Stderr},
+			{Name: "stdout", Enabled: cfg.Stdout},
+		}
+	}
+
+	w.Header().Set("Content-Type", "text/html; charset=utf-8")
+	_ = statusTemplate.Execute(w, page)
+}
+
+func (h *statusHandler) update(w http.ResponseWriter, r *http.Request) {
+	if crossSite(r) {
+		http.Error(w, "cross-site requests are not allowed", http.StatusForbidden)
+		return
+	}
+	r.Body = http.MaxBytesReader(w, r.Body, maxRequestSize)
+	if err := r.ParseForm(); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	var err error
+	switch {
+	case r.PostForm.Get("subsystem") != "":
+		err = h.reg.SetLogLevel(r.PostForm.Get("subsystem"), r.PostForm.Get("level"))
+	case r.PostForm.Get("output") != "":
+		err = h.toggleOutput(r.PostForm.Get("output"), r.PostForm.Get("enabled") == "true")
+	default:
+		http.Error(w, "invalid request: missing subsystem or output", http.StatusBadRequest)
+		return
+	}
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	http.Redirect(w, r, r.URL.Path, http.StatusSeeOther)
+}
+
+// toggleOutput enables or disables the named standard output.
+func (h *statusHandler) toggleOutput(name string, enabled bool) error {
+	if isCustom(h.reg.GetSnapshot()) {
+		return errCustomOutputs
+	}
+	return h.reg.SetStandardOutput(name, enabled)
+}
+
+// crossSite reports whether r was sent by a browser on behalf of a page of
+// another site, or of another port of this host, so that the pages visited
+// by the operator can't change the configuration through the debug port.
+// Requests from other clients carry neither header and are let through.
+func crossSite(r *http.Request) bool {
+	switch r.Header.Get("Sec-Fetch-Site") {
+	case "", "same-origin", "none":
+	default:
+		return true
+	}
+	if origin := r.Header.Get("Origin"); origin != "" {
+		u, err := url.Parse(origin)
+		return err != nil || u.Host != r.Host
+	}
+	return false
+}
+
+func isCustom(s logging.Snapshot) bool {
+	return len(s.Outputs) == 1 && s.Outputs[0] == "custom"
+}
@@ -0,0 +1,113 @@
+package loghttp
+
+import (
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+
+	logging "github.com/ipfs/go-log/v2"
+)
+
+func TestStatusHandler(t *testing.T) {
+	reg := logging.NewRegistry(logging.Config{Level: logging.LevelError, Stderr: true})
+	reg.Logger("dht")
+	reg.Logger("bitswap")
+	reg.Logger("net")
+	if err := reg.SetLogLevel("bitswap", "info"); err != nil {
+		t.Fatal(err)
+	}
+	if err := reg.SetLogLevel("net", "off"); err != nil {
+		t.Fatal(err)
+	}
+	srv := httptest.NewServer(NewStatusHandler(reg))
+	defer srv.Close()
+
+	resp, err := http.Get(srv.URL)
+	if err != nil {
+		t.Fatal(err)
+	}
+	body, _ := io.ReadAll(resp.Body)
+	resp.Body.Close()
+	page := string(body)
+	if !strings.Contains(page, `value="dht"`) || !strings.Contains(page, `<option selected>info</option>`) {
+		t.Errorf("page misses the subsystems:\n%s", page)
+	}
+	if !strings.Contains(page, `<option selected>off</option>`) {
+		t.Errorf("page misses the off level:\n%s", page)
+	}
+
+	resp, err = http.PostForm(srv.URL, url.Values{"subsystem": {"dht"}, "level": {"debug"}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp.Body.Close()
+	if lvl, _ := reg.SubsystemLevel("dht"); lvl != logging.LevelDebug {
+		t.Errorf("got level %s, want debug", lvl)
+	}
+
+	resp, err = http.PostForm(srv.URL, url.Values{"output": {"stdout"}, "enabled": {"true"}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		t.Errorf("got status %d toggling stdout", resp.StatusCode)
+	}
+	if cfg := reg.GetConfig(); !cfg.Stdout || !cfg.Stderr {
+		t.Errorf("got config %+v, want both standard outputs", cfg)
+	}
+	if lvl, _ := reg.SubsystemLevel("dht"); lvl != logging.LevelDebug {
+		t.Errorf("got level %s after toggling an output, want debug", lvl)
+	}
+}
+
+func TestStatusHandlerCrossSite(t *testing.T) {
+	reg := logging.NewRegistry(logging.Config{Level: logging.LevelError, Stderr: true})
+	reg.Logger("dht")
+	srv := httptest.NewServer(NewStatusHandler(reg))
+	defer srv.Close()
+
+	for _, header := range []http.Header{
+		{"Origin": {"https://example.com"}},
+		{"Origin": {"null"}},
+		{"Sec-Fetch-Site": {"cross-site"}},
+		{"Sec-Fetch-Site": {"same-site"}},
+	} {
+		req, err := http.NewRequest(http.MethodPost, srv.URL, strings.NewReader("subsystem=dht&level=debug"))
+		if err != nil {
+			t.Fatal(err)
+		}
+		req.Header = header
+		req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+		resp, err := http.DefaultClient.Do(req)
+		if err != nil {
+			t.Fatal(err)
+		}
+		resp.Body.Close()
+		if resp.StatusCode != http.StatusForbidden {
+			t.Errorf("got status %d with %v, want the request rejected", resp.StatusCode, header)
+		}
+	}
+	if lvl, _ := reg.SubsystemLevel("dht"); lvl != logging.LevelError {
+		t.Errorf("got level %s, want the cross-site requests ignored", lvl)
+	}
+
+	req, err := http.NewRequest(http.MethodPost, srv.URL, strings.NewReader("subsystem=dht&level=debug"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	req.Header.Set("Origin", srv.URL)
+	req.Header.Set("Sec-Fetch-Site", "same-origin")
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp.Body.Close()
+	if lvl, _ := reg.SubsystemLevel("dht"); lvl != logging.LevelDebug {
+		t.Errorf("got level %s, want the same-origin request applied", lvl)
+	}
+}
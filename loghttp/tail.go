@@ -0,0 +1,84 @@
+package loghttp
+
+import (
+	"io"
+	"net/http"
+
+	logging "github.com/ipfs/go-log/v2"
+)
+
+type tailHandler struct {
+	reg *logging.Registry
+}
+
+// tailQueueSize is the number of entries queued for each client of the tail
+// handler before entries are dropped.
+const tailQueueSize = 1024
+
+// NewTailHandler returns an http.Handler streaming the entries of reg, or of
+// the package-level loggers if reg is nil, as JSON lines until the client
+// goes away. The stream starts with a PipeStreamHeader line. The optional
+// "level" query parameter sets the minimum level of the streamed entries,
+// e.g. /logs?level=warn.
+//
+// Entries are queued for each client, so a slow client doesn't slow down
+// logging: the entries logged while its queue is full are dropped and
+// counted in Metrics.Dropped.
+func NewTailHandler(reg *logging.Registry) http.Handler {
+	if reg == nil {
+		reg = logging.DefaultRegistry()
+	}
+	return &tailHandler{reg: reg}
+}
+
+func (h *tailHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		w.Header().Set("Allow", "GET")
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	opts := []logging.PipeReaderOption{
+		logging.PipeFormat(logging.JSONOutput),
+		logging.PipeHeader(),
+		logging.PipeQueue(tailQueueSize),
+	}
+	if level := r.URL.Query().Get("level"); level != "" {
+		lvl, err := logging.LevelFromString(level)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		opts = append(opts, logging.PipeLevel(lvl))
+	}
+
+	pipe := h.reg.NewPipeReader(opts...)
+	defer pipe.Close()
+	go func() {
+		<-r.Context().Done()
+		pipe.Close()
+	}()
+
+	w.Header().Set("Content-Type", "application/x-ndjson")
+	w.WriteHeader(http.StatusOK)
+	flusher, _ := w.(http.Flusher)
+	if flusher != nil {
+		flusher.Flush()
+	}
+	_, _ = io.Copy(flushWriter{w: w, f: flusher}, pipe)
+}
+
+// flushWriter flushes every write, so that entries are streamed as they are
+// logged.
+type flushWriter struct {
+	w io.Writer
+	f http.Flusher
+}
+
+func (fw flushWriter) Write(p []byte) (int, error) {
+	n, err := fw.w.Write(p)
+	if fw.f != nil {
+		fw.f.Flush()
+	}
+	return n, err
+}
@@ -0,0 +1,73 @@
+package loghttp
+
+import (
+	"bufio"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	logging "github.com/ipfs/go-log/v2"
+)
+
+func TestTailHandler(t *testing.T) {
+	reg := logging.NewRegistry(logging.Config{Level: logging.LevelDebug})
+	log := reg.Logger("dht")
+	srv := httptest.NewServer(NewTailHandler(reg))
+	defer srv.Close()
+
+	resp, err := http.Get(srv.URL + "?level=info")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		t.Fatalf("got status %d", resp.StatusCode)
+	}
+
+	// The pipe may not be registered yet when the headers arrive.
+	stop := make(chan struct{})
+	defer close(stop)
+	go func() {
+		for {
+			log.Debug("hidden")
+			log.Info("scooby")
+			select {
+			case <-stop:
+				return
+			case <-time.After(10 * time.Millisecond):
+			}
+		}
+	}()
+
+	body := bufio.NewReader(resp.Body)
+	line, err := body.ReadBytes('\n')
+	if err != nil {
+		t.Fatal(err)
+	}
+	var header logging.PipeStreamHeader
+	if err := json.Unmarshal(line, &header); err != nil || header.Golog.Version != logging.PipeStreamVersion {
+		t.Fatalf("got %s, wanted the stream header", line)
+	}
+	line, err = body.ReadBytes('\n')
+	if err != nil {
+		t.Fatal(err)
+	}
+	var entry map[string]interface{}
+	if err := json.Unmarshal(line, &entry); err != nil {
+		t.Fatal(err)
+	}
+	if entry["msg"] != "scooby" || entry["logger"] != "dht" {
+		t.Errorf("got %s", line)
+	}
+
+	resp, err = http.Get(srv.URL + "?level=loud")
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusBadRequest {
+		t.Errorf("got status %d for an invalid level", resp.StatusCode)
+	}
+}
@@ -0,0 +1,74 @@
+// Package logprom exposes the logging activity of go-log as Prometheus
+// metrics.
+//
+// It is a module of its own, so that only the programs using it depend on
+// the Prometheus client.
+package logprom
+
+import (
+	logging "github.com/ipfs/go-log/v2"
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+var (
+	entriesDesc = prometheus.NewDesc(
+		"golog_entries_total",
+		"Number of log entries emitted, by subsystem and level.",
+		[]string{"subsystem", "level"}, nil,
+	)
+	droppedDesc = prometheus.NewDesc(
+		"golog_dropped_entries_total",
+		"Number of log entries discarded before reaching the outputs.",
+		nil, nil,
+	)
+	writeErrorsDesc = prometheus.NewDesc(
+		"golog_write_errors_total",
+		"Number of failed writes and syncs to the log outputs.",
+		nil, nil,
+	)
+	evictedDesc = prometheus.NewDesc(
+		"golog_evicted_loggers_total",
+		"Number of loggers released to stay within the configured maximum.",
+		nil, nil,
+	)
+	diskGuardDesc = prometheus.NewDesc(
+		"golog_disk_guard_trips_total",
+		"Number of times writing to the log file was suspended to save disk space.",
+		nil, nil,
+	)
+)
+
+type collector struct {
+	reg *logging.Registry
+}
+
+// NewCollector returns a prometheus.Collector reporting the logging activity
+// of reg, or of the package-level loggers if reg is nil.
+func NewCollector(reg *logging.Registry) prometheus.Collector {
+	if reg == nil {
+		reg = logging.DefaultRegistry()
+	}
+	return &collector{reg: reg}
+}
+
+func (c *collector) Describe(ch chan<- *prometheus.Desc) {
+	ch <- entriesDesc
+	ch <- droppedDesc
+	ch <- writeErrorsDesc
+	ch <- evictedDesc
+	ch <- diskGuardDesc
+}
+
+func (c *collector) Collect(ch chan<- prometheus.Metric) {
+	m := c.reg.GetMetrics()
+	for subsystem, byLevel := range m.Entries {
+		for level, n := range byLevel {
+			ch <- prometheus.MustNewConstMetric(entriesDesc, prometheus.CounterValue, float64(n),
+				subsystem, level.String())
+		}
+	}
+	ch <- prometheus.MustNewConstMetric(droppedDesc, prometheus.CounterValue, float64(m.Dropped))
+	ch <- prometheus.MustNewConstMetric(writeErrorsDesc, prometheus.CounterValue, float64(m.WriteErrors))
+	ch <- prometheus.MustNewConstMetric(evictedDesc, prometheus.CounterValue, float64(m.Evicted))
+	ch <- prometheus.MustNewConstMetric(diskGuardDesc, prometheus.CounterValue, float64(m.DiskGuardTrips))
+}
@@ -0,0 +1,28 @@
+package logprom
+
+import (
+	"strings"
+	"testing"
+
+	logging "github.com/ipfs/go-log/v2"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+)
+
+func TestCollector(t *testing.T) {
+	reg := logging.NewRegistry(logging.Config{Level: logging.LevelInfo})
+	log := reg.Logger("test")
+	log.Debug("hidden")
+	log.Info("scooby")
+	log.Info("doo")
+	log.Error("velma")
+
+	expected := `
+# HELP golog_entries_total Number of log entries emitted, by subsystem and level.
+# TYPE golog_entries_total counter
+golog_entries_total{level="error",subsystem="test"} 1
+golog_entries_total{level="info",subsystem="test"} 2
+`
+	if err := testutil.CollectAndCompare(NewCollector(reg), strings.NewReader(expected), "golog_entries_total"); err != nil {
+		t.Error(err)
+	}
+}
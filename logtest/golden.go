@@ -0,0 +1,157 @@
+package logtest
+
+import (
+	"bytes"
+	"errors"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	logging "github.com/ipfs/go-log/v2"
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+)
+
+// envUpdateGolden makes CheckGoldenFormats and CheckGoldenEncoder rewrite the
+// golden files with the current output instead of comparing against them.
+const envUpdateGolden = "GOLOG_UPDATE_GOLDEN"
+
+// goldenEntry is an entry of the canned set rendered into golden files.
+type goldenEntry struct {
+	ent    zapcore.Entry
+	fields []zapcore.Field
+}
+
+var goldenTime = time.Date(2010, 5, 23, 15, 14, 0, 0, time.UTC)
+
+// goldenEntries returns the canned set of entries, covering each level, the
+// optional parts of entries and the common field types.
+func goldenEntries() []goldenEntry {
+	caller := zapcore.EntryCaller{
+		Defined:  true,
+		PC:       1,
+		File:     "/go/src/example.com/mystery/machine.go",
+		Line:     42,
+		Function: "example.com/mystery.(*Machine).Drive",
+	}
+	return []goldenEntry{{
+		ent: zapcore.Entry{Level: zapcore.InfoLevel, Time: goldenTime, LoggerName: "mystery", Message: "scooby", Caller: caller},
+		fields: []zapcore.Field{
+			zap.String("name", "doo"),
+			zap.Int("snacks", 3),
+			zap.Bool("hungry", true),
+		},
+	}, {
+		ent: zapcore.Entry{Level: zapcore.DebugLevel, Time: goldenTime.Add(1500 * time.Millisecond), LoggerName: "mystery/van", Message: `"jinkies" – ünïcode`, Caller: caller},
+		fields: []zapcore.Field{
+			zap.Duration("elapsed", 1500*time.Millisecond),
+			zap.Float64("speed", 88.5),
+			zap.Strings("gang", []string{"fred", "daphne", "velma"}),
+		},
+	}, {
+		ent: zapcore.Entry{Level: zapcore.WarnLevel, Time: goldenTime.Add(2 * time.Second), LoggerName: "mystery", Message: "first line\nsecond line", Caller: caller},
+		fields: []zapcore.Field{
+			zap.String("path", "/haunted/mansion"),
+		},
+	}, {
+		ent: zapcore.Entry{
+			Level:      zapcore.ErrorLevel,
+			Time:       goldenTime.Add(3 * time.Second),
+			LoggerName: "mystery",
+			Message:    "unmasked",
+			Caller:     caller,
+			Stack:      "example.com/mystery.(*Machine).Drive\n\t/go/src/example.com/mystery/machine.go:42",
+		},
+		fields: []zapcore.Field{
+			zap.Error(errors.New("it was old man jenkins")),
+			zap.Object("villain", zapcore.ObjectMarshalerFunc(func(enc zapcore.ObjectEncoder) error {
+				enc.AddString("name", "jenkins")
+				enc.AddInt("age", 67)
+				return nil
+			})),
+		},
+	}, {
+		ent: zapcore.Entry{Level: zapcore.InfoLevel, Time: goldenTime.Add(4 * time.Second), Message: "no logger, caller or fields"},
+	}}
+}
+
+// renderGolden renders the canned set of entries with enc.
+func renderGolden(enc zapcore.Encoder) ([]byte, error) {
+	var out bytes.Buffer
+	for _, e := range goldenEntries() {
+		buf, err := enc.EncodeEntry(e.ent, e.fields)
+		if err != nil {
+			return nil, err
+		}
+		out.Write(buf.Bytes())
+		buf.Free()
+	}
+	return out.Bytes(), nil
+}
+
+// CheckGoldenFormats renders a canned set of entries through every LogFormat
+// and compares the output against the golden files in dir, named after the
+// formats as accepted by GOLOG_LOG_FMT (e.g. dir/json.golden). Run the test
+// with GOLOG_UPDATE_GOLDEN=true to (re)write the golden files.
+func CheckGoldenFormats(t testing.TB, dir string) {
+	t.Helper()
+	for _, format := range logging.LogFormats() {
+		CheckGoldenEncoder(t, filepath.Join(dir, format.String()+".golden"), logging.NewEncoder(format))
+	}
+}
+
+// CheckGoldenEncoder renders the canned set of entries of CheckGoldenFormats
+// with enc and compares the output against the golden file at path, so that
+// encoders not provided by this package can be guarded the same way.
+func CheckGoldenEncoder(t testing.TB, path string, enc zapcore.Encoder) {
+	t.Helper()
+
+	got, err := renderGolden(enc)
+	if err != nil {
+		t.Errorf("%s: encoding failed: %s", path, err)
+		return
+	}
+
+	if update, _ := strconv.ParseBool(os.Getenv(envUpdateGolden)); update {
+		if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, got, 0o644); err != nil {
+			t.Fatal(err)
+		}
+		return
+	}
+
+	want, err := os.ReadFile(path)
+	if err != nil {
+		t.Errorf("%s: %s (run with %s=true to create it)", path, err, envUpdateGolden)
+		return
+	}
+	if !bytes.Equal(got, want) {
+		line, gotLine, wantLine := firstDiff(string(got), string(want))
+		t.Errorf("%s: output differs at line %d:\n got: %q\nwant: %q\n(run with %s=true to update it)",
+			path, line, gotLine, wantLine, envUpdateGolden)
+	}
+}
+
+// firstDiff returns the number and contents of the first line that differs
+// between got and want.
+func firstDiff(got, want string) (int, string, string) {
+	gotLines := strings.SplitAfter(got, "\n")
+	wantLines := strings.SplitAfter(want, "\n")
+	for i := 0; ; i++ {
+		var g, w string
+		if i < len(gotLines) {
+			g = gotLines[i]
+		}
+		if i < len(wantLines) {
+			w = wantLines[i]
+		}
+		if g != w || i >= len(gotLines) {
+			return i + 1, g, w
+		}
+	}
+}
@@ -0,0 +1,53 @@
+package logtest
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	logging "github.com/ipfs/go-log/v2"
+)
+
+func TestGoldenFormats(t *testing.T) {
+	CheckGoldenFormats(t, "testdata")
+}
+
+type erroringTB struct {
+	testing.TB
+	errors []string
+}
+
+func (e *erroringTB) Errorf(format string, args ...interface{}) {
+	e.errors = append(e.errors, format)
+}
+
+func TestCheckGoldenEncoderMismatch(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "json.golden")
+	if err := os.WriteFile(path, []byte("{}\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	rec := &erroringTB{TB: t}
+	CheckGoldenEncoder(rec, path, logging.NewEncoder(logging.JSONOutput))
+	if len(rec.errors) != 1 || !strings.Contains(rec.errors[0], "differs at line") {
+		t.Fatalf("got errors %q, wanted a mismatch", rec.errors)
+	}
+
+	rec = &erroringTB{TB: t}
+	CheckGoldenEncoder(rec, filepath.Join(t.TempDir(), "missing.golden"), logging.NewEncoder(logging.JSONOutput))
+	if len(rec.errors) != 1 {
+		t.Fatalf("got errors %q, wanted a missing golden file", rec.errors)
+	}
+}
+
+func TestFirstDiff(t *testing.T) {
+	line, got, want := firstDiff("a\nb\n", "a\nc\n")
+	if line != 2 || got != "b\n" || want != "c\n" {
+		t.Fatalf("got %d %q %q", line, got, want)
+	}
+	line, got, want = firstDiff("a\n", "a\nb\n")
+	if line != 2 || got != "" || want != "b\n" {
+		t.Fatalf("got %d %q %q", line, got, want)
+	}
+}
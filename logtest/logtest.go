@@ -0,0 +1,117 @@
+// Package logtest provides helpers for testing code that logs with go-log,
+// like zaptest does for zap.
+package logtest
+
+import (
+	"bytes"
+	"sync"
+	"testing"
+
+	logging "github.com/ipfs/go-log/v2"
+	"github.com/ipfs/go-log/v2/internal/testhooks"
+	"go.uber.org/zap/zapcore"
+	"go.uber.org/zap/zaptest/observer"
+)
+
+// IntoTesting redirects all log output to t.Log for the duration of the test
+// by swapping out the primary core. The previous primary core is restored
+// when the test and its subtests complete, so package logs show up attached
+// to the test that produced them instead of polluting stderr.
+//
+// Other cores (e.g. those of PipeReaders) are not affected, nor are log
+// levels.
+func IntoTesting(t testing.TB) {
+	w := &testingWriter{t: t}
+	core := zapcore.NewCore(logging.NewEncoder(logging.PlaintextOutput), w, zapcore.Level(logging.LevelTrace))
+	restore := testhooks.ReplacePrimaryCore(core)
+
+	t.Cleanup(func() {
+		restore()
+		w.close()
+	})
+}
+
+// testingWriter is a zapcore.WriteSyncer that writes to a testing.TB. Writes
+// after close are dropped, as calling t.Log once a test has completed panics.
+type testingWriter struct {
+	mu     sync.Mutex // guards t and closed
+	t      testing.TB
+	closed bool
+}
+
+var _ zapcore.WriteSyncer = (*testingWriter)(nil)
+
+func (w *testingWriter) Write(p []byte) (int, error) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	if !w.closed {
+		// t.Log adds a newline of its own.
+		w.t.Log(string(bytes.TrimSuffix(p, []byte{'\n'})))
+	}
+	return len(p), nil
+}
+
+func (w *testingWriter) Sync() error {
+	return nil
+}
+
+func (w *testingWriter) close() {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	w.closed = true
+}
+
+// CapturedLogs holds entries captured by CaptureLogs. Filter methods return a
+// new CapturedLogs holding a snapshot of the matching entries.
+type CapturedLogs struct {
+	logs *observer.ObservedLogs
+}
+
+// CaptureLogs records every entry emitted by any logger, regardless of the
+// primary core, until the test completes. Entries are only recorded if they
+// are enabled by the level of their subsystem.
+func CaptureLogs(t testing.TB) *CapturedLogs {
+	core, logs := observer.New(zapcore.Level(logging.LevelTrace))
+	t.Cleanup(logging.RegisterHook(logging.LevelTrace, core.Write))
+
+	return &CapturedLogs{logs: logs}
+}
+
+// Entries returns a copy of all captured entries.
+func (c *CapturedLogs) Entries() []observer.LoggedEntry {
+	return c.logs.All()
+}
+
+// Len returns the number of captured entries.
+func (c *CapturedLogs) Len() int {
+	return c.logs.Len()
+}
+
+// FilterMessage returns the entries with the given message.
+func (c *CapturedLogs) FilterMessage(msg string) *CapturedLogs {
+	return &CapturedLogs{logs: c.logs.FilterMessage(msg)}
+}
+
+// FilterMessageSnippet returns the entries whose message contains snippet.
+func (c *CapturedLogs) FilterMessageSnippet(snippet string) *CapturedLogs {
+	return &CapturedLogs{logs: c.logs.FilterMessageSnippet(snippet)}
+}
+
+// FilterSubsystem returns the entries emitted by the named subsystem.
+func (c *CapturedLogs) FilterSubsystem(name string) *CapturedLogs {
+	return &CapturedLogs{logs: c.logs.Filter(func(e observer.LoggedEntry) bool {
+		return e.LoggerName == name
+	})}
+}
+
+// FilterLevel returns the entries logged at exactly the given level.
+func (c *CapturedLogs) FilterLevel(level logging.LogLevel) *CapturedLogs {
+	return &CapturedLogs{logs: c.logs.FilterLevelExact(zapcore.Level(level))}
+}
+
+// FilterField returns the entries carrying the given field.
+func (c *CapturedLogs) FilterField(field zapcore.Field) *CapturedLogs {
+	return &CapturedLogs{logs: c.logs.FilterField(field)}
+}
@@ -0,0 +1,94 @@
+package logtest
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+
+	logging "github.com/ipfs/go-log/v2"
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+)
+
+type recordingTB struct {
+	testing.TB
+	logs     []string
+	cleanups []func()
+}
+
+func (r *recordingTB) Log(args ...interface{}) {
+	r.logs = append(r.logs, args[0].(string))
+}
+
+func (r *recordingTB) Cleanup(f func()) {
+	r.cleanups = append(r.cleanups, f)
+}
+
+func TestIntoTesting(t *testing.T) {
+	logging.SetupLogging(logging.Config{Level: logging.LevelInfo})
+	var buf bytes.Buffer
+	logging.SetPrimaryCore(zapcore.NewCore(logging.NewEncoder(logging.PlaintextOutput), zapcore.AddSync(&buf), zapcore.DebugLevel))
+	log := logging.Logger("test")
+
+	rec := &recordingTB{TB: t}
+	IntoTesting(rec)
+
+	log.Info("scooby")
+
+	if len(rec.logs) != 1 || !strings.HasSuffix(rec.logs[0], "\tscooby") {
+		t.Fatalf("got %q, wanted one entry containing scooby", rec.logs)
+	}
+	if buf.Len() != 0 {
+		t.Errorf("got %q, wanted no logs on the previous core", buf.String())
+	}
+
+	for _, f := range rec.cleanups {
+		f()
+	}
+	log.Info("doo")
+
+	if len(rec.logs) != 1 {
+		t.Errorf("got %q, wanted no logs after cleanup", rec.logs)
+	}
+	if !strings.Contains(buf.String(), "doo") {
+		t.Errorf("got %q, wanted the previous core restored", buf.String())
+	}
+}
+
+func TestCaptureLogs(t *testing.T) {
+	logging.SetupLogging(logging.Config{Level: logging.LevelInfo})
+	log1 := logging.Logger("test1")
+	log2 := logging.Logger("test2")
+
+	var logs *CapturedLogs
+	t.Run("capture", func(t *testing.T) {
+		logs = CaptureLogs(t)
+
+		log1.Debug("hidden")
+		log1.Infow("scooby", "snack", 1)
+		log2.Warn("scooby")
+		log2.Error("doo")
+
+		if n := logs.Len(); n != 3 {
+			t.Errorf("got %d entries, wanted 3", n)
+		}
+		if n := logs.FilterMessage("scooby").Len(); n != 2 {
+			t.Errorf("got %d scooby entries, wanted 2", n)
+		}
+		entries := logs.FilterSubsystem("test2").FilterMessage("scooby").Entries()
+		if len(entries) != 1 || entries[0].Level != zapcore.WarnLevel {
+			t.Errorf("got %v, wanted one warning", entries)
+		}
+		if n := logs.FilterLevel(logging.LevelError).Len(); n != 1 {
+			t.Errorf("got %d error entries, wanted 1", n)
+		}
+		if n := logs.FilterField(zap.Int("snack", 1)).Len(); n != 1 {
+			t.Errorf("got %d entries with field, wanted 1", n)
+		}
+	})
+
+	log1.Error("after")
+	if n := logs.Len(); n != 3 {
+		t.Errorf("got %d entries after cleanup, wanted 3", n)
+	}
+}
@@ -0,0 +1,162 @@
+package log
+
+import (
+	"sync"
+	"sync/atomic"
+
+	"go.uber.org/zap/zapcore"
+)
+
+// Metrics is a snapshot of the logging activity of a registry, as returned
+// by GetMetrics.
+type Metrics struct {
+	// Entries counts the entries emitted, by subsystem and level.
+	Entries map[string]map[LogLevel]uint64 `json:"entries"`
+
+	// Dropped counts the entries that were discarded before reaching the
+	// outputs, e.g. because a buffer was full.
+	Dropped uint64 `json:"dropped"`
+
+	// WriteErrors counts the failed writes and syncs to the outputs
+	// configured with SetupLogging. Outputs that don't support syncing,
+	// like terminals, aren't counted. The failures are also logged as
+	// errors of the "golog" subsystem, at most once a minute.
+	WriteErrors uint64 `json:"write_errors"`
+
+	// Evicted counts the loggers released to stay within
+	// Config.MaxLoggers.
+	Evicted uint64 `json:"evicted"`
+
+	// DiskGuardTrips counts the times writing to a log file was suspended
+	// because of Config.FileMaxTotalSize or Config.FileMinFreeSpace. The
+	// entries discarded meanwhile are counted in Dropped.
+	DiskGuardTrips uint64 `json:"disk_guard_trips"`
+}
+
+// GetMetrics returns a snapshot of the logging activity of the process.
+func GetMetrics() Metrics {
+	return defaultRegistry.GetMetrics()
+}
+
+// GetMetrics returns a snapshot of the logging activity of this registry.
+func (r *Registry) GetMetrics() Metrics {
+	return r.metrics.snapshot()
+}
+
+const (
+	minCountedLevel = traceLevel
+	maxCountedLevel = zapcore.FatalLevel
+)
+
+type levelCounts [maxCountedLevel - minCountedLevel + 1]uint64
+
+// metrics holds the counters backing Metrics.
+type metrics struct {
+	entries     sync.Map // subsystem -> *levelCounts
+	dropped     uint64
+	writeErrors uint64
+	evicted     uint64
+	suspensions uint64
+
+	// onOutputError, if set, is called with the failures counted in
+	// writeErrors.
+	onOutputError func(op string, err error)
+}
+
+func (m *metrics) countEntry(ent zapcore.Entry) {
+	if ent.Level < minCountedLevel || ent.Level > maxCountedLevel {
+		return
+	}
+	counts, ok := m.entries.Load(ent.LoggerName)
+	if !ok {
+		counts, _ = m.entries.LoadOrStore(ent.LoggerName, new(levelCounts))
+	}
+	atomic.AddUint64(&counts.(*levelCounts)[ent.Level-minCountedLevel], 1)
+}
+
+func (m *metrics) addDropped(n int) {
+	atomic.AddUint64(&m.dropped, uint64(n))
+}
+
+func (m *metrics) outputError(op string, err error) {
+	atomic.AddUint64(&m.writeErrors, 1)
+	if m.onOutputError != nil {
+		m.onOutputError(op, err)
+	}
+}
+
+func (m *metrics) addSuspension() {
+	atomic.AddUint64(&m.suspensions, 1)
+}
+
+func (m *metrics) snapshot() Metrics {
+	s := Metrics{
+		Entries:        make(map[string]map[LogLevel]uint64),
+		Dropped:        atomic.LoadUint64(&m.dropped),
+		WriteErrors:    atomic.LoadUint64(&m.writeErrors),
+		Evicted:        atomic.LoadUint64(&m.evicted),
+		DiskGuardTrips: atomic.LoadUint64(&m.suspensions),
+	}
+	m.entries.Range(func(k, v interface{}) bool {
+		byLevel := make(map[LogLevel]uint64)
+		for i := range v.(*levelCounts) {
+			if n := atomic.LoadUint64(&v.(*levelCounts)[i]); n > 0 {
+				byLevel[LogLevel(minCountedLevel+zapcore.Level(i))] = n
+			}
+		}
+		s.Entries[k.(string)] = byLevel
+		return true
+	})
+	return s
+}
+
+// countingCore counts the entries passing through it without writing them
+// anywhere.
+type countingCore struct {
+	m *metrics
+}
+
+var _ zapcore.Core = (*countingCore)(nil)
+
+func (c *countingCore) Enabled(zapcore.Level) bool {
+	return true
+}
+
+func (c *countingCore) With([]zapcore.Field) zapcore.Core {
+	return c
+}
+
+func (c *countingCore) Check(ent zapcore.Entry, ce *zapcore.CheckedEntry) *zapcore.CheckedEntry {
+	return ce.AddCore(ent, c)
+}
+
+func (c *countingCore) Write(ent zapcore.Entry, _ []zapcore.Field) error {
+	c.m.countEntry(ent)
+	return nil
+}
+
+func (c *countingCore) Sync() error {
+	return nil
+}
+
+// countingWriteSyncer counts the errors returned by a WriteSyncer.
+type countingWriteSyncer struct {
+	zapcore.WriteSyncer
+	m *metrics
+}
+
+func (w *countingWriteSyncer) Write(p []byte) (int, error) {
+	n, err := w.WriteSyncer.Write(p)
+	if err != nil {
+		w.m.outputError("write", err)
+	}
+	return n, err
+}
+
+func (w *countingWriteSyncer) Sync() error {
+	err := w.WriteSyncer.Sync()
+	if failure := syncFailure(err); failure != nil {
+		w.m.outputError("sync", failure)
+	}
+	return err
+}
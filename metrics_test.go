@@ -0,0 +1,32 @@
+package log
+
+import (
+	"testing"
+)
+
+func TestGetMetrics(t *testing.T) {
+	reg := newRegistry()
+	reg.bufferStartup(1, 0)
+
+	log := reg.Logger("test")
+	log.Error("scooby")
+	log.Error("dropped")
+
+	reg.SetupLogging(Config{Level: LevelInfo})
+	log.Debug("hidden")
+	log.Info("doo")
+
+	m := reg.GetMetrics()
+	if n := m.Entries["test"][LevelError]; n != 2 {
+		t.Errorf("got %d errors, want 2", n)
+	}
+	if n := m.Entries["test"][LevelInfo]; n != 1 {
+		t.Errorf("got %d infos, want 1", n)
+	}
+	if _, ok := m.Entries["test"][LevelDebug]; ok {
+		t.Error("disabled entries should not be counted")
+	}
+	if m.Dropped != 1 {
+		t.Errorf("got %d dropped entries, want 1", m.Dropped)
+	}
+}
@@ -0,0 +1,98 @@
+package log
+
+import (
+	"strings"
+
+	"go.uber.org/zap/buffer"
+	"go.uber.org/zap/zapcore"
+)
+
+// multilineIndent prefixes every line of a multiline field block.
+const multilineIndent = "    "
+
+// multilineEncoder wraps a console encoder and moves string fields spanning
+// several lines out of the entry line, rendering them as indented blocks
+// below it:
+//
+//	2010-05-23T15:14:00.000Z	ERROR	main	oops
+//	    stack:
+//	        goroutine 1 [running]:
+//	        main.main()
+type multilineEncoder struct {
+	zapcore.Encoder
+
+	// blocks holds the multiline fields added through With.
+	blocks []zapcore.Field
+}
+
+func isMultiline(f zapcore.Field) bool {
+	return f.Type == zapcore.StringType && strings.ContainsRune(f.String, '\n')
+}
+
+// eachLine calls f with every line of s, ignoring trailing line breaks,
+// without allocating.
+func eachLine(s string, f func(line string)) {
+	s = strings.TrimRight(s, "\n")
+	for {
+		i := strings.IndexByte(s, '\n')
+		if i < 0 {
+			f(s)
+			return
+		}
+		f(s[:i])
+		s = s[i+1:]
+	}
+}
+
+func (e *multilineEncoder) AddString(key, value string) {
+	if strings.ContainsRune(value, '\n') {
+		e.blocks = append(e.blocks, zapcore.Field{Key: key, Type: zapcore.StringType, String: value})
+		return
+	}
+	e.Encoder.AddString(key, value)
+}
+
+func (e *multilineEncoder) Clone() zapcore.Encoder {
+	return &multilineEncoder{
+		Encoder: e.Encoder.Clone(),
+		blocks:  append([]zapcore.Field(nil), e.blocks...),
+	}
+}
+
+func (e *multilineEncoder) EncodeEntry(ent zapcore.Entry, fields []zapcore.Field) (*buffer.Buffer, error) {
+	blocks := e.blocks
+	inline := fields
+	for i, f := range fields {
+		if isMultiline(f) {
+			// Only copy once we know there is something to split off.
+			inline = append([]zapcore.Field(nil), fields[:i]...)
+			blocks = append([]zapcore.Field(nil), blocks...)
+			for _, f := range fields[i:] {
+				if isMultiline(f) {
+					blocks = append(blocks, f)
+				} else {
+					inline = append(inline, f)
+				}
+			}
+			break
+		}
+	}
+
+	buf, err := e.Encoder.EncodeEntry(ent, inline)
+	if err != nil || len(blocks) == 0 {
+		return buf, err
+	}
+
+	for _, f := range blocks {
+		buf.AppendString(multilineIndent)
+		buf.AppendString(f.Key)
+		buf.AppendString(":\n")
+		eachLine(f.String, func(line string) {
+			buf.AppendString(multilineIndent)
+			buf.AppendString(multilineIndent)
+			buf.AppendString(line)
+			buf.AppendByte('\n')
+		})
+	}
+	return buf, nil
+}
@@ -0,0 +1,26 @@
+//go:build golog_nodebug
+
+package log
+
+// With the golog_nodebug build tag, debug and trace logging is compiled out:
+// the methods below are empty, so that calls to them, including the
+// evaluation of their arguments when those have no side effects, can be
+// eliminated by the compiler.
+
+// Debug does nothing, debug logging is compiled out.
+func (logger *ZapEventLogger) Debug(args ...interface{}) {}
+
+// Debugf does nothing, debug logging is compiled out.
+func (logger *ZapEventLogger) Debugf(format string, args ...interface{}) {}
+
+// Debugw does nothing, debug logging is compiled out.
+func (logger *ZapEventLogger) Debugw(msg string, keysAndValues ...interface{}) {}
+
+// Trace does nothing, trace logging is compiled out.
+func (logger *ZapEventLogger) Trace(args ...interface{}) {}
+
+// Tracef does nothing, trace logging is compiled out.
+func (logger *ZapEventLogger) Tracef(format string, args ...interface{}) {}
+
+// Tracew does nothing, trace logging is compiled out.
+func (logger *ZapEventLogger) Tracew(msg string, keysAndValues ...interface{}) {}
@@ -0,0 +1,37 @@
+//go:build golog_nodebug
+
+package log
+
+import (
+	"testing"
+
+	"go.uber.org/zap/zapcore"
+	"go.uber.org/zap/zaptest/observer"
+)
+
+// skipWithoutDebug skips tests relying on debug logging, which is compiled
+// out.
+func skipWithoutDebug(t testing.TB) {
+	t.Helper()
+	t.Skip("debug logging is compiled out")
+}
+
+func TestNoDebug(t *testing.T) {
+	reg := newRegistry()
+	core, logs := observer.New(zapcore.Level(LevelTrace))
+	reg.SetPrimaryCore(core)
+	log := reg.Logger("test")
+	reg.SetAllLoggers(LevelTrace)
+
+	log.Debug("scooby")
+	log.Debugf("scooby %d", 1)
+	log.Debugw("scooby", "k", "v")
+	log.Trace("doo")
+	log.Tracef("doo %d", 1)
+	log.Tracew("doo", "k", "v")
+	log.Info("velma")
+
+	if n := logs.Len(); n != 1 {
+		t.Errorf("got %d entries, want only the info one", n)
+	}
+}
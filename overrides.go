@@ -0,0 +1,115 @@
+package log
+
+import (
+	"time"
+
+	"go.uber.org/zap/zapcore"
+)
+
+// levelOverride is a temporary level of a subsystem, reverted to prior when
+// its timer fires.
+type levelOverride struct {
+	prior zapcore.Level
+	level zapcore.Level
+	timer *time.Timer
+	gen   uint64 // incremented whenever the override is extended
+}
+
+// SetLogLevelFor changes the log level of a specific subsystem for d, after
+// which the level it had before is restored, unless the level was changed
+// meanwhile. name=="*" changes all subsystems.
+//
+// Overlapping calls replace the temporary level and restart the countdown;
+// the level from before the first call is the one restored.
+func SetLogLevelFor(name, level string, d time.Duration) error {
+	return defaultRegistry.SetLogLevelFor(name, level, d)
+}
+
+// SetLogLevelFor temporarily changes the log level of a subsystem of this
+// registry. See the package-level SetLogLevelFor.
+func (r *Registry) SetLogLevelFor(name, level string, d time.Duration) error {
+	lvl, err := LevelFromString(level)
+	if err != nil {
+		return err
+	}
+
+	if name == "*" {
+		for _, name := range r.GetSubsystems() {
+			if err := r.setLevelFor(name, lvl, d); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+	return r.setLevelFor(name, lvl, d)
+}
+
+// setLevelFor sets the level of the named subsystem to lvl for d. If the
+// subsystem already has a temporary level, it is replaced and extended,
+// and the level from before the first override is eventually restored.
+func (r *Registry) setLevelFor(name string, lvl LogLevel, d time.Duration) error {
+	defer r.updateLevelActivations()
+
+	r.mu.RLock()
+	leveler, ok := r.levels[name]
+	r.mu.RUnlock()
+	if !ok {
+		return ErrNoSuchLogger
+	}
+
+	r.overridesMu.Lock()
+	defer r.overridesMu.Unlock()
+
+	o, ok := r.overrides[name]
+	if ok {
+		o.timer.Stop()
+	} else {
+		o = &levelOverride{prior: leveler.Level()}
+		r.overrides[name] = o
+	}
+	o.gen++
+	o.level = zapcore.Level(lvl)
+	leveler.SetLevel(o.level)
+
+	gen := o.gen
+	o.timer = time.AfterFunc(d, func() {
+		r.expireOverride(name, o, gen)
+	})
+	return nil
+}
+
+// expireOverride restores the level from before the override, unless the
+// level was changed meanwhile.
+func (r *Registry) expireOverride(name string, o *levelOverride, gen uint64) {
+	defer r.updateLevelActivations()
+
+	r.overridesMu.Lock()
+	defer r.overridesMu.Unlock()
+
+	if r.overrides[name] != o || o.gen != gen {
+		return // extended
+	}
+	delete(r.overrides, name)
+
+	r.mu.RLock()
+	leveler, ok := r.levels[name]
+	r.mu.RUnlock()
+	if ok && leveler.Level() == o.level {
+		leveler.SetLevel(o.prior)
+	}
+}
+
+// raiseLevelsFor makes the named subsystems at least as verbose as lvl for
+// d. Subsystems that already are, and aren't under a temporary level, are
+// left alone.
+func (r *Registry) raiseLevelsFor(names []string, lvl LogLevel, d time.Duration) {
+	for _, name := range names {
+		r.overridesMu.Lock()
+		_, overridden := r.overrides[name]
+		r.overridesMu.Unlock()
+
+		if current, ok := r.SubsystemLevel(name); ok && (overridden || current > lvl) {
+			_ = r.setLevelFor(name, lvl, d)
+		}
+	}
+}
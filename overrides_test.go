@@ -0,0 +1,56 @@
+package log
+
+import (
+	"testing"
+	"time"
+)
+
+func TestSetLogLevelFor(t *testing.T) {
+	reg := NewRegistry(Config{Level: LevelError})
+	reg.Logger("dht")
+
+	if err := reg.SetLogLevelFor("dht", "debug", 100*time.Millisecond); err != nil {
+		t.Fatal(err)
+	}
+	if lvl, _ := reg.SubsystemLevel("dht"); lvl != LevelDebug {
+		t.Fatalf("got level %s, want debug", lvl)
+	}
+
+	// An overlapping call replaces the level and restarts the countdown.
+	time.Sleep(50 * time.Millisecond)
+	if err := reg.SetLogLevelFor("dht", "info", 100*time.Millisecond); err != nil {
+		t.Fatal(err)
+	}
+	time.Sleep(75 * time.Millisecond)
+	if lvl, _ := reg.SubsystemLevel("dht"); lvl != LevelInfo {
+		t.Errorf("got level %s, want info until the second call expires", lvl)
+	}
+
+	time.Sleep(150 * time.Millisecond)
+	if lvl, _ := reg.SubsystemLevel("dht"); lvl != LevelError {
+		t.Errorf("got level %s, want the original level restored", lvl)
+	}
+
+	if err := reg.SetLogLevelFor("nope", "debug", time.Second); err != ErrNoSuchLogger {
+		t.Errorf("got %v, want ErrNoSuchLogger", err)
+	}
+	if err := reg.SetLogLevelFor("dht", "loud", time.Second); err == nil {
+		t.Error("expected an error for an invalid level")
+	}
+}
+
+func TestSetLogLevelForKeepsChangedLevel(t *testing.T) {
+	reg := NewRegistry(Config{Level: LevelError})
+	reg.Logger("dht")
+
+	if err := reg.SetLogLevelFor("dht", "debug", 50*time.Millisecond); err != nil {
+		t.Fatal(err)
+	}
+	if err := reg.SetLogLevel("dht", "info"); err != nil {
+		t.Fatal(err)
+	}
+	time.Sleep(150 * time.Millisecond)
+	if lvl, _ := reg.SubsystemLevel("dht"); lvl != LevelInfo {
+		t.Errorf("got level %s, want the level set meanwhile to stick", lvl)
+	}
+}
@@ -0,0 +1,78 @@
+package log
+
+import (
+	"context"
+	"runtime/debug"
+
+	"go.uber.org/zap"
+)
+
+type recoverOptions struct {
+	repanic bool
+}
+
+// RecoverOption configures Recover.
+type RecoverOption interface {
+	setOption(*recoverOptions)
+}
+
+type recoverOptionFunc func(*recoverOptions)
+
+func (f recoverOptionFunc) setOption(o *recoverOptions) {
+	f(o)
+}
+
+// RecoverRepanic makes Recover panic again with the recovered value after
+// logging it, instead of swallowing the panic.
+func RecoverRepanic() RecoverOption {
+	return recoverOptionFunc(func(o *recoverOptions) {
+		o.repanic = true
+	})
+}
+
+// Recover recovers a panic of the calling goroutine and logs it at error
+// level along with its stack and the fields attached to ctx with
+// ContextWithFields. It must be deferred directly:
+//
+//	defer logger.Recover(ctx)
+//
+// By default the panic is swallowed; pass RecoverRepanic to propagate it.
+func (logger *ZapEventLogger) Recover(ctx context.Context, opts ...RecoverOption) {
+	r := recover()
+	if r == nil {
+		return
+	}
+
+	var o recoverOptions
+	for _, opt := range opts {
+		opt.setOption(&o)
+	}
+
+	// The caller would point into the runtime, the stack says it all.
+	l := logger.Desugar().WithOptions(zap.WithCaller(false)).Sugar()
+	l.Errorw("recovered from panic", panicFields(r, contextFields(ctx))...)
+
+	if o.repanic {
+		panic(r)
+	}
+}
+
+// LogPanic logs a value returned by recover at error level along with the
+// current stack and the given key-value pairs. It does nothing if recovered
+// is nil, so it can be used as:
+//
+//	defer func() {
+//		logger.LogPanic(recover(), "peer", p)
+//	}()
+func (logger *ZapEventLogger) LogPanic(recovered interface{}, kv ...interface{}) {
+	if recovered == nil {
+		return
+	}
+	logger.skipLogger.Errorw("recovered from panic", panicFields(recovered, kv)...)
+}
+
+func panicFields(recovered interface{}, kv []interface{}) []interface{} {
+	fields := make([]interface{}, 0, len(kv)+4)
+	fields = append(fields, "panic", recovered, "stacktrace", string(debug.Stack()))
+	return append(fields, kv...)
+}
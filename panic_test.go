@@ -0,0 +1,71 @@
+package log
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"go.uber.org/zap/zapcore"
+	"go.uber.org/zap/zaptest/observer"
+)
+
+func TestRecover(t *testing.T) {
+	reg := NewRegistry(Config{Level: LevelError})
+	core, logs := observer.New(zapcore.DebugLevel)
+	reg.SetPrimaryCore(core)
+	logger := reg.Logger("test-panic")
+
+	ctx := ContextWithFields(context.Background(), "peer", "scooby")
+	func() {
+		defer logger.Recover(ctx)
+		panic("doo")
+	}()
+
+	func() {
+		defer func() {
+			if r := recover(); r != "velma" {
+				t.Errorf("got %v, wanted the panic to be propagated", r)
+			}
+		}()
+		defer logger.Recover(ctx, RecoverRepanic())
+		panic("velma")
+	}()
+
+	entries := logs.All()
+	if len(entries) != 2 {
+		t.Fatalf("got %d entries, want 2", len(entries))
+	}
+	fields := entries[0].ContextMap()
+	if fields["panic"] != "doo" || fields["peer"] != "scooby" {
+		t.Errorf("got fields %v", fields)
+	}
+	if !strings.Contains(fields["stacktrace"].(string), "TestRecover") {
+		t.Errorf("got stack %q, wanted it to contain the panicking function", fields["stacktrace"])
+	}
+}
+
+func TestLogPanic(t *testing.T) {
+	reg := NewRegistry(Config{Level: LevelError})
+	core, logs := observer.New(zapcore.DebugLevel)
+	reg.SetPrimaryCore(core)
+	logger := reg.Logger("test-panic")
+
+	func() {
+		defer func() {
+			logger.LogPanic(recover(), "snacks", 2)
+		}()
+		panic("scooby")
+	}()
+	logger.LogPanic(nil)
+
+	entries := logs.All()
+	if len(entries) != 1 {
+		t.Fatalf("got %d entries, want 1", len(entries))
+	}
+	if fields := entries[0].ContextMap(); fields["panic"] != "scooby" || fields["snacks"] != int64(2) {
+		t.Errorf("got fields %v", fields)
+	}
+	if !strings.HasSuffix(entries[0].Caller.File, "panic_test.go") {
+		t.Errorf("got caller %v, want the test", entries[0].Caller)
+	}
+}
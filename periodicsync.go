@@ -0,0 +1,134 @@
+package log
+
+import (
+	"math/rand"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"go.uber.org/zap/zapcore"
+)
+
+// syncOnErrorDelay is how long Config.SyncOnError waits after an error
+// before syncing, so that a burst of errors leads to a single sync.
+const syncOnErrorDelay = 100 * time.Millisecond
+
+// outputSyncer syncs the outputs opened by SetupLogging in the background,
+// as configured with Config.SyncInterval and Config.SyncOnError.
+type outputSyncer struct {
+	current atomic.Pointer[periodicSync]
+
+	after func(time.Duration) <-chan time.Time // time.After, unless testing
+}
+
+// open starts syncing ws if cfg asks for it. The returned function stops
+// doing so before calling closeOutputs.
+func (s *outputSyncer) open(ws zapcore.WriteSyncer, closeOutputs func(), cfg Config) func() {
+	if cfg.SyncInterval <= 0 && !cfg.SyncOnError {
+		return closeOutputs
+	}
+	p := newPeriodicSync(ws, cfg.SyncInterval, cfg.SyncOnError, s.after)
+	s.current.Store(p)
+	return func() {
+		s.current.CompareAndSwap(p, nil)
+		p.stop()
+		closeOutputs()
+	}
+}
+
+// onError is a Hook scheduling a sync of the current outputs.
+func (s *outputSyncer) onError(zapcore.Entry, []zapcore.Field) error {
+	if p := s.current.Load(); p != nil {
+		p.kick()
+	}
+	return nil
+}
+
+// periodicSync syncs a WriteSyncer every interval, give or take 10% so that
+// processes started together don't hit the disk together, and shortly after
+// being kicked, if onError is set.
+type periodicSync struct {
+	ws       zapcore.WriteSyncer
+	interval time.Duration
+	onError  bool
+	after    func(time.Duration) <-chan time.Time
+
+	kicks chan struct{}
+	done  chan struct{}
+	wg    sync.WaitGroup
+	once  sync.Once
+}
+
+// newPeriodicSync starts syncing ws. after, time.After if nil, is used for
+// all the waits.
+func newPeriodicSync(ws zapcore.WriteSyncer, interval time.Duration, onError bool, after func(time.Duration) <-chan time.Time) *periodicSync {
+	if after == nil {
+		after = time.After
+	}
+	p := &periodicSync{
+		ws:       ws,
+		interval: interval,
+		onError:  onError,
+		after:    after,
+		kicks:    make(chan struct{}, 1),
+		done:     make(chan struct{}),
+	}
+	p.wg.Add(1)
+	go p.run()
+	return p
+}
+
+func (p *periodicSync) run() {
+	defer p.wg.Done()
+
+	var periodic <-chan time.Time
+	if p.interval > 0 {
+		periodic = p.after(p.jittered())
+	}
+
+	// debounced fires syncOnErrorDelay after the first of a burst of kicks.
+	var debounced <-chan time.Time
+	for {
+		select {
+		case <-periodic:
+			// Errors are counted by the countingWriteSyncer below.
+			_ = p.ws.Sync()
+			periodic = p.after(p.jittered())
+		case <-p.kicks:
+			if debounced == nil {
+				debounced = p.after(syncOnErrorDelay)
+			}
+		case <-debounced:
+			debounced = nil
+			_ = p.ws.Sync()
+		case <-p.done:
+			return
+		}
+	}
+}
+
+func (p *periodicSync) jittered() time.Duration {
+	spread := int64(p.interval / 5)
+	if spread <= 0 {
+		return p.interval
+	}
+	return p.interval - time.Duration(spread/2) + time.Duration(rand.Int63n(spread))
+}
+
+// kick schedules a sync, unless one already is.
+func (p *periodicSync) kick() {
+	if !p.onError {
+		return
+	}
+	select {
+	case p.kicks <- struct{}{}:
+	default:
+	}
+}
+
+func (p *periodicSync) stop() {
+	p.once.Do(func() {
+		close(p.done)
+		p.wg.Wait()
+	})
+}
@@ -0,0 +1,111 @@
+package log
+
+import (
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"go.uber.org/zap/zapcore"
+)
+
+// syncCounter counts the calls to Sync.
+type syncCounter struct {
+	zapcore.WriteSyncer
+	syncs  int32
+	synced chan struct{}
+}
+
+func newSyncCounter() *syncCounter {
+	return &syncCounter{synced: make(chan struct{}, 100)}
+}
+
+func (w *syncCounter) Sync() error {
+	atomic.AddInt32(&w.syncs, 1)
+	w.synced <- struct{}{}
+	return nil
+}
+
+func (w *syncCounter) count() int32 {
+	return atomic.LoadInt32(&w.syncs)
+}
+
+// fakeTimers replaces time.After, handing the timers out to the test to fire.
+type fakeTimers struct {
+	timers chan fakeTimer
+}
+
+type fakeTimer struct {
+	d time.Duration
+	c chan time.Time
+}
+
+func newFakeTimers() *fakeTimers {
+	return &fakeTimers{timers: make(chan fakeTimer, 100)}
+}
+
+func (f *fakeTimers) after(d time.Duration) <-chan time.Time {
+	c := make(chan time.Time, 1)
+	f.timers <- fakeTimer{d: d, c: c}
+	return c
+}
+
+// next returns the next timer started, failing the test if none is.
+func (f *fakeTimers) next(t *testing.T) fakeTimer {
+	t.Helper()
+	select {
+	case timer := <-f.timers:
+		return timer
+	case <-time.After(5 * time.Second):
+		t.Fatal("no timer started")
+		return fakeTimer{}
+	}
+}
+
+func TestPeriodicSync(t *testing.T) {
+	ws := newSyncCounter()
+	timers := newFakeTimers()
+	interval := 10 * time.Second
+	p := newPeriodicSync(ws, interval, false, timers.after)
+
+	for i := 0; i < 3; i++ {
+		timer := timers.next(t)
+		if timer.d < interval*9/10 || timer.d > interval*11/10 {
+			t.Errorf("got a sync in %s, want one about every %s", timer.d, interval)
+		}
+		timer.c <- time.Time{}
+		<-ws.synced
+	}
+	p.stop()
+
+	if n := ws.count(); n != 3 {
+		t.Errorf("got %d syncs, want 3", n)
+	}
+}
+
+func TestSyncOnError(t *testing.T) {
+	reg := newRegistry()
+	ws := newSyncCounter()
+	timers := newFakeTimers()
+	reg.syncer.after = timers.after
+	closeOutputs := reg.syncer.open(ws, func() {}, Config{SyncOnError: true})
+	defer closeOutputs()
+	log := reg.Logger("test")
+
+	log.Warn("scooby")
+	for i := 0; i < 10; i++ {
+		log.Error("doo")
+	}
+
+	// The first error starts the timer, the others only wait for it.
+	timer := timers.next(t)
+	if timer.d != syncOnErrorDelay {
+		t.Errorf("got a sync in %s after errors, want one in %s", timer.d, syncOnErrorDelay)
+	}
+	timer.c <- time.Time{}
+	<-ws.synced
+	closeOutputs()
+
+	if n := ws.count(); n != 1 {
+		t.Errorf("got %d syncs after a burst of errors, want 1", n)
+	}
+}
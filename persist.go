@@ -0,0 +1,138 @@
+package log
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strconv"
+	"sync"
+)
+
+// persistedLevels is the schema of the file written by PersistLevels. It is
+// also a valid configuration file (see LoadConfigFile).
+type persistedLevels struct {
+	Subsystems map[string]LogLevel `json:"subsystems"`
+}
+
+// PersistLevels restores the subsystem levels saved in the JSON file at path,
+// if it exists, and saves them there whenever they change until stop is
+// called, so that the levels chosen by an operator at runtime survive a
+// restart. Only the levels set at runtime with SetLogLevel and
+// SetLogLevelRegex since the last SetupLogging are saved, along with the
+// restored ones, not those of the configuration or the environment.
+// Temporary levels (see SetLogLevelFor) are not saved either.
+//
+// Setting GOLOG_PERSIST_LEVELS to false turns PersistLevels into a no-op.
+// Failures to save are logged.
+func PersistLevels(path string) (stop func(), err error) {
+	return defaultRegistry.PersistLevels(path)
+}
+
+// PersistLevels persists the levels of this registry. See the package-level
+// PersistLevels.
+func (r *Registry) PersistLevels(path string) (stop func(), err error) {
+	if v := getenv(envPersistLevels); v != "" {
+		if on, err := strconv.ParseBool(v); err != nil {
+			return nil, fmt.Errorf("invalid %s value %q", envName(envPersistLevels), v)
+		} else if !on {
+			return func() {}, nil
+		}
+	}
+
+	data, err := os.ReadFile(path)
+	switch {
+	case errors.Is(err, os.ErrNotExist):
+	case err != nil:
+		return nil, err
+	default:
+		var saved persistedLevels
+		if err := json.Unmarshal(data, &saved); err != nil {
+			return nil, fmt.Errorf("failed to parse %s: %w", path, err)
+		}
+		r.restoreLevels(saved.Subsystems)
+	}
+
+	p := &levelPersister{r: r, path: path, last: data}
+	return r.OnLevelChange(func(string, LogLevel, LogLevel) {
+		if err := p.save(); err != nil {
+			r.getLogger("setup-logger").Errorw("failed to save log levels", "error", err)
+		}
+	}), nil
+}
+
+func (r *Registry) restoreLevels(levels map[string]LogLevel) {
+	defer r.updateLevelActivations()
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.setSubsystemLevels(levels)
+	for name := range levels {
+		r.setLevels[name] = struct{}{}
+	}
+}
+
+// persistentLevels returns the levels to save: those set at runtime, with
+// the levels from before temporary overrides.
+func (r *Registry) persistentLevels() map[string]LogLevel {
+	r.mu.RLock()
+	persistent := make(map[string]LogLevel, len(r.setLevels))
+	for name := range r.setLevels {
+		persistent[name] = LogLevel(r.levels[name].Level())
+	}
+	r.mu.RUnlock()
+
+	r.overridesMu.Lock()
+	for name, o := range r.overrides {
+		if _, ok := persistent[name]; ok {
+			persistent[name] = LogLevel(o.prior)
+		}
+	}
+	r.overridesMu.Unlock()
+
+	return persistent
+}
+
+type levelPersister struct {
+	r    *Registry
+	path string
+
+	mu   sync.Mutex // serializes saves and guards last
+	last []byte     // the file as last read or written
+}
+
+// save writes the levels to the file, atomically, if they changed.
+func (p *levelPersister) save() error {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	data, err := json.MarshalIndent(persistedLevels{Subsystems: p.r.persistentLevels()}, "", "  ")
+	if err != nil {
+		return err
+	}
+	data = append(data, '\n')
+	if bytes.Equal(data, p.last) {
+		return nil
+	}
+
+	tmp, err := os.CreateTemp(filepath.Dir(p.path), filepath.Base(p.path)+".tmp")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name()) // nolint:errcheck
+	if _, err := tmp.Write(data); err != nil {
+		tmp.Close() // nolint:errcheck
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+	if err := os.Rename(tmp.Name(), p.path); err != nil {
+		return err
+	}
+	p.last = data
+	return nil
+}
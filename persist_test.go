@@ -0,0 +1,93 @@
+package log
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestPersistLevels(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "levels.json")
+
+	reg := NewRegistry(Config{Level: LevelError})
+	reg.Logger("dht")
+	reg.Logger("bitswap")
+	stop, err := reg.PersistLevels(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := reg.SetLogLevel("dht", "debug"); err != nil {
+		t.Fatal(err)
+	}
+	if err := reg.SetLogLevelFor("bitswap", "debug", time.Hour); err != nil {
+		t.Fatal(err)
+	}
+	stop()
+
+	b, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := "{\n  \"subsystems\": {\n    \"dht\": \"debug\"\n  }\n}\n"; string(b) != want {
+		t.Errorf("got file %q, want %q", b, want)
+	}
+
+	// A restarted process gets the levels back, even before creating its
+	// loggers.
+	reg = NewRegistry(Config{Level: LevelError})
+	stop, err = reg.PersistLevels(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer stop()
+	if lvl, _ := reg.SubsystemLevel("dht"); lvl != LevelDebug {
+		t.Errorf("got level %s, want the saved level", lvl)
+	}
+}
+
+func TestPersistLevelsSkipsConfigured(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "levels.json")
+
+	reg := NewRegistry(Config{
+		Level:           LevelError,
+		SubsystemLevels: map[string]LogLevel{"bitswap": LevelInfo},
+	})
+	reg.Logger("dht")
+	reg.Logger("bitswap")
+	stop, err := reg.PersistLevels(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer stop()
+	if err := reg.SetLogLevelRegex("^d", "warn"); err != nil {
+		t.Fatal(err)
+	}
+
+	b, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := "{\n  \"subsystems\": {\n    \"dht\": \"warn\"\n  }\n}\n"; string(b) != want {
+		t.Errorf("got file %q, want %q", b, want)
+	}
+}
+
+func TestPersistLevelsOptOut(t *testing.T) {
+	t.Setenv(envPersistLevels, "false")
+	path := filepath.Join(t.TempDir(), "levels.json")
+
+	reg := NewRegistry(Config{Level: LevelError})
+	reg.Logger("dht")
+	stop, err := reg.PersistLevels(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer stop()
+	if err := reg.SetLogLevel("dht", "debug"); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := os.Stat(path); !os.IsNotExist(err) {
+		t.Errorf("expected no file to be written, got %v", err)
+	}
+}
@@ -1,6 +1,7 @@
 package log
 
 import (
+	"encoding/json"
 	"io"
 
 	"go.uber.org/multierr"
@@ -13,18 +14,45 @@ type PipeReader struct {
 	r      *io.PipeReader
 	closer io.Closer
 	core   zapcore.Core
+	reg    *Registry
+
+	// header is the unread remainder of the stream header, if any
+	header []byte
+}
+
+// PipeStreamVersion is the version of the stream schema announced by the
+// header written with the PipeHeader option. It is incremented whenever the
+// encoding of entries changes in a way consumers may need to adapt to.
+const PipeStreamVersion = 1
+
+// PipeStreamHeader is the first record of a stream written with the
+// PipeHeader option. It is encoded as a single line of JSON regardless of
+// the format of the entries that follow it:
+//
+//	{"golog":{"version":1,"format":"json"}}
+type PipeStreamHeader struct {
+	Golog struct {
+		Version int    `json:"version"`
+		Format  string `json:"format"`
+	} `json:"golog"`
 }
 
 // Read implements the standard Read interface
 func (p *PipeReader) Read(data []byte) (int, error) {
+	if len(p.header) > 0 {
+		n := copy(data, p.header)
+		p.header = p.header[n:]
+		return n, nil
+	}
 	return p.r.Read(data)
 }
 
 // Close unregisters the reader from the logger.
 func (p *PipeReader) Close() error {
 	if p.core != nil {
-		loggerCore.DeleteCore(p.core)
+		p.reg.loggerCore.DeleteCore(p.core)
 	}
+	p.reg.untrackPipe(p)
 	return multierr.Append(p.core.Sync(), p.closer.Close())
 }
 
@@ -38,24 +66,56 @@ func (p *PipeReader) Close() error {
 //     output. That is, everything enabled by SetLogLevel. The minimum log level
 //     can be increased by passing the PipeLevel option.
 func NewPipeReader(opts ...PipeReaderOption) *PipeReader {
+	return defaultRegistry.NewPipeReader(opts...)
+}
+
+// NewPipeReader creates a new in-memory reader that reads from all loggers of
+// this registry. See the package-level NewPipeReader.
+func (r *Registry) NewPipeReader(opts ...PipeReaderOption) *PipeReader {
 	opt := pipeReaderOptions{
 		format: JSONOutput,
-		level:  LevelDebug,
+		level:  LevelTrace,
 	}
 
 	for _, o := range opts {
 		o.setOption(&opt)
 	}
 
-	r, w := io.Pipe()
+	pr, pw := io.Pipe()
+	var ws zapcore.WriteSyncer = zapcore.AddSync(pw)
+	var closer io.Closer = pw
+	if opt.queue > 0 {
+		q := &pipeQueue{
+			asyncWriteSyncer: newAsyncWriteSyncer(ws, opt.queue, AsyncDrop, r.metrics),
+			r:                pr,
+			w:                pw,
+		}
+		ws, closer = q, q
+	}
 
 	p := &PipeReader{
-		r:      r,
-		closer: w,
-		core:   newCore(opt.format, zapcore.AddSync(w), opt.level),
+		r:      pr,
+		closer: closer,
+		core:   newCore(opt.format, ws, opt.level),
+		reg:    r,
+	}
+	if opt.seq {
+		p.core = &seqCore{core: p.core, state: newSeqState()}
+	}
+
+	if opt.header {
+		var h PipeStreamHeader
+		h.Golog.Version = PipeStreamVersion
+		h.Golog.Format = opt.format.String()
+		b, err := json.Marshal(h)
+		if err != nil {
+			panic(err) // can't happen
+		}
+		p.header = append(b, '\n')
 	}
 
-	loggerCore.AddCore(p.core)
+	r.trackPipe(p)
+	r.loggerCore.AddCore(p.core)
 
 	return p
 }
@@ -63,6 +123,9 @@ func NewPipeReader(opts ...PipeReaderOption) *PipeReader {
 type pipeReaderOptions struct {
 	format LogFormat
 	level  LogLevel
+	header bool
+	seq    bool
+	queue  int
 }
 
 type PipeReaderOption interface {
@@ -88,3 +151,53 @@ func PipeLevel(level LogLevel) PipeReaderOption {
 		o.level = level
 	})
 }
+
+// PipeHeader makes the pipe reader start the stream with a PipeStreamHeader
+// record describing the schema version and format of the entries, so that
+// long-lived consumers can detect changes to the encoding instead of
+// breaking silently.
+func PipeHeader() PipeReaderOption {
+	return pipeReaderOptionFunc(func(o *pipeReaderOptions) {
+		o.header = true
+	})
+}
+
+// PipeSequenceNumbers numbers the entries sent to the pipe reader, under
+// SeqKey, starting from 1, so that the consumer can detect lost entries.
+func PipeSequenceNumbers() PipeReaderOption {
+	return pipeReaderOptionFunc(func(o *pipeReaderOptions) {
+		o.seq = true
+	})
+}
+
+// PipeQueue makes the pipe reader queue up to size entries for the consumer,
+// dropping those logged while the queue is full instead of blocking logging,
+// e.g. for remote consumers. Dropped entries are counted in
+// Metrics.Dropped. Entries still queued when the reader is closed are
+// discarded.
+func PipeQueue(size int) PipeReaderOption {
+	return pipeReaderOptionFunc(func(o *pipeReaderOptions) {
+		o.queue = size
+	})
+}
+
+// pipeQueue is the queue of a PipeReader created with PipeQueue.
+type pipeQueue struct {
+	*asyncWriteSyncer
+	r *io.PipeReader
+	w *io.PipeWriter
+}
+
+// Sync doesn't wait for the consumer to catch up, which may never happen.
+func (q *pipeQueue) Sync() error {
+	return nil
+}
+
+// Close discards the queued entries and closes the pipe.
+func (q *pipeQueue) Close() error {
+	// Closing the reading end first fails the writes of the queued
+	// entries, which nobody reads anymore, instead of blocking on them.
+	_ = q.r.Close()
+	q.close()
+	return q.w.Close()
+}
@@ -2,10 +2,12 @@ package log
 
 import (
 	"bytes"
+	"encoding/json"
 	"io"
 	"strings"
 	"sync"
 	"testing"
+	"time"
 
 	"go.uber.org/zap"
 )
@@ -101,3 +103,63 @@ func TestNewPipeReaderLevel(t *testing.T) {
 	}
 
 }
+
+func TestNewPipeReaderHeader(t *testing.T) {
+	log := getLogger("test")
+
+	var wg sync.WaitGroup
+	wg.Add(1)
+
+	r := NewPipeReader(PipeFormat(PlaintextOutput), PipeHeader())
+
+	buf := &bytes.Buffer{}
+	go func() {
+		defer wg.Done()
+		if _, err := io.Copy(buf, r); err != nil && err != io.ErrClosedPipe {
+			t.Errorf("unexpected error: %v", err)
+		}
+	}()
+
+	log.Error("scooby")
+	r.Close()
+	wg.Wait()
+
+	header, rest, _ := strings.Cut(buf.String(), "\n")
+	var h PipeStreamHeader
+	if err := json.Unmarshal([]byte(header), &h); err != nil {
+		t.Fatalf("failed to decode header %q: %v", header, err)
+	}
+	if h.Golog.Version != PipeStreamVersion || h.Golog.Format != "nocolor" {
+		t.Errorf("got header %q", header)
+	}
+	if !strings.Contains(rest, "scooby") {
+		t.Errorf("got %q, wanted it to contain log output", rest)
+	}
+}
+
+func TestNewPipeReaderQueue(t *testing.T) {
+	reg := NewRegistry(Config{Level: LevelInfo})
+	p := reg.NewPipeReader(PipeQueue(2))
+	log := reg.Logger("test")
+
+	// Nobody reads: logging must not block.
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		for i := 0; i < 10; i++ {
+			log.Info("scooby")
+		}
+		_ = reg.Flush()
+	}()
+	select {
+	case <-done:
+	case <-time.After(5 * time.Second):
+		t.Fatal("logging blocked on a pipe that isn't read")
+	}
+	if dropped := reg.GetMetrics().Dropped; dropped == 0 {
+		t.Error("expected dropped entries")
+	}
+	if err := p.Close(); err != nil {
+		t.Fatal(err)
+	}
+}
@@ -0,0 +1,9 @@
+package log
+
+import (
+	"go.uber.org/zap/buffer"
+)
+
+// bufferPool recycles the buffers our encoders and writers need on top of
+// those handed out by zap, to keep logging calls from allocating.
+var bufferPool = buffer.NewPool()
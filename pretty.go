@@ -0,0 +1,175 @@
+package log
+
+import (
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+
+	"go.uber.org/zap/buffer"
+	"go.uber.org/zap/zapcore"
+)
+
+const (
+	prettyKeyColor    = "\x1b[36m" // cyan
+	prettyStringColor = "\x1b[32m" // green
+	prettyNumberColor = "\x1b[33m" // yellow
+	prettyOtherColor  = "\x1b[35m" // magenta
+	prettyResetColor  = "\x1b[0m"
+)
+
+// prettyEncoder renders the entry on a single colorized console line and its
+// fields indented below it, one per line, with syntax colors:
+//
+//	2010-05-23T15:14:00.000Z	ERROR	main	oops
+//	    peer: "12D3KooW..."
+//	    attempts: 3
+//
+// It is meant for reading field-heavy entries during local development.
+// The colors are left out if NO_COLOR or CLICOLOR=0 ask for none.
+type prettyEncoder struct {
+	// MapObjectEncoder holds the fields added through With.
+	*zapcore.MapObjectEncoder
+
+	// line renders the entry line, without fields nor stack.
+	line zapcore.Encoder
+
+	colors bool
+}
+
+func newPrettyEncoder(encCfg zapcore.EncoderConfig) *prettyEncoder {
+	colors := !colorsDisabled()
+	if colors {
+		encCfg.EncodeLevel = capitalColorLevelEncoder
+	} else {
+		encCfg.EncodeLevel = capitalLevelEncoder
+	}
+	encCfg.StacktraceKey = zapcore.OmitKey
+	return &prettyEncoder{
+		MapObjectEncoder: zapcore.NewMapObjectEncoder(),
+		line:             zapcore.NewConsoleEncoder(encCfg),
+		colors:           colors,
+	}
+}
+
+func (e *prettyEncoder) Clone() zapcore.Encoder {
+	clone := zapcore.NewMapObjectEncoder()
+	for k, v := range e.Fields {
+		clone.Fields[k] = v
+	}
+	return &prettyEncoder{MapObjectEncoder: clone, line: e.line, colors: e.colors}
+}
+
+func (e *prettyEncoder) EncodeEntry(ent zapcore.Entry, fields []zapcore.Field) (*buffer.Buffer, error) {
+	buf, err := e.line.EncodeEntry(ent, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	sc := prettyScratchPool.Get().(*prettyScratch)
+	defer prettyScratchPool.Put(sc)
+
+	// Fields bound with With come first, sorted as their order is lost.
+	for _, k := range sc.sortedKeys(e.Fields) {
+		appendPrettyField(buf, k, e.Fields[k], e.colors)
+	}
+
+	for _, f := range fields {
+		m := sc.m
+		if f.Type == zapcore.NamespaceType {
+			// Namespaces can't be undone, don't reuse the encoder.
+			m = zapcore.NewMapObjectEncoder()
+		} else {
+			for k := range m.Fields {
+				delete(m.Fields, k)
+			}
+		}
+		f.AddTo(m)
+		// Some fields, like errors, add several keys.
+		for _, k := range sc.sortedKeys(m.Fields) {
+			appendPrettyField(buf, k, m.Fields[k], e.colors)
+		}
+	}
+
+	if ent.Stack != "" {
+		buf.AppendString(strings.TrimRight(ent.Stack, "\n"))
+		buf.AppendByte('\n')
+	}
+	return buf, nil
+}
+
+// prettyScratch is the working memory of prettyEncoder.EncodeEntry, pooled
+// to save allocations.
+type prettyScratch struct {
+	m    *zapcore.MapObjectEncoder
+	keys []string
+}
+
+var prettyScratchPool = sync.Pool{
+	New: func() interface{} {
+		return &prettyScratch{m: zapcore.NewMapObjectEncoder()}
+	},
+}
+
+// sortedKeys returns the sorted keys of fields. The slice is only valid
+// until the next call.
+func (sc *prettyScratch) sortedKeys(fields map[string]interface{}) []string {
+	sc.keys = sc.keys[:0]
+	for k := range fields {
+		sc.keys = append(sc.keys, k)
+	}
+	sort.Strings(sc.keys)
+	return sc.keys
+}
+
+// appendPrettyField appends a field line, with syntax colors if colors is
+// set.
+func appendPrettyField(buf *buffer.Buffer, key string, value interface{}, colors bool) {
+	colored := func(color, s string) {
+		if colors {
+			buf.AppendString(color)
+		}
+		buf.AppendString(s)
+		if colors {
+			buf.AppendString(prettyResetColor)
+		}
+	}
+
+	buf.AppendString(multilineIndent)
+	colored(prettyKeyColor, key)
+	buf.AppendString(": ")
+
+	var color, s string
+	switch v := value.(type) {
+	case string:
+		color, s = prettyStringColor, v
+		if !strings.ContainsRune(v, '\n') {
+			s = strconv.Quote(v)
+		}
+	case bool, int, int8, int16, int32, int64, uint, uint8, uint16, uint32, uint64, uintptr, float32, float64, complex64, complex128:
+		color, s = prettyNumberColor, fmt.Sprint(v)
+	case map[string]interface{}, []interface{}:
+		color = prettyOtherColor
+		if b, err := json.MarshalIndent(v, "", "  "); err == nil {
+			s = string(b)
+		} else {
+			s = fmt.Sprint(v)
+		}
+	default:
+		color, s = prettyOtherColor, fmt.Sprint(v)
+	}
+
+	first := true
+	eachLine(s, func(line string) {
+		if !first {
+			buf.AppendByte('\n')
+			buf.AppendString(multilineIndent)
+			buf.AppendString(multilineIndent)
+		}
+		first = false
+		colored(color, line)
+	})
+	buf.AppendByte('\n')
+}
@@ -0,0 +1,68 @@
+package log
+
+import (
+	"bytes"
+	"testing"
+	"time"
+
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+)
+
+func TestPrettyOutput(t *testing.T) {
+	t.Setenv("NO_COLOR", "")
+	t.Setenv("CLICOLOR", "")
+	if f, err := parseLogFormat("pretty"); err != nil || f != PrettyOutput || f.String() != "pretty" {
+		t.Fatalf("got %v, %v, want the pretty format", f, err)
+	}
+
+	buf := &bytes.Buffer{}
+	core := newConfiguredCore(Config{
+		Format: PrettyOutput,
+		Labels: map[string]string{"dc": "sjc-1"},
+	}, zapcore.AddSync(buf), LevelDebug)
+	core = core.With([]zapcore.Field{zap.String("peer", "scooby")})
+
+	entry := zapcore.Entry{
+		LoggerName: "main",
+		Level:      zapcore.InfoLevel,
+		Message:    "doo",
+		Time:       time.Date(2010, 5, 23, 15, 14, 0, 0, time.UTC),
+	}
+	if err := core.Write(entry, []zapcore.Field{zap.Int("snacks", 2), zap.String("yaml", "a: 1\nb: 2")}); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	want := "2010-05-23T15:14:00.000Z\t\x1b[34mINFO\x1b[0m\tmain\tdoo\n" +
+		"    \x1b[36mdc\x1b[0m: \x1b[32m\"sjc-1\"\x1b[0m\n" +
+		"    \x1b[36mpeer\x1b[0m: \x1b[32m\"scooby\"\x1b[0m\n" +
+		"    \x1b[36msnacks\x1b[0m: \x1b[33m2\x1b[0m\n" +
+		"    \x1b[36myaml\x1b[0m: \x1b[32ma: 1\x1b[0m\n" +
+		"        \x1b[32mb: 2\x1b[0m\n"
+	if got := buf.String(); got != want {
+		t.Errorf("got %q, want %q", got, want)
+	}
+}
+
+func TestPrettyOutputNoColor(t *testing.T) {
+	t.Setenv("NO_COLOR", "1")
+
+	buf := &bytes.Buffer{}
+	core := newConfiguredCore(Config{Format: PrettyOutput}, zapcore.AddSync(buf), LevelDebug)
+	entry := zapcore.Entry{
+		LoggerName: "main",
+		Level:      zapcore.InfoLevel,
+		Message:    "doo",
+		Time:       time.Date(2010, 5, 23, 15, 14, 0, 0, time.UTC),
+	}
+	if err := core.Write(entry, []zapcore.Field{zap.String("peer", "scooby"), zap.Int("snacks", 2)}); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	want := "2010-05-23T15:14:00.000Z\tINFO\tmain\tdoo\n" +
+		"    peer: \"scooby\"\n" +
+		"    snacks: 2\n"
+	if got := buf.String(); got != want {
+		t.Errorf("got %q, want %q", got, want)
+	}
+}
@@ -0,0 +1,55 @@
+package log
+
+import (
+	"errors"
+	"fmt"
+)
+
+// ErrNoSuchProfile is returned when applying a level profile that isn't
+// defined in Config.Profiles.
+var ErrNoSuchProfile = errors.New("no such level profile")
+
+// LevelProfile is a named set of levels, see Config.Profiles.
+type LevelProfile struct {
+	// Level, if set, is applied to all subsystems and becomes the default
+	// level.
+	Level *LogLevel
+
+	// SubsystemLevels are applied to the named subsystems, after Level.
+	SubsystemLevels map[string]LogLevel
+}
+
+// ApplyProfile switches to the levels of the named profile, as defined in
+// Config.Profiles or in the "profiles" of the configuration file. This lets
+// support staff toggle a curated set of subsystem levels in one call.
+// Subsystems the profile doesn't mention keep their level, unless the
+// profile sets a global level.
+func ApplyProfile(name string) error {
+	return defaultRegistry.ApplyProfile(name)
+}
+
+// ApplyProfile applies a level profile to this registry. See the
+// package-level ApplyProfile.
+func (r *Registry) ApplyProfile(name string) error {
+	defer r.updateLevelActivations()
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	profile, ok := r.config.Profiles[name]
+	if !ok {
+		return fmt.Errorf("%w: %q", ErrNoSuchProfile, name)
+	}
+	r.applyProfile(profile)
+	r.config.Profile = name
+	return nil
+}
+
+// applyProfile sets the levels of profile. r.mu must be held.
+func (r *Registry) applyProfile(profile LevelProfile) {
+	if profile.Level != nil {
+		r.defaultLevel = *profile.Level
+		r.setAllLoggers(*profile.Level)
+	}
+	r.setSubsystemLevels(profile.SubsystemLevels)
+}
@@ -0,0 +1,79 @@
+package log
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestApplyProfile(t *testing.T) {
+	debug := LevelDebug
+	reg := NewRegistry(Config{
+		Level: LevelError,
+		Profiles: map[string]LevelProfile{
+			"verbose-dht": {SubsystemLevels: map[string]LogLevel{"dht": LevelDebug}},
+			"debug-all":   {Level: &debug},
+		},
+	})
+	reg.Logger("dht")
+	reg.Logger("bitswap")
+
+	if err := reg.ApplyProfile("verbose-dht"); err != nil {
+		t.Fatal(err)
+	}
+	if lvl, _ := reg.SubsystemLevel("dht"); lvl != LevelDebug {
+		t.Errorf("got dht level %s, want debug", lvl)
+	}
+	if lvl, _ := reg.SubsystemLevel("bitswap"); lvl != LevelError {
+		t.Errorf("got bitswap level %s, want it untouched", lvl)
+	}
+
+	if err := reg.ApplyProfile("debug-all"); err != nil {
+		t.Fatal(err)
+	}
+	if lvl, _ := reg.SubsystemLevel("bitswap"); lvl != LevelDebug || reg.DefaultLevel() != LevelDebug {
+		t.Errorf("got bitswap level %s and default %s, want debug", lvl, reg.DefaultLevel())
+	}
+	if cfg := reg.GetConfig(); cfg.Profile != "debug-all" {
+		t.Errorf("got profile %q in the config", cfg.Profile)
+	}
+
+	if err := reg.ApplyProfile("loud"); !errors.Is(err, ErrNoSuchProfile) {
+		t.Errorf("got %v, want ErrNoSuchProfile", err)
+	}
+}
+
+func TestConfigFileProfiles(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "logging.json")
+	err := os.WriteFile(path, []byte(`{
+		"profiles": {"quiet": {"level": "error"}, "verbose-dht": {"subsystems": {"dht": "debug"}}},
+		"profile": "verbose-dht"
+	}`), 0o644)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	cfg, err := LoadConfigFile(path, Config{Level: LevelInfo})
+	if err != nil {
+		t.Fatal(err)
+	}
+	reg := newRegistry()
+	if err := reg.SetupLoggingWithError(cfg); err != nil {
+		t.Fatal(err)
+	}
+	if lvl, _ := reg.SubsystemLevel("dht"); lvl != LevelDebug {
+		t.Errorf("got dht level %s, want the profile's", lvl)
+	}
+	if err := reg.ApplyProfile("quiet"); err != nil {
+		t.Fatal(err)
+	}
+	if lvl, _ := reg.SubsystemLevel("dht"); lvl != LevelError {
+		t.Errorf("got dht level %s after switching profiles, want error", lvl)
+	}
+
+	cfg.Profile = "loud"
+	if err := reg.SetupLoggingWithError(cfg); !errors.Is(err, ErrNoSuchProfile) {
+		t.Errorf("got %v, want ErrNoSuchProfile", err)
+	}
+}
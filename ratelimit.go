@@ -0,0 +1,60 @@
+package log
+
+import (
+	"runtime"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"go.uber.org/zap/zapcore"
+)
+
+// callsites holds the state of the InfoEvery and WarnOnce call sites, keyed
+// by program counter.
+var callsites sync.Map // map[uintptr]*callsite
+
+type callsite struct {
+	last int64 // atomic; unix nanoseconds of the last entry logged
+}
+
+// allowCallsite reports whether the call site skip frames above the caller may log
+// now, given at most one entry per interval. A negative interval allows a
+// single entry ever.
+func allowCallsite(skip int, interval time.Duration) bool {
+	pc, _, _, ok := runtime.Caller(skip + 1)
+	if !ok {
+		return true
+	}
+	v, _ := callsites.LoadOrStore(pc, &callsite{})
+	cs := v.(*callsite)
+
+	now := time.Now().UnixNano()
+	for {
+		last := atomic.LoadInt64(&cs.last)
+		if last != 0 && (interval < 0 || now-last < int64(interval)) {
+			return false
+		}
+		if atomic.CompareAndSwapInt64(&cs.last, last, now) {
+			return true
+		}
+	}
+}
+
+// InfoEvery logs a message at info level with some additional context, at
+// most once per interval for each call site. It is meant for heartbeat-style
+// logs in hot loops.
+func (logger *ZapEventLogger) InfoEvery(interval time.Duration, msg string, keysAndValues ...interface{}) {
+	if !logger.Desugar().Core().Enabled(zapcore.InfoLevel) || !allowCallsite(1, interval) {
+		return
+	}
+	logger.skipLogger.Infow(msg, keysAndValues...)
+}
+
+// WarnOnce logs a message at warn level with some additional context, only
+// the first time it is called from a given call site.
+func (logger *ZapEventLogger) WarnOnce(msg string, keysAndValues ...interface{}) {
+	if !logger.Desugar().Core().Enabled(zapcore.WarnLevel) || !allowCallsite(1, -1) {
+		return
+	}
+	logger.skipLogger.Warnw(msg, keysAndValues...)
+}
@@ -0,0 +1,49 @@
+package log
+
+import (
+	"testing"
+	"time"
+
+	"go.uber.org/zap/zapcore"
+	"go.uber.org/zap/zaptest/observer"
+)
+
+func TestInfoEvery(t *testing.T) {
+	reg := NewRegistry(Config{Level: LevelInfo})
+	core, logs := observer.New(zapcore.DebugLevel)
+	reg.SetPrimaryCore(core)
+	log := reg.Logger("test")
+
+	for i := 0; i < 10; i++ {
+		log.InfoEvery(time.Hour, "scooby", "i", i)
+		log.InfoEvery(time.Hour, "doo", "i", i)
+	}
+	if logs.Len() != 2 {
+		t.Fatalf("got %d entries, want one per call site", logs.Len())
+	}
+
+	for i := 0; i < 3; i++ {
+		log.InfoEvery(time.Millisecond, "velma")
+		time.Sleep(5 * time.Millisecond)
+	}
+	if n := logs.FilterMessage("velma").Len(); n != 3 {
+		t.Errorf("got %d entries, want 3 once the interval elapsed", n)
+	}
+}
+
+func TestWarnOnce(t *testing.T) {
+	reg := NewRegistry(Config{Level: LevelInfo})
+	core, logs := observer.New(zapcore.DebugLevel)
+	reg.SetPrimaryCore(core)
+	log := reg.Logger("test")
+
+	for i := 0; i < 10; i++ {
+		log.WarnOnce("scooby")
+	}
+	if logs.Len() != 1 {
+		t.Fatalf("got %d entries, want 1", logs.Len())
+	}
+	if ent := logs.All()[0]; ent.Level != zapcore.WarnLevel || ent.Caller.Function != "github.com/ipfs/go-log/v2.TestWarnOnce" {
+		t.Errorf("got %+v", ent)
+	}
+}
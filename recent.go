@@ -0,0 +1,106 @@
+package log
+
+import (
+	"sync"
+
+	"go.uber.org/zap/zapcore"
+)
+
+// recentErrorsSize is the number of warnings and errors kept per subsystem.
+const recentErrorsSize = 32
+
+// RecordedEntry is a log entry kept in memory, along with its fields.
+type RecordedEntry struct {
+	zapcore.Entry
+	Fields []zapcore.Field
+}
+
+// RecentErrors returns up to n of the most recent warnings and errors logged
+// by the given subsystem, oldest first. If n <= 0, all retained entries are
+// returned. Only the last few dozen entries of each subsystem are retained.
+//
+// This is handy for health endpoints and bug reports, as it doesn't require
+// debug logging to be enabled.
+func RecentErrors(subsystem string, n int) []RecordedEntry {
+	return defaultRegistry.RecentErrors(subsystem, n)
+}
+
+// RecentErrors returns recent warnings and errors of a subsystem of this
+// registry. See the package-level RecentErrors.
+func (r *Registry) RecentErrors(subsystem string, n int) []RecordedEntry {
+	return r.recent.last(subsystem, n)
+}
+
+// entryRing is a fixed-size ring buffer of entries.
+type entryRing struct {
+	entries []RecordedEntry
+	next    int
+	full    bool
+}
+
+func newEntryRing(size int) *entryRing {
+	return &entryRing{entries: make([]RecordedEntry, size)}
+}
+
+func (r *entryRing) add(e RecordedEntry) {
+	if len(r.entries) == 0 {
+		return
+	}
+	r.entries[r.next] = e
+	r.next++
+	if r.next == len(r.entries) {
+		r.next = 0
+		r.full = true
+	}
+}
+
+// last returns up to n of the most recent entries, oldest first.
+func (r *entryRing) last(n int) []RecordedEntry {
+	size := r.next
+	if r.full {
+		size = len(r.entries)
+	}
+	if n <= 0 || n > size {
+		n = size
+	}
+	out := make([]RecordedEntry, 0, n)
+	for i := r.next - n; i < r.next; i++ {
+		out = append(out, r.entries[(i+len(r.entries))%len(r.entries)])
+	}
+	return out
+}
+
+// recentErrors keeps an entryRing of warnings and errors per subsystem.
+type recentErrors struct {
+	mu    sync.Mutex // guards rings
+	rings map[string]*entryRing
+}
+
+func newRecentErrors() *recentErrors {
+	return &recentErrors{rings: make(map[string]*entryRing)}
+}
+
+func (r *recentErrors) record(ent zapcore.Entry, fields []zapcore.Field) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	ring, ok := r.rings[ent.LoggerName]
+	if !ok {
+		ring = newEntryRing(recentErrorsSize)
+		r.rings[ent.LoggerName] = ring
+	}
+	// The fields slice may be reused by the caller.
+	ring.add(RecordedEntry{Entry: ent, Fields: append([]zapcore.Field(nil), fields...)})
+	return nil
+}
+
+func (r *recentErrors) last(subsystem string, n int) []RecordedEntry {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	ring, ok := r.rings[subsystem]
+	if !ok {
+		return nil
+	}
+	return ring.last(n)
+}
@@ -0,0 +1,37 @@
+package log
+
+import (
+	"fmt"
+	"testing"
+)
+
+func TestRecentErrors(t *testing.T) {
+	reg := NewRegistry(Config{Level: LevelInfo})
+	log := reg.Logger("test")
+
+	if got := reg.RecentErrors("test", 0); len(got) != 0 {
+		t.Errorf("got %d entries, want none", len(got))
+	}
+
+	log.Info("hidden")
+	for i := 0; i < recentErrorsSize+5; i++ {
+		log.Errorw(fmt.Sprint(i), "i", i)
+	}
+	log.Warn("last")
+
+	all := reg.RecentErrors("test", 0)
+	if len(all) != recentErrorsSize {
+		t.Fatalf("got %d entries, want %d", len(all), recentErrorsSize)
+	}
+	if all[0].Message != "6" || all[len(all)-1].Message != "last" {
+		t.Errorf("got entries from %q to %q", all[0].Message, all[len(all)-1].Message)
+	}
+
+	got := reg.RecentErrors("test", 2)
+	if len(got) != 2 || got[0].Message != fmt.Sprint(recentErrorsSize+4) || got[1].Message != "last" {
+		t.Errorf("got %v", got)
+	}
+	if len(got[0].Fields) != 1 || got[0].Fields[0].Key != "i" {
+		t.Errorf("got fields %v", got[0].Fields)
+	}
+}
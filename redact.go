@@ -0,0 +1,410 @@
+package log
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"path"
+	"strings"
+	"sync/atomic"
+	"time"
+
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+)
+
+// redactedValue replaces the values of redacted fields.
+const redactedValue = "[REDACTED]"
+
+// redactor replaces the values of the fields whose key matches one of its
+// patterns and, if enabled, anonymizes the IP addresses in string fields.
+type redactor struct {
+	patterns     []string // lower-cased path.Match patterns
+	anonymizeIPs bool
+}
+
+// newRedactor returns a redactor for the redaction options of cfg, or nil if
+// there is nothing to redact.
+func newRedactor(cfg Config) *redactor {
+	if len(cfg.Redact) == 0 && !cfg.AnonymizeIPs {
+		return nil
+	}
+	r := &redactor{
+		patterns:     make([]string, len(cfg.Redact)),
+		anonymizeIPs: cfg.AnonymizeIPs,
+	}
+	for i, p := range cfg.Redact {
+		r.patterns[i] = strings.ToLower(p)
+	}
+	return r
+}
+
+func (r *redactor) redacts(key string) bool {
+	key = strings.ToLower(key)
+	for _, p := range r.patterns {
+		if ok, _ := path.Match(p, key); ok {
+			return true
+		}
+	}
+	return false
+}
+
+// field returns f redacted, and whether it was changed.
+func (r *redactor) field(f zapcore.Field) (zapcore.Field, bool) {
+	if r.redacts(f.Key) {
+		return zap.String(f.Key, redactedValue), true
+	}
+	if len(r.patterns) > 0 {
+		// The keys nested in objects, maps and structs are redacted too.
+		switch f.Type {
+		case zapcore.ObjectMarshalerType, zapcore.InlineMarshalerType:
+			f.Interface = redactedObject{f.Interface.(zapcore.ObjectMarshaler), r}
+			return f, true
+		case zapcore.ArrayMarshalerType:
+			f.Interface = redactedArray{f.Interface.(zapcore.ArrayMarshaler), r}
+			return r.anonymized(f, true)
+		case zapcore.ReflectType:
+			if v, ok := r.reflected(f.Interface); ok {
+				f.Interface = v
+				return f, true
+			}
+		}
+	}
+	return r.anonymized(f, false)
+}
+
+// anonymized returns f with the IP addresses of its strings anonymized, if
+// enabled, and whether f was changed, here or before as reported by changed.
+func (r *redactor) anonymized(f zapcore.Field, changed bool) (zapcore.Field, bool) {
+	if !r.anonymizeIPs {
+		return f, changed
+	}
+
+	var s string
+	switch f.Type {
+	case zapcore.StringType:
+		s = f.String
+	case zapcore.StringerType:
+		s = fmt.Sprint(f.Interface)
+	case zapcore.ArrayMarshalerType:
+		f.Interface = anonymizedArray{f.Interface.(zapcore.ArrayMarshaler)}
+		return f, true
+	default:
+		return f, changed
+	}
+	if anon, ok := anonymizeIPs(s); ok {
+		return zap.String(f.Key, anon), true
+	}
+	return f, changed
+}
+
+// reflected returns v, logged through reflection, with the values of the
+// redacted keys of its JSON form replaced, and whether any was.
+func (r *redactor) reflected(v interface{}) (interface{}, bool) {
+	b, err := json.Marshal(v)
+	if err != nil || len(b) == 0 || (b[0] != '{' && b[0] != '[') {
+		return v, false
+	}
+	dec := json.NewDecoder(bytes.NewReader(b))
+	dec.UseNumber()
+	var generic interface{}
+	if err := dec.Decode(&generic); err != nil {
+		return v, false
+	}
+	if !r.redactJSON(generic) {
+		return v, false
+	}
+	return generic, true
+}
+
+// redactJSON replaces the values of the redacted keys of v, decoded from
+// JSON, in place, and reports whether any was.
+func (r *redactor) redactJSON(v interface{}) bool {
+	changed := false
+	switch v := v.(type) {
+	case map[string]interface{}:
+		for k, elem := range v {
+			if r.redacts(k) {
+				v[k] = redactedValue
+				changed = true
+			} else if r.redactJSON(elem) {
+				changed = true
+			}
+		}
+	case []interface{}:
+		for _, elem := range v {
+			if r.redactJSON(elem) {
+				changed = true
+			}
+		}
+	}
+	return changed
+}
+
+// redactedObject redacts the values of the keys of an object, e.g. of a
+// zap.Object field, matching the patterns of a redactor.
+type redactedObject struct {
+	zapcore.ObjectMarshaler
+	r *redactor
+}
+
+func (o redactedObject) MarshalLogObject(enc zapcore.ObjectEncoder) error {
+	return o.ObjectMarshaler.MarshalLogObject(redactingObjectEncoder{enc, o.r})
+}
+
+// redactedArray redacts the keys of the objects of an array, e.g. of a
+// zap.Objects field.
+type redactedArray struct {
+	zapcore.ArrayMarshaler
+	r *redactor
+}
+
+func (a redactedArray) MarshalLogArray(enc zapcore.ArrayEncoder) error {
+	return a.ArrayMarshaler.MarshalLogArray(redactingArrayEncoder{enc, a.r})
+}
+
+type redactingArrayEncoder struct {
+	zapcore.ArrayEncoder
+	r *redactor
+}
+
+func (enc redactingArrayEncoder) AppendArray(arr zapcore.ArrayMarshaler) error {
+	return enc.ArrayEncoder.AppendArray(redactedArray{arr, enc.r})
+}
+
+func (enc redactingArrayEncoder) AppendObject(obj zapcore.ObjectMarshaler) error {
+	return enc.ArrayEncoder.AppendObject(redactedObject{obj, enc.r})
+}
+
+func (enc redactingArrayEncoder) AppendReflected(v interface{}) error {
+	v, _ = enc.r.reflected(v)
+	return enc.ArrayEncoder.AppendReflected(v)
+}
+
+// redactingObjectEncoder replaces the values of the redacted keys added to
+// an ObjectEncoder.
+type redactingObjectEncoder struct {
+	zapcore.ObjectEncoder
+	r *redactor
+}
+
+// redacted adds the placeholder for key and reports whether key is redacted.
+func (enc redactingObjectEncoder) redacted(key string) bool {
+	if !enc.r.redacts(key) {
+		return false
+	}
+	enc.ObjectEncoder.AddString(key, redactedValue)
+	return true
+}
+
+func (enc redactingObjectEncoder) AddArray(key string, arr zapcore.ArrayMarshaler) error {
+	if enc.redacted(key) {
+		return nil
+	}
+	return enc.ObjectEncoder.AddArray(key, redactedArray{arr, enc.r})
+}
+
+func (enc redactingObjectEncoder) AddObject(key string, obj zapcore.ObjectMarshaler) error {
+	if enc.redacted(key) {
+		return nil
+	}
+	return enc.ObjectEncoder.AddObject(key, redactedObject{obj, enc.r})
+}
+
+func (enc redactingObjectEncoder) AddReflected(key string, v interface{}) error {
+	if enc.redacted(key) {
+		return nil
+	}
+	v, _ = enc.r.reflected(v)
+	return enc.ObjectEncoder.AddReflected(key, v)
+}
+
+func (enc redactingObjectEncoder) AddBinary(key string, v []byte) {
+	if !enc.redacted(key) {
+		enc.ObjectEncoder.AddBinary(key, v)
+	}
+}
+
+func (enc redactingObjectEncoder) AddByteString(key string, v []byte) {
+	if !enc.redacted(key) {
+		enc.ObjectEncoder.AddByteString(key, v)
+	}
+}
+
+func (enc redactingObjectEncoder) AddBool(key string, v bool) {
+	if !enc.redacted(key) {
+		enc.ObjectEncoder.AddBool(key, v)
+	}
+}
+
+func (enc redactingObjectEncoder) AddComplex128(key string, v complex128) {
+	if !enc.redacted(key) {
+		enc.ObjectEncoder.AddComplex128(key, v)
+	}
+}
+
+func (enc redactingObjectEncoder) AddComplex64(key string, v complex64) {
+	if !enc.redacted(key) {
+		enc.ObjectEncoder.AddComplex64(key, v)
+	}
+}
+
+func (enc redactingObjectEncoder) AddDuration(key string, v time.Duration) {
+	if !enc.redacted(key) {
+		enc.ObjectEncoder.AddDuration(key, v)
+	}
+}
+
+func (enc redactingObjectEncoder) AddFloat64(key string, v float64) {
+	if !enc.redacted(key) {
+		enc.ObjectEncoder.AddFloat64(key, v)
+	}
+}
+
+func (enc redactingObjectEncoder) AddFloat32(key string, v float32) {
+	if !enc.redacted(key) {
+		enc.ObjectEncoder.AddFloat32(key, v)
+	}
+}
+
+func (enc redactingObjectEncoder) AddInt(key string, v int) {
+	if !enc.redacted(key) {
+		enc.ObjectEncoder.AddInt(key, v)
+	}
+}
+
+func (enc redactingObjectEncoder) AddInt64(key string, v int64) {
+	if !enc.redacted(key) {
+		enc.ObjectEncoder.AddInt64(key, v)
+	}
+}
+
+func (enc redactingObjectEncoder) AddInt32(key string, v int32) {
+	if !enc.redacted(key) {
+		enc.ObjectEncoder.AddInt32(key, v)
+	}
+}
+
+func (enc redactingObjectEncoder) AddInt16(key string, v int16) {
+	if !enc.redacted(key) {
+		enc.ObjectEncoder.AddInt16(key, v)
+	}
+}
+
+func (enc redactingObjectEncoder) AddInt8(key string, v int8) {
+	if !enc.redacted(key) {
+		enc.ObjectEncoder.AddInt8(key, v)
+	}
+}
+
+func (enc redactingObjectEncoder) AddString(key, v string) {
+	if !enc.redacted(key) {
+		enc.ObjectEncoder.AddString(key, v)
+	}
+}
+
+func (enc redactingObjectEncoder) AddTime(key string, v time.Time) {
+	if !enc.redacted(key) {
+		enc.ObjectEncoder.AddTime(key, v)
+	}
+}
+
+func (enc redactingObjectEncoder) AddUint(key string, v uint) {
+	if !enc.redacted(key) {
+		enc.ObjectEncoder.AddUint(key, v)
+	}
+}
+
+func (enc redactingObjectEncoder) AddUint64(key string, v uint64) {
+	if !enc.redacted(key) {
+		enc.ObjectEncoder.AddUint64(key, v)
+	}
+}
+
+func (enc redactingObjectEncoder) AddUint32(key string, v uint32) {
+	if !enc.redacted(key) {
+		enc.ObjectEncoder.AddUint32(key, v)
+	}
+}
+
+func (enc redactingObjectEncoder) AddUint16(key string, v uint16) {
+	if !enc.redacted(key) {
+		enc.ObjectEncoder.AddUint16(key, v)
+	}
+}
+
+func (enc redactingObjectEncoder) AddUint8(key string, v uint8) {
+	if !enc.redacted(key) {
+		enc.ObjectEncoder.AddUint8(key, v)
+	}
+}
+
+func (enc redactingObjectEncoder) AddUintptr(key string, v uintptr) {
+	if !enc.redacted(key) {
+		enc.ObjectEncoder.AddUintptr(key, v)
+	}
+}
+
+// fields returns fields redacted. The slice is only copied if needed.
+func (r *redactor) fields(fields []zapcore.Field) []zapcore.Field {
+	var redacted []zapcore.Field
+	for i, f := range fields {
+		f, changed := r.field(f)
+		if changed && redacted == nil {
+			redacted = append([]zapcore.Field(nil), fields...)
+		}
+		if redacted != nil {
+			redacted[i] = f
+		}
+	}
+	if redacted == nil {
+		return fields
+	}
+	return redacted
+}
+
+// redaction holds the redactor of a registry, nil if disabled.
+type redaction struct {
+	v atomic.Value // *redactor
+}
+
+func (r *redaction) load() *redactor {
+	red, _ := r.v.Load().(*redactor)
+	return red
+}
+
+func (r *redaction) store(red *redactor) {
+	r.v.Store(red)
+}
+
+// redactCore redacts the fields of the entries it is given before writing
+// them to core, honoring its levels.
+type redactCore struct {
+	core     zapcore.Core
+	redactor *redactor
+}
+
+var _ zapcore.Core = (*redactCore)(nil)
+
+func (c *redactCore) Enabled(lvl zapcore.Level) bool {
+	return c.core.Enabled(lvl)
+}
+
+func (c *redactCore) With(fields []zapcore.Field) zapcore.Core {
+	return &redactCore{core: c.core.With(c.redactor.fields(fields)), redactor: c.redactor}
+}
+
+func (c *redactCore) Check(ent zapcore.Entry, ce *zapcore.CheckedEntry) *zapcore.CheckedEntry {
+	if c.Enabled(ent.Level) {
+		return ce.AddCore(ent, c)
+	}
+	return ce
+}
+
+func (c *redactCore) Write(ent zapcore.Entry, fields []zapcore.Field) error {
+	return c.core.Write(ent, c.redactor.fields(fields))
+}
+
+func (c *redactCore) Sync() error {
+	return c.core.Sync()
+}
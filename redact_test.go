@@ -0,0 +1,97 @@
+package log
+
+import (
+	"bufio"
+	"bytes"
+	"strings"
+	"testing"
+
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+	"go.uber.org/zap/zaptest/observer"
+)
+
+func TestRedact(t *testing.T) {
+	reg := NewRegistry(Config{Level: LevelInfo, Redact: []string{"password", "*token*"}})
+	core, logs := observer.New(zapcore.DebugLevel)
+	reg.SetPrimaryCore(core)
+	pipe := reg.NewPipeReader()
+
+	var hooked []zapcore.Field
+	reg.RegisterHook(LevelInfo, func(ent zapcore.Entry, fields []zapcore.Field) error {
+		hooked = fields
+		return nil
+	})
+
+	log := reg.Logger("test")
+	done := make(chan string)
+	go func() {
+		line, _ := bufio.NewReader(pipe).ReadString('\n')
+		done <- line
+	}()
+	log.With("Password", "hunter2").Infow("scooby", "api_token", "s3cr3t", "user", "velma")
+
+	if line := <-done; strings.Contains(line, "hunter2") || strings.Contains(line, "s3cr3t") || !strings.Contains(line, redactedValue) {
+		t.Errorf("got pipe line %q, want redacted values", line)
+	}
+	pipe.Close()
+	fields := logs.All()[0].ContextMap()
+	if fields["Password"] != redactedValue || fields["api_token"] != redactedValue || fields["user"] != "velma" {
+		t.Errorf("got fields %v", fields)
+	}
+	for _, f := range hooked {
+		if f.Key == "api_token" && f.String != redactedValue {
+			t.Errorf("got hook field %v, want it redacted", f)
+		}
+	}
+
+	reg.SetupLogging(Config{Level: LevelInfo})
+	reg.SetPrimaryCore(core)
+	log.Infow("doo", "password", "hunter2")
+	if v := logs.All()[1].ContextMap()["password"]; v != "hunter2" {
+		t.Errorf("got %v, want redaction disabled", v)
+	}
+}
+
+type credentials struct {
+	user, token string
+}
+
+func (c credentials) MarshalLogObject(enc zapcore.ObjectEncoder) error {
+	enc.AddString("user", c.user)
+	enc.AddString("token", c.token)
+	return nil
+}
+
+type credentialsList []credentials
+
+func (l credentialsList) MarshalLogArray(enc zapcore.ArrayEncoder) error {
+	for _, c := range l {
+		if err := enc.AppendObject(c); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func TestRedactNested(t *testing.T) {
+	reg := NewRegistry(Config{Level: LevelInfo, Redact: []string{"authorization", "*token*"}})
+	var buf bytes.Buffer
+	reg.SetPrimaryCore(reg.newPrimaryCore(Config{Format: JSONOutput}, zapcore.AddSync(&buf)))
+
+	reg.Logger("test").Desugar().Info("scooby",
+		zap.Any("headers", map[string]string{"Authorization": "Bearer hunter2", "Accept": "*/*"}),
+		zap.Object("creds", credentials{user: "velma", token: "s3cr3t"}),
+		zap.Array("peers", credentialsList{{user: "shaggy", token: "s3cr3t"}}),
+	)
+
+	out := buf.String()
+	if strings.Contains(out, "hunter2") || strings.Contains(out, "s3cr3t") {
+		t.Errorf("got %q, want the nested values redacted", out)
+	}
+	for _, want := range []string{`"Authorization":"[REDACTED]"`, `"Accept":"*/*"`, `"user":"velma"`, `"user":"shaggy"`} {
+		if !strings.Contains(out, want) {
+			t.Errorf("got %q, want it to contain %s", out, want)
+		}
+	}
+}
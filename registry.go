@@ -0,0 +1,677 @@
+package log
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"regexp"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+)
+
+// Registry is a self-contained set of loggers along with their levels,
+// primary core and configuration.
+//
+// The package-level functions operate on a default registry that is
+// configured from the environment on startup. Tests and embedded libraries
+// that want to configure logging without touching that global state can
+// create their own with NewRegistry.
+type Registry struct {
+	mu sync.RWMutex // guards access to the fields below
+
+	config Config
+
+	// loggers is the set of loggers in the registry
+	loggers map[string]*zap.SugaredLogger
+	levels  map[string]zap.AtomicLevel
+
+	// setLevels are the subsystems whose level was set at runtime, through
+	// SetLogLevel, SetLogLevelRegex or PersistLevels, until the next
+	// SetupLogging
+	setLevels map[string]struct{}
+
+	// info describes the subsystems with a logger
+	info map[string]*subsystemInfo
+
+	// cache mirrors loggers for lock-free lookups of existing loggers. It
+	// is only written with mu held.
+	cache sync.Map // name -> *zap.SugaredLogger
+
+	// overflow holds the loggers created once Config.MaxLoggers was
+	// reached, oldest first: only those are evicted to stay within it
+	overflow []overflowLogger
+
+	// released holds the subsystems of Config.SubsystemLevels whose
+	// logger was released, so that they are not reported as unknown
+	released map[string]struct{}
+
+	// primaryFormat is the format of the primary core used for logging
+	primaryFormat LogFormat
+
+	// defaultLevel is the default log level
+	defaultLevel LogLevel
+
+	// verbosity holds the V(n) verbosity of the subsystems that don't use
+	// defaultVerbosity
+	verbosity        map[string]int
+	defaultVerbosity int
+
+	// stacktraceLevel is the minimum level of the entries loggers attach a
+	// stack trace to
+	stacktraceLevel zap.AtomicLevel
+
+	// primaryCore is the primary logging core
+	primaryCore zapcore.Core
+
+	// outputsCore is the last primary core writing to the outputs, retired
+	// when they are replaced
+	outputsCore *outputsCore
+
+	// loggerCore is the base for all loggers created by this registry
+	loggerCore *lockedMultiCore
+
+	// configLocked is set by LockConfiguration
+	configLocked bool
+
+	// startup holds entries until logging is configured, if enabled
+	startup *startupBuffer
+
+	// buffering mirrors startup for the loggers, which pass it all entries
+	// while it is set
+	buffering atomic.Pointer[startupBuffer]
+
+	// outputs are the outputs opened by SetupLogging, closeOutputs
+	// closes them and std toggles the standard ones among them. All are
+	// nil if the primary core was set with SetPrimaryCore.
+	outputs      zapcore.WriteSyncer
+	closeOutputs func()
+	std          *standardOutputs
+
+	// rules are the rules applied to the outputs, nil if there are none
+	rules *ruleSet
+
+	// files are the additional files of Config.Files
+	files []openedFile
+
+	// reloadConfig re-reads the configuration file, if one is watched
+	reloadConfig func() error
+
+	// pipes are the open PipeReaders and EntryReaders, closed by Close
+	pipes map[io.Closer]struct{}
+
+	metrics *metrics
+
+	// recent holds the last warnings and errors of each subsystem
+	recent *recentErrors
+
+	recorder *flightRecorder
+
+	// fatal holds the hooks registered with OnFatal
+	fatal *fatalHooks
+
+	// redaction holds the redactor configured with Config.Redact
+	redaction *redaction
+
+	// filters holds the field filters of Config.FieldFilters and
+	// SetFieldFilters
+	filters *fieldFilters
+
+	// audit is the audit file of the AuditLoggers
+	audit *auditSink
+
+	// events is the file structured events are written to
+	events *eventSink
+
+	// syncer syncs the outputs in the background, if configured
+	syncer *outputSyncer
+
+	// clock timestamps the entries of the loggers
+	clock *registryClock
+
+	// seqs number the entries of each output, if configured, keyed by
+	// output. They outlive the outputs, so that numbers keep increasing
+	// across reconfigurations.
+	seqs map[string]*seqState
+
+	overridesMu sync.Mutex // guards overrides
+
+	// overrides are the temporary levels of subsystems
+	overrides map[string]*levelOverride
+
+	levelChangeMu sync.Mutex // guards levelListeners and lastLevels
+
+	// levelListeners are the callbacks registered with OnLevelChange
+	levelListeners map[*levelListener]struct{}
+
+	// lastLevels are the levels last reported to levelListeners
+	lastLevels map[string]zapcore.Level
+
+	activationsMutex sync.Mutex // guards activations
+
+	// activations are the callbacks registered with OnLevelActive
+	activations map[*levelActivation]struct{}
+}
+
+// NewRegistry returns a new registry configured with cfg.
+func NewRegistry(cfg Config) *Registry {
+	r := newRegistry()
+	r.SetupLogging(cfg)
+	return r
+}
+
+// noStacktraceLevel is above all levels, so that no stack traces are added.
+const noStacktraceLevel = zapcore.FatalLevel + 1
+
+func newRegistry() *Registry {
+	m := &metrics{}
+	recent := newRecentErrors()
+	syncer := &outputSyncer{}
+	clock := &registryClock{}
+	r := &Registry{
+		loggers:         make(map[string]*zap.SugaredLogger),
+		levels:          make(map[string]zap.AtomicLevel),
+		setLevels:       make(map[string]struct{}),
+		info:            make(map[string]*subsystemInfo),
+		released:        make(map[string]struct{}),
+		verbosity:       make(map[string]int),
+		stacktraceLevel: zap.NewAtomicLevelAt(noStacktraceLevel),
+		primaryFormat:   ColorizedOutput,
+		defaultLevel:    LevelError,
+		loggerCore: newLockedMultiCore(
+			&countingCore{m: m},
+			&hookCore{LevelEnabler: zapcore.WarnLevel, hook: recent.record},
+			&hookCore{LevelEnabler: zapcore.ErrorLevel, hook: syncer.onError},
+		),
+		overrides:      make(map[string]*levelOverride),
+		levelListeners: make(map[*levelListener]struct{}),
+		activations:    make(map[*levelActivation]struct{}),
+		pipes:          make(map[io.Closer]struct{}),
+		metrics:        m,
+		recent:         recent,
+		recorder:       &flightRecorder{},
+		fatal:          &fatalHooks{},
+		redaction:      &redaction{},
+		filters:        &fieldFilters{},
+		audit:          &auditSink{},
+		events:         &eventSink{clock: clock},
+		syncer:         syncer,
+		seqs:           make(map[string]*seqState),
+		clock:          clock,
+	}
+	m.onOutputError = (&selfReporter{r: r}).report
+	return r
+}
+
+// Logger retrieves an event logger by name
+func (r *Registry) Logger(system string) *ZapEventLogger {
+	if len(system) == 0 {
+		setuplog := r.getLogger("setup-logger")
+		setuplog.Error("Missing name parameter")
+		system = "undefined"
+	}
+
+	logger := r.getLogger(system)
+	skipLogger := logger.Desugar().WithOptions(zap.AddCallerSkip(1)).Sugar()
+
+	return &ZapEventLogger{
+		reg:           r,
+		system:        system,
+		SugaredLogger: *logger,
+		skipLogger:    *skipLogger,
+	}
+}
+
+// GetConfig returns a copy of the saved config. It can be inspected, modified,
+// and re-applied using a subsequent call to SetupLogging().
+func (r *Registry) GetConfig() Config {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	return r.config
+}
+
+// SetupLogging will initialize the logger backend and set the flags.
+func (r *Registry) SetupLogging(cfg Config) {
+	if err := r.setupLogging(cfg, false); err != nil {
+		r.getLogger("setup-logger").Errorw("ignoring SetupLogging call", "error", err)
+	}
+}
+
+// SetupLoggingWithError is like SetupLogging, but returns an error instead
+// of carrying on with a partial configuration.
+func (r *Registry) SetupLoggingWithError(cfg Config) error {
+	return r.setupLogging(cfg, true)
+}
+
+// setupLogging applies cfg. Unless strict is set, outputs that can't be
+// resolved are skipped with a warning on stderr.
+func (r *Registry) setupLogging(cfg Config, strict bool) error {
+	defer r.updateLevelActivations()
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if r.configLocked {
+		return ErrConfigurationLocked
+	}
+
+	if strict {
+		switch cfg.Format {
+		case ColorizedOutput, PlaintextOutput, JSONOutput, PrettyOutput, GitHubActionsOutput:
+		default:
+			return fmt.Errorf("invalid log format: %s", cfg.Format)
+		}
+	}
+
+	profile, ok := cfg.Profiles[cfg.Profile]
+	if !ok && cfg.Profile != "" {
+		err := fmt.Errorf("%w: %q", ErrNoSuchProfile, cfg.Profile)
+		if strict {
+			return err
+		}
+		fmt.Fprintln(os.Stderr, err)
+	}
+
+	if err := r.audit.setFile(cfg.AuditFile); err != nil {
+		if strict {
+			return err
+		}
+		fmt.Fprintln(os.Stderr, err)
+	}
+	if err := r.events.setFile(cfg.EventFile); err != nil {
+		if strict {
+			return err
+		}
+		fmt.Fprintln(os.Stderr, err)
+	}
+
+	o, err := r.openOutputs(cfg, strict)
+	if err != nil {
+		return err
+	}
+
+	r.config = cfg
+
+	r.primaryFormat = cfg.Format
+	r.defaultLevel = cfg.Level
+	r.defaultVerbosity = cfg.Verbosity
+	r.verbosity = make(map[string]int, len(cfg.SubsystemVerbosity))
+	for name, v := range cfg.SubsystemVerbosity {
+		r.verbosity[name] = v
+	}
+	r.redaction.store(newRedactor(cfg))
+	r.filters.store(newFieldFilterSet(cfg.FieldFilters))
+	r.clock.store(cfg.Clock)
+	if cfg.StacktraceLevel != nil {
+		r.stacktraceLevel.SetLevel(zapcore.Level(*cfg.StacktraceLevel))
+	} else {
+		r.stacktraceLevel.SetLevel(noStacktraceLevel)
+	}
+
+	r.useOutputs(o)
+	r.setAllLoggers(r.defaultLevel)
+	r.setLevels = make(map[string]struct{})
+
+	r.setSubsystemLevels(cfg.SubsystemLevels)
+	r.applyProfile(profile)
+
+	r.replayStartup()
+	return nil
+}
+
+// SetPrimaryCore changes the primary logging core. If the SetupLogging was
+// called then the previously configured core will be replaced.
+func (r *Registry) SetPrimaryCore(core zapcore.Core) {
+	r.mu.Lock()
+	locked := r.configLocked
+	if !locked {
+		r.setPrimaryCore(core)
+		// Don't close the outputs of the previous core, the caller may
+		// well restore it later.
+		r.outputs, r.closeOutputs, r.std = nil, nil, nil
+		r.replayStartup()
+	}
+	r.mu.Unlock()
+
+	if locked {
+		r.getLogger("setup-logger").Errorw("ignoring SetPrimaryCore call", "error", ErrConfigurationLocked)
+	}
+}
+
+// replacePrimaryCore replaces the primary core until restore is called, for
+// logtest.IntoTesting. Unlike SetPrimaryCore, the configured outputs are
+// kept open for the previous core, which restore puts back unless the
+// primary core was changed meanwhile, e.g. by SetupLogging closing the
+// outputs of the previous core.
+func (r *Registry) replacePrimaryCore(core zapcore.Core) (restore func()) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	prev := r.primaryCore
+	r.setPrimaryCore(core)
+	return func() {
+		r.mu.Lock()
+		defer r.mu.Unlock()
+
+		if r.primaryCore == core {
+			r.setPrimaryCore(prev)
+		}
+	}
+}
+
+// LockConfiguration prevents any further changes to the configuration of
+// the registry through SetupLogging or SetPrimaryCore. Such calls are
+// ignored and logged as an error. Levels may still be changed.
+func (r *Registry) LockConfiguration() {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.configLocked = true
+}
+
+// outputs are the opened outputs of a configuration.
+type outputs struct {
+	ws    zapcore.WriteSyncer
+	std   *standardOutputs
+	rules *ruleSet
+	files []openedFile
+	close func()
+}
+
+// openOutputs opens the outputs, rule sinks and additional files of cfg,
+// and starts syncing them in the background if configured. Unless strict is
+// set, outputs that can't be resolved are skipped with a warning on stderr.
+func (r *Registry) openOutputs(cfg Config, strict bool) (*outputs, error) {
+	rules, closeRules, err := openRules(cfg, strict, r.metrics)
+	if err != nil {
+		return nil, err
+	}
+	files, closeFiles, err := openFiles(cfg, strict, r.metrics)
+	if err != nil {
+		closeRules()
+		return nil, err
+	}
+	std := newStandardOutputs(cfg)
+	ws, closeOutputs, err := openOutputs(cfg, std, strict, r.metrics)
+	if err != nil {
+		closeFiles()
+		closeRules()
+		return nil, err
+	}
+	ws = &countingWriteSyncer{WriteSyncer: ws, m: r.metrics}
+	ws, closeOutputs, err = openFallback(ws, closeOutputs, cfg, strict, r.metrics)
+	if err != nil {
+		closeFiles()
+		closeRules()
+		return nil, err
+	}
+	ws, closeOutputs = openAsync(ws, closeOutputs, cfg, r.metrics)
+
+	// Only Sync is called on the syncers given to the background syncer.
+	syncers := []zapcore.WriteSyncer{ws}
+	for _, f := range files {
+		syncers = append(syncers, f.ws)
+	}
+	closeOutputs = r.syncer.open(zapcore.NewMultiWriteSyncer(syncers...), closeOutputs, cfg)
+
+	return &outputs{
+		ws:    ws,
+		std:   std,
+		rules: rules,
+		files: files,
+		close: closeAll(closeOutputs, closeFiles, closeRules),
+	}, nil
+}
+
+// useOutputs switches to the outputs o, closing the current ones. r.mu
+// must be held.
+func (r *Registry) useOutputs(o *outputs) {
+	r.rules = o.rules
+	r.files = o.files
+	r.setOutputsCore(r.newPrimaryCore(r.config, o.ws))
+	r.setCloseOutputs(o.close)
+	r.outputs = o.ws
+	r.std = o.std
+}
+
+// openOutputs opens the outputs configured in cfg, next to the standard
+// outputs std. Unless strict is set, outputs that can't be resolved are
+// skipped with a warning on stderr.
+func openOutputs(cfg Config, std *standardOutputs, strict bool, m *metrics) (zapcore.WriteSyncer, func(), error) {
+	outputPaths := []string{}
+
+	if len(cfg.URL) > 0 {
+		outputPaths = append(outputPaths, cfg.URL)
+	}
+
+	ws, closeOutputs, err := zap.Open(outputPaths...)
+	if err == nil {
+		ws = zapcore.NewMultiWriteSyncer(std, ws)
+	}
+	if err == nil && len(cfg.File) > 0 {
+		ws, closeOutputs, err = openFile(ws, closeOutputs, cfg, m)
+	}
+	if err != nil {
+		if strict {
+			return nil, nil, fmt.Errorf("unable to open logging output: %w", err)
+		}
+		panic(fmt.Sprintf("unable to open logging output: %v", err))
+	}
+	return ws, closeOutputs, nil
+}
+
+// setCloseOutputs records the function closing the outputs of the current
+// primary core, closing those of the previous one.
+func (r *Registry) setCloseOutputs(closeOutputs func()) {
+	if r.closeOutputs != nil {
+		r.closeOutputs()
+	}
+	r.closeOutputs = closeOutputs
+}
+
+// setOutputsCore makes core, writing to the outputs, the primary core. The
+// entries still reaching the previous one, from loggers derived before or
+// checked before, are forwarded to core once those being written are done,
+// so that the previous outputs can be closed on return. r.mu must be held.
+func (r *Registry) setOutputsCore(core zapcore.Core) {
+	prev := r.outputsCore
+	r.outputsCore = newOutputsCore(core)
+	r.setPrimaryCore(r.outputsCore)
+	if prev != nil {
+		prev.retire(r.outputsCore)
+	}
+}
+
+func (r *Registry) setPrimaryCore(core zapcore.Core) {
+	if r.primaryCore != nil {
+		r.loggerCore.ReplaceCore(r.primaryCore, core)
+	} else {
+		r.loggerCore.AddCore(core)
+	}
+	r.primaryCore = core
+}
+
+// SetAllLoggers changes the logging level of all loggers to lvl
+func (r *Registry) SetAllLoggers(lvl LogLevel) {
+	defer r.updateLevelActivations()
+
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	r.setAllLoggers(lvl)
+}
+
+func (r *Registry) setAllLoggers(lvl LogLevel) {
+	for _, l := range r.levels {
+		l.SetLevel(zapcore.Level(lvl))
+	}
+}
+
+// setSubsystemLevels sets the levels of the given subsystems, whether their
+// loggers exist yet or not. r.mu must be held.
+func (r *Registry) setSubsystemLevels(levels map[string]LogLevel) {
+	for name, level := range levels {
+		if leveler, ok := r.levels[name]; ok {
+			leveler.SetLevel(zapcore.Level(level))
+		} else {
+			r.levels[name] = zap.NewAtomicLevelAt(zapcore.Level(level))
+		}
+	}
+}
+
+// SetLogLevel changes the log level of a specific subsystem
+// name=="*" changes all subsystems
+func (r *Registry) SetLogLevel(name, level string) error {
+	lvl, err := LevelFromString(level)
+	if err != nil {
+		return err
+	}
+
+	// wildcard, change all
+	if name == "*" {
+		defer r.updateLevelActivations()
+
+		r.mu.Lock()
+		defer r.mu.Unlock()
+
+		r.setAllLoggers(lvl)
+		for name := range r.levels {
+			r.setLevels[name] = struct{}{}
+		}
+		return nil
+	}
+
+	defer r.updateLevelActivations()
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	// Check if we have a logger by that name
+	if _, ok := r.levels[name]; !ok {
+		return ErrNoSuchLogger
+	}
+
+	r.levels[name].SetLevel(zapcore.Level(lvl))
+	r.setLevels[name] = struct{}{}
+
+	return nil
+}
+
+// SetLogLevelRegex sets all loggers to level `l` that match expression `e`.
+// An error is returned if `e` fails to compile.
+func (r *Registry) SetLogLevelRegex(e, l string) error {
+	lvl, err := LevelFromString(l)
+	if err != nil {
+		return err
+	}
+
+	rem, err := regexp.Compile(e)
+	if err != nil {
+		return err
+	}
+
+	defer r.updateLevelActivations()
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	for name := range r.loggers {
+		if rem.MatchString(name) {
+			r.levels[name].SetLevel(zapcore.Level(lvl))
+			r.setLevels[name] = struct{}{}
+		}
+	}
+	return nil
+}
+
+// DefaultLevel returns the default minimum enabled logging level, as
+// configured by the last call to SetupLogging.
+func (r *Registry) DefaultLevel() LogLevel {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	return r.defaultLevel
+}
+
+// SubsystemLevel returns the current minimum enabled logging level of the
+// named subsystem. The boolean is false if no level is known for name.
+func (r *Registry) SubsystemLevel(name string) (LogLevel, bool) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	level, ok := r.levels[name]
+	if !ok {
+		return r.defaultLevel, false
+	}
+	return LogLevel(level.Level()), true
+}
+
+// SubsystemLevelEnabler returns a zapcore.LevelEnabler backed by the named
+// subsystem's level. See the package-level SubsystemLevelEnabler.
+func (r *Registry) SubsystemLevelEnabler(name string) (zapcore.LevelEnabler, bool) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	level, ok := r.levels[name]
+	if !ok {
+		return nil, false
+	}
+	return level, true
+}
+
+// GetSubsystems returns a slice containing the
+// names of the current loggers
+func (r *Registry) GetSubsystems() []string {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	subs := make([]string, 0, len(r.loggers))
+
+	for k := range r.loggers {
+		subs = append(subs, k)
+	}
+	return subs
+}
+
+func (r *Registry) getLogger(name string) *zap.SugaredLogger {
+	if log, ok := r.cache.Load(name); ok {
+		return log.(*zap.SugaredLogger)
+	}
+
+	r.mu.Lock()
+	log, ok := r.loggers[name]
+	var evicted []string
+	if !ok {
+		level, ok := r.levels[name]
+		if !ok {
+			level = zap.NewAtomicLevelAt(zapcore.Level(r.defaultLevel))
+			r.levels[name] = level
+		}
+		log = zap.New(newSubsystemCore(level, r.loggerCore, r.recorder, r.fatal, r.redaction, r.filters, &r.buffering)).
+			WithOptions(
+				zap.WithCaller(!r.config.DisableCaller),
+				zap.AddStacktrace(r.stacktraceLevel),
+				zap.WithClock(r.clock),
+			).
+			Named(name).
+			Sugar()
+
+		if max := r.config.MaxLoggers; max > 0 && len(r.loggers) >= max {
+			evicted = r.evictLocked(len(r.loggers) + 1 - max)
+			r.overflow = append(r.overflow, overflowLogger{name: name, log: log})
+		}
+
+		r.loggers[name] = log
+		r.cache.Store(name, log)
+		r.info[name] = &subsystemInfo{created: time.Now()}
+	}
+	r.mu.Unlock()
+
+	if len(evicted) > 0 {
+		r.forgetOverrides(evicted)
+		r.updateLevelActivations()
+	}
+	return log
+}
@@ -0,0 +1,103 @@
+package log
+
+import (
+	"bytes"
+	"io"
+	"strings"
+	"sync"
+	"testing"
+
+	"go.uber.org/zap/zapcore"
+	"go.uber.org/zap/zaptest/observer"
+)
+
+func TestRegistryIsolation(t *testing.T) {
+	skipWithoutDebug(t)
+
+	SetupLogging(Config{Level: LevelError})
+	global := getLogger("test-registry")
+
+	reg := NewRegistry(Config{Level: LevelInfo})
+	log := reg.Logger("test-registry")
+
+	var wg sync.WaitGroup
+	wg.Add(1)
+
+	r := reg.NewPipeReader(PipeFormat(PlaintextOutput))
+
+	buf := &bytes.Buffer{}
+	go func() {
+		defer wg.Done()
+		if _, err := io.Copy(buf, r); err != nil && err != io.ErrClosedPipe {
+			t.Errorf("unexpected error: %v", err)
+		}
+	}()
+
+	global.Error("global")
+	log.Info("scooby")
+	if err := reg.SetLogLevel("test-registry", "debug"); err != nil {
+		t.Fatal(err)
+	}
+	log.Debug("doo")
+	r.Close()
+	wg.Wait()
+
+	if strings.Contains(buf.String(), "global") {
+		t.Errorf("got %q, wanted it to not contain global log output", buf.String())
+	}
+	if !strings.Contains(buf.String(), "scooby") || !strings.Contains(buf.String(), "doo") {
+		t.Errorf("got %q, wanted it to contain registry log output", buf.String())
+	}
+	if lvl, _ := SubsystemLevel("test-registry"); lvl != LevelError {
+		t.Errorf("global level changed to %v", lvl)
+	}
+}
+
+func TestLockConfiguration(t *testing.T) {
+	reg := NewRegistry(Config{Level: LevelInfo})
+	log := reg.Logger("test-lock")
+
+	buf := &bytes.Buffer{}
+	reg.SetPrimaryCore(newCore(PlaintextOutput, zapcore.AddSync(buf), LevelDebug))
+	reg.LockConfiguration()
+
+	other := &bytes.Buffer{}
+	reg.SetPrimaryCore(newCore(PlaintextOutput, zapcore.AddSync(other), LevelDebug))
+	reg.SetupLogging(Config{Level: LevelError})
+
+	log.Info("scooby")
+
+	if other.Len() != 0 {
+		t.Errorf("got %q, wanted no output on replacement core", other.String())
+	}
+	if strings.Count(buf.String(), ErrConfigurationLocked.Error()) != 2 {
+		t.Errorf("got %q, wanted two warnings", buf.String())
+	}
+	if !strings.Contains(buf.String(), "scooby") {
+		t.Errorf("got %q, wanted it to contain log output", buf.String())
+	}
+}
+
+func TestReplacePrimaryCore(t *testing.T) {
+	reg := NewRegistry(Config{Level: LevelInfo})
+	prev := reg.primaryCore
+	replacement, _ := observer.New(zapcore.DebugLevel)
+
+	restore := reg.replacePrimaryCore(replacement)
+	if reg.primaryCore != replacement {
+		t.Fatal("primary core not replaced")
+	}
+	restore()
+	if reg.primaryCore != prev {
+		t.Error("primary core not restored")
+	}
+
+	// A core set meanwhile stays in place.
+	restore = reg.replacePrimaryCore(replacement)
+	other, _ := observer.New(zapcore.DebugLevel)
+	reg.SetPrimaryCore(other)
+	restore()
+	if reg.primaryCore != other {
+		t.Error("restore replaced a core set after the replacement")
+	}
+}
@@ -0,0 +1,90 @@
+package log
+
+import (
+	"sync/atomic"
+
+	"go.uber.org/zap"
+)
+
+// overflowLogger is a logger created once Config.MaxLoggers was reached.
+type overflowLogger struct {
+	name string
+	log  *zap.SugaredLogger // to tell it from a later logger of the same name
+}
+
+// ReleaseLogger removes the named logger from the registry, so that
+// processes creating loggers for dynamic names, e.g. per peer or per
+// session, don't accumulate them forever. It returns false if there is no
+// such logger.
+//
+// The level of the subsystem is forgotten, unless it comes from
+// Config.SubsystemLevels. Loggers already handed out keep working, but no
+// longer follow level changes; a later call to Logger creates a new one.
+func ReleaseLogger(name string) bool {
+	return defaultRegistry.ReleaseLogger(name)
+}
+
+// ReleaseLogger removes a logger from this registry. See the package-level
+// ReleaseLogger.
+func (r *Registry) ReleaseLogger(name string) bool {
+	defer r.updateLevelActivations()
+
+	r.mu.Lock()
+	_, ok := r.loggers[name]
+	if ok {
+		r.releaseLocked(name)
+	}
+	r.mu.Unlock()
+
+	if ok {
+		r.forgetOverrides([]string{name})
+	}
+	return ok
+}
+
+// releaseLocked removes the named logger. r.mu must be held.
+func (r *Registry) releaseLocked(name string) {
+	delete(r.loggers, name)
+	delete(r.info, name)
+	r.cache.Delete(name)
+	if _, configured := r.config.SubsystemLevels[name]; configured {
+		r.released[name] = struct{}{}
+	} else {
+		delete(r.levels, name)
+	}
+	r.metrics.entries.Delete(name)
+}
+
+// evictLocked releases up to n of the loggers created once
+// Config.MaxLoggers was reached, oldest first, and returns their names. The
+// loggers created before, typically those of packages, are never evicted, so
+// that they keep following level changes. r.mu must be held.
+func (r *Registry) evictLocked(n int) []string {
+	var evicted []string
+	for len(evicted) < n && len(r.overflow) > 0 {
+		o := r.overflow[0]
+		r.overflow[0] = overflowLogger{}
+		r.overflow = r.overflow[1:]
+		// Skip the loggers released meanwhile.
+		if r.loggers[o.name] == o.log {
+			r.releaseLocked(o.name)
+			evicted = append(evicted, o.name)
+		}
+	}
+	atomic.AddUint64(&r.metrics.evicted, uint64(len(evicted)))
+	return evicted
+}
+
+// forgetOverrides cancels the temporary levels of the named subsystems,
+// which were released. It must be called without holding r.mu.
+func (r *Registry) forgetOverrides(names []string) {
+	r.overridesMu.Lock()
+	defer r.overridesMu.Unlock()
+
+	for _, name := range names {
+		if o, ok := r.overrides[name]; ok {
+			o.timer.Stop()
+			delete(r.overrides, name)
+		}
+	}
+}
@@ -0,0 +1,78 @@
+package log
+
+import (
+	"sort"
+	"testing"
+)
+
+func TestReleaseLogger(t *testing.T) {
+	reg := NewRegistry(Config{Level: LevelError, SubsystemLevels: map[string]LogLevel{"dht": LevelWarn}})
+	reg.Logger("peer-1")
+	reg.Logger("dht")
+	if err := reg.SetLogLevel("peer-1", "debug"); err != nil {
+		t.Fatal(err)
+	}
+	if err := reg.SetLogLevel("dht", "info"); err != nil {
+		t.Fatal(err)
+	}
+
+	if !reg.ReleaseLogger("peer-1") || !reg.ReleaseLogger("dht") {
+		t.Fatal("expected the loggers to be released")
+	}
+	if reg.ReleaseLogger("peer-1") {
+		t.Error("expected no logger to release")
+	}
+	if subs := reg.GetSubsystems(); len(subs) != 0 {
+		t.Errorf("got subsystems %v", subs)
+	}
+	if _, ok := reg.SubsystemLevel("peer-1"); ok {
+		t.Error("expected the level of peer-1 to be forgotten")
+	}
+	if lvl, _ := reg.SubsystemLevel("dht"); lvl != LevelInfo {
+		t.Errorf("got dht level %s, want the configured subsystem to keep its level", lvl)
+	}
+
+	reg.Logger("peer-1")
+	if lvl, _ := reg.SubsystemLevel("peer-1"); lvl != LevelError {
+		t.Errorf("got level %s for the new logger, want the default", lvl)
+	}
+}
+
+func TestMaxLoggers(t *testing.T) {
+	reg := NewRegistry(Config{Level: LevelError, MaxLoggers: 3})
+	reg.Logger("dht")
+	if err := reg.SetLogLevel("dht", "debug"); err != nil {
+		t.Fatal(err)
+	}
+	reg.Logger("peer-1")
+	reg.Logger("peer-2")
+	reg.Logger("peer-3")
+	reg.Logger("peer-4")
+
+	// The loggers created within the cap are kept, only the most recent one
+	// created past it is.
+	subs := reg.GetSubsystems()
+	sort.Strings(subs)
+	if len(subs) != 4 || subs[0] != "dht" || subs[1] != "peer-1" || subs[2] != "peer-2" || subs[3] != "peer-4" {
+		t.Errorf("got subsystems %v, want peer-3 evicted", subs)
+	}
+	if m := reg.GetMetrics(); m.Evicted != 1 {
+		t.Errorf("got %d evicted loggers, want 1", m.Evicted)
+	}
+	if lvl, _ := reg.SubsystemLevel("dht"); lvl != LevelDebug {
+		t.Errorf("got dht level %s, want the logger created within the cap kept", lvl)
+	}
+
+	// A logger released and created again is evicted in its new turn.
+	reg.ReleaseLogger("peer-4")
+	reg.Logger("peer-5")
+	reg.Logger("peer-4")
+	subs = reg.GetSubsystems()
+	sort.Strings(subs)
+	if len(subs) != 4 || subs[3] != "peer-4" {
+		t.Errorf("got subsystems %v, want peer-5 evicted", subs)
+	}
+	if m := reg.GetMetrics(); m.Evicted != 2 {
+		t.Errorf("got %d evicted loggers, want 2", m.Evicted)
+	}
+}
@@ -0,0 +1,101 @@
+package log
+
+import (
+	"os"
+	"os/signal"
+	"sync"
+)
+
+// Reopen reopens the outputs configured with SetupLogging, e.g. after an
+// external tool like logrotate moved the log file away. Log files are also
+// reopened on their own within a second of being moved or deleted, so this
+// is only needed to do so right away or for other sinks. Levels are left
+// untouched. If a configuration file is being watched (see GOLOG_LOG_CONFIG),
+// it is re-read and applied instead, unless the configuration is locked
+// (see LockConfiguration). Environment variables are not re-read: the
+// outputs are reopened as last configured.
+//
+// Reopen does nothing if the primary core was set with SetPrimaryCore.
+func Reopen() error {
+	return defaultRegistry.Reopen()
+}
+
+// Reopen reopens the outputs of this registry. See the package-level Reopen.
+func (r *Registry) Reopen() error {
+	r.mu.RLock()
+	reload := r.reloadConfig
+	locked := r.configLocked
+	r.mu.RUnlock()
+	if reload != nil && !locked {
+		return reload()
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	// Reopening the same outputs doesn't change the configuration, so it is
+	// allowed even once locked.
+	if r.closeOutputs == nil {
+		return nil
+	}
+
+	o, err := r.openOutputs(r.config, true)
+	if err != nil {
+		return err
+	}
+	r.useOutputs(o)
+	return nil
+}
+
+// ReopenOnSignal calls Reopen whenever the process receives one of the given
+// signals, SIGHUP if none are given, until stop is called. Failures are
+// logged. This lets external log rotation work without restarting the
+// process. Like Reopen, it doesn't re-read environment variables, only the
+// watched configuration file, if any.
+//
+// Outside Unix, where there is no SIGHUP, it does nothing unless signals are
+// given.
+func ReopenOnSignal(sigs ...os.Signal) (stop func()) {
+	return defaultRegistry.ReopenOnSignal(sigs...)
+}
+
+// ReopenOnSignal reopens the outputs of this registry on signals. See the
+// package-level ReopenOnSignal.
+func (r *Registry) ReopenOnSignal(sigs ...os.Signal) (stop func()) {
+	if len(sigs) == 0 {
+		sigs = reopenSignals
+	}
+	if len(sigs) == 0 {
+		// signal.Notify would relay all signals.
+		return func() {}
+	}
+
+	ch := make(chan os.Signal, 1)
+	done := make(chan struct{})
+	signal.Notify(ch, sigs...)
+
+	var wg sync.WaitGroup
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		for {
+			select {
+			case <-ch:
+				if err := r.Reopen(); err != nil {
+					r.getLogger("setup-logger").Errorw("failed to reopen logging outputs", "error", err)
+				}
+			case <-done:
+				return
+			}
+		}
+	}()
+
+	var once sync.Once
+	return func() {
+		once.Do(func() {
+			signal.Stop(ch)
+			close(done)
+			wg.Wait()
+		})
+	}
+}
@@ -0,0 +1,9 @@
+//go:build !unix
+
+package log
+
+import "os"
+
+// reopenSignals is empty as there is no SIGHUP outside Unix, see
+// ReopenOnSignal.
+var reopenSignals []os.Signal
@@ -0,0 +1,159 @@
+package log
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestReopen(t *testing.T) {
+	skipWithoutDebug(t)
+
+	if runtime.GOOS == "windows" {
+		t.Skip("can't move open files on windows")
+	}
+
+	dir := t.TempDir()
+	logfile := filepath.Join(dir, "go-log-test")
+	rotated := logfile + ".1"
+
+	reg := NewRegistry(Config{
+		Format: PlaintextOutput,
+		Level:  LevelInfo,
+		File:   logfile,
+	})
+	log := reg.Logger("test")
+	if err := reg.SetLogLevel("test", "debug"); err != nil {
+		t.Fatal(err)
+	}
+
+	log.Info("scooby")
+	if err := os.Rename(logfile, rotated); err != nil {
+		t.Fatal(err)
+	}
+	log.Info("doo")
+	if err := reg.Reopen(); err != nil {
+		t.Fatal(err)
+	}
+	log.Debug("velma")
+
+	old, err := os.ReadFile(rotated)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(string(old), "scooby") || !strings.Contains(string(old), "doo") {
+		t.Errorf("got %q, wanted rotated file to contain entries before reopen", old)
+	}
+	cur, err := os.ReadFile(logfile)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(string(cur), "velma") || strings.Contains(string(cur), "doo") {
+		t.Errorf("got %q, wanted new file to contain only entries after reopen", cur)
+	}
+}
+
+func TestReopenOnSignal(t *testing.T) {
+	if len(reopenSignals) == 0 {
+		t.Skip("no reopen signal on " + runtime.GOOS)
+	}
+
+	dir := t.TempDir()
+	logfile := filepath.Join(dir, "go-log-test")
+
+	reg := NewRegistry(Config{
+		Format: PlaintextOutput,
+		Level:  LevelInfo,
+		File:   logfile,
+	})
+	stop := reg.ReopenOnSignal()
+	defer stop()
+
+	if err := os.Remove(logfile); err != nil {
+		t.Fatal(err)
+	}
+
+	p, err := os.FindProcess(os.Getpid())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := p.Signal(reopenSignals[0]); err != nil {
+		t.Fatal(err)
+	}
+
+	deadline := time.Now().Add(5 * time.Second)
+	for {
+		if _, err := os.Stat(logfile); err == nil {
+			break
+		}
+		if time.Now().After(deadline) {
+			t.Fatal("log file was not reopened")
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+}
+
+func TestReopenLocked(t *testing.T) {
+	logfile := filepath.Join(t.TempDir(), "go-log-test")
+
+	reg := NewRegistry(Config{
+		Format: PlaintextOutput,
+		Level:  LevelInfo,
+		File:   logfile,
+	})
+	reg.LockConfiguration()
+	if err := os.Remove(logfile); err != nil {
+		t.Fatal(err)
+	}
+	if err := reg.Reopen(); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := os.Stat(logfile); err != nil {
+		t.Errorf("log file was not reopened: %v", err)
+	}
+}
+
+func TestReopenKeepsEntries(t *testing.T) {
+	logfile := filepath.Join(t.TempDir(), "go-log-test")
+
+	reg := NewRegistry(Config{
+		Format: PlaintextOutput,
+		Level:  LevelInfo,
+		File:   logfile,
+	})
+	// Derived before the outputs are replaced.
+	log := reg.Logger("test").With("peer", "QmScooby")
+
+	const n = 1000
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		for i := 0; i < n; i++ {
+			log.Info("scooby")
+		}
+	}()
+	for i := 0; i < 20; i++ {
+		if err := reg.Reopen(); err != nil {
+			t.Fatal(err)
+		}
+	}
+	<-done
+	if err := reg.Close(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+
+	lines := readLines(t, logfile)
+	if len(lines) != n {
+		t.Errorf("got %d entries, want %d", len(lines), n)
+	}
+	for _, line := range lines {
+		if !strings.Contains(line, "QmScooby") {
+			t.Errorf("got %q, want the bound field", line)
+			break
+		}
+	}
+}
@@ -0,0 +1,11 @@
+//go:build unix
+
+package log
+
+import (
+	"os"
+	"syscall"
+)
+
+// reopenSignals are the signals ReopenOnSignal reacts to when none are given.
+var reopenSignals = []os.Signal{syscall.SIGHUP}
@@ -0,0 +1,297 @@
+package log
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path"
+	"sync"
+
+	"go.uber.org/multierr"
+	"go.uber.org/zap/zapcore"
+)
+
+// Rule matches entries on their way to the outputs configured with
+// SetupLogging, and acts on them. See Config.Rules.
+//
+// A rule matches the entries satisfying all of its matchers; one without
+// matchers matches all entries. Its actions then apply in this order: the
+// level is changed, labels are added, and the entry is either dropped,
+// routed to the sink or passed on to the next rules.
+type Rule struct {
+	// Subsystem is a path.Match pattern the subsystem of the entries must
+	// match, e.g. "dht*".
+	Subsystem string
+
+	// MinLevel and MaxLevel, if set, bound the level of the entries.
+	MinLevel *LogLevel
+	MaxLevel *LogLevel
+
+	// Fields are the field values the entries must all carry, compared
+	// like those of a FieldFilter.
+	Fields map[string]string
+
+	// SetLevel, if set, changes the level the entries are written with. It
+	// doesn't change whether they panic or exit the process.
+	SetLevel *LogLevel
+
+	// Labels are added to the entries as fields.
+	Labels map[string]string
+
+	// Drop discards the entries.
+	Drop bool
+
+	// Sink, if set, is a path or a URL with a scheme supported by zap the
+	// entries are written to, in the configured format, instead of the
+	// outputs.
+	Sink string
+}
+
+// compiledRule is a Rule ready to be evaluated.
+type compiledRule struct {
+	subsystem string
+	minLevel  zapcore.Level
+	maxLevel  zapcore.Level
+	fields    map[string]string
+	setLevel  *zapcore.Level
+	labels    []zapcore.Field
+	drop      bool
+	sink      int // index in ruleSet.sinks, -1 if none
+}
+
+func (r *compiledRule) matches(lvl zapcore.Level, bound, fields []zapcore.Field) bool {
+	if lvl < r.minLevel || lvl > r.maxLevel {
+		return false
+	}
+	for k, v := range r.fields {
+		if !hasField(bound, k, v) && !hasField(fields, k, v) {
+			return false
+		}
+	}
+	return true
+}
+
+// ruleSet is the compiled Config.Rules of a registry, along with their
+// opened sinks.
+type ruleSet struct {
+	rules     []compiledRule
+	sinks     []zapcore.WriteSyncer
+	sinkNames []string // the Rule.Sink of each of sinks
+
+	// bySubsystem caches the indices of the rules matching each subsystem,
+	// so that patterns are only matched once per subsystem.
+	bySubsystem sync.Map // subsystem -> []int
+}
+
+// openRules compiles the rules of cfg and opens their sinks. Unless strict
+// is set, invalid rules are skipped with a warning on stderr. The returned
+// function closes the sinks. It returns a nil ruleSet if there are no rules.
+func openRules(cfg Config, strict bool, m *metrics) (*ruleSet, func(), error) {
+	if len(cfg.Rules) == 0 {
+		return nil, func() {}, nil
+	}
+
+	set := &ruleSet{}
+	sinks := make(map[string]int)
+	var closers []func()
+
+	for i, rule := range cfg.Rules {
+		cr, err := compileRule(rule)
+		if err == nil && rule.Sink != "" {
+			idx, ok := sinks[rule.Sink]
+			if !ok {
+				var ws zapcore.WriteSyncer
+				var closeSink func()
+				ws, closeSink, err = openSink(rule.Sink, m)
+				if err == nil {
+					idx = len(set.sinks)
+					sinks[rule.Sink] = idx
+					set.sinks = append(set.sinks, &countingWriteSyncer{WriteSyncer: ws, m: m})
+					set.sinkNames = append(set.sinkNames, rule.Sink)
+					closers = append(closers, closeSink)
+				}
+			}
+			cr.sink = idx
+		}
+		if err != nil {
+			err = fmt.Errorf("invalid rule %d: %w", i, err)
+			if strict {
+				closeAll(closers...)()
+				return nil, nil, err
+			}
+			fmt.Fprintln(os.Stderr, err)
+			continue
+		}
+		set.rules = append(set.rules, cr)
+	}
+	return set, closeAll(closers...), nil
+}
+
+func compileRule(rule Rule) (compiledRule, error) {
+	cr := compiledRule{
+		subsystem: rule.Subsystem,
+		minLevel:  zapcore.Level(LevelTrace),
+		maxLevel:  zapcore.FatalLevel,
+		fields:    rule.Fields,
+		labels:    labelFields(rule.Labels),
+		drop:      rule.Drop,
+		sink:      -1,
+	}
+	if _, err := path.Match(rule.Subsystem, ""); err != nil {
+		return cr, fmt.Errorf("invalid subsystem pattern %q: %w", rule.Subsystem, err)
+	}
+	if rule.MinLevel != nil {
+		cr.minLevel = zapcore.Level(*rule.MinLevel)
+	}
+	if rule.MaxLevel != nil {
+		cr.maxLevel = zapcore.Level(*rule.MaxLevel)
+	}
+	if rule.SetLevel != nil {
+		lvl := zapcore.Level(*rule.SetLevel)
+		cr.setLevel = &lvl
+	}
+	if rule.Drop && rule.Sink != "" {
+		return cr, errors.New("rule both drops and routes entries")
+	}
+	return cr, nil
+}
+
+// forSubsystem returns the indices of the rules matching the subsystem.
+func (s *ruleSet) forSubsystem(name string) []int {
+	if idx, ok := s.bySubsystem.Load(name); ok {
+		return idx.([]int)
+	}
+	var idx []int
+	for i := range s.rules {
+		if ok, _ := path.Match(s.rules[i].subsystem, name); ok || s.rules[i].subsystem == "" {
+			idx = append(idx, i)
+		}
+	}
+	s.bySubsystem.Store(name, idx)
+	return idx
+}
+
+// rulesCore applies a ruleSet to the entries written to the primary core.
+type rulesCore struct {
+	core  zapcore.Core
+	set   *ruleSet
+	sinks []zapcore.Core // the cores of set.sinks, with the bound fields
+	bound []zapcore.Field
+}
+
+var _ zapcore.Core = (*rulesCore)(nil)
+
+// newRulesCore returns a rulesCore writing to core and the sinks of set,
+// whose cores are passed to number with the name of the sink.
+func newRulesCore(core zapcore.Core, set *ruleSet, cfg Config, number func(string, zapcore.Core) zapcore.Core) *rulesCore {
+	c := &rulesCore{core: core, set: set, sinks: make([]zapcore.Core, len(set.sinks))}
+	cfg.DisableTimestamp = false
+	for i, ws := range set.sinks {
+		c.sinks[i] = number("rule:"+set.sinkNames[i], newConfiguredCore(cfg, ws, LevelTrace))
+	}
+	return c
+}
+
+func (c *rulesCore) Enabled(lvl zapcore.Level) bool {
+	return c.core.Enabled(lvl)
+}
+
+func (c *rulesCore) With(fields []zapcore.Field) zapcore.Core {
+	sinks := make([]zapcore.Core, len(c.sinks))
+	for i, sink := range c.sinks {
+		sinks[i] = sink.With(fields)
+	}
+	return &rulesCore{
+		core:  c.core.With(fields),
+		set:   c.set,
+		sinks: sinks,
+		bound: append(c.bound[:len(c.bound):len(c.bound)], fields...),
+	}
+}
+
+func (c *rulesCore) Check(ent zapcore.Entry, ce *zapcore.CheckedEntry) *zapcore.CheckedEntry {
+	if c.Enabled(ent.Level) {
+		return ce.AddCore(ent, c)
+	}
+	return ce
+}
+
+func (c *rulesCore) Write(ent zapcore.Entry, fields []zapcore.Field) error {
+	for _, i := range c.set.forSubsystem(ent.LoggerName) {
+		rule := &c.set.rules[i]
+		if !rule.matches(ent.Level, c.bound, fields) {
+			continue
+		}
+		if rule.setLevel != nil {
+			ent.Level = *rule.setLevel
+		}
+		if len(rule.labels) > 0 {
+			fields = append(fields[:len(fields):len(fields)], rule.labels...)
+		}
+		if rule.drop {
+			return nil
+		}
+		if rule.sink >= 0 {
+			return c.sinks[rule.sink].Write(ent, fields)
+		}
+	}
+	return c.core.Write(ent, fields)
+}
+
+func (c *rulesCore) Sync() error {
+	err := c.core.Sync()
+	for _, sink := range c.sinks {
+		err = multierr.Append(err, sink.Sync())
+	}
+	return err
+}
+
+// newPrimaryCore returns the primary core writing to ws and the additional
+// files with the options of cfg, applying the rules of the registry. r.mu
+// must be held.
+func (r *Registry) newPrimaryCore(cfg Config, ws zapcore.WriteSyncer) zapcore.Core {
+	// Each output numbers the entries it writes, once filtered by the
+	// rules and its level, so that gaps only show lost entries.
+	number := func(output string, core zapcore.Core) zapcore.Core {
+		if !cfg.SequenceNumbers {
+			return core
+		}
+		state, ok := r.seqs[output]
+		if !ok {
+			state = newSeqState()
+			r.seqs[output] = state
+		}
+		return &seqCore{core: core, state: state}
+	}
+
+	core := number("", newConfiguredCore(cfg, ws, LevelTrace)) // the main core needs to log everything.
+	if len(r.files) > 0 {
+		cores := []zapcore.Core{core}
+		for _, f := range r.files {
+			fileCfg := cfg
+			fileCfg.Format = f.format
+			fileCfg.DisableTimestamp = false
+			cores = append(cores, number("file:"+f.path, newConfiguredCore(fileCfg, f.ws, f.level)))
+		}
+		core = newLockedMultiCore(cores...)
+	}
+	if r.rules != nil {
+		core = newRulesCore(core, r.rules, cfg, number)
+	}
+	if cfg.Uptime {
+		core = &computedFieldCore{core: core, field: uptimeField}
+	}
+	if cfg.GoroutineID {
+		core = &computedFieldCore{core: core, field: goroutineField}
+	}
+	return core
+}
+
+// closeAll returns a function calling each of closers in turn.
+func closeAll(closers ...func()) func() {
+	return func() {
+		for _, c := range closers {
+			c()
+		}
+	}
+}
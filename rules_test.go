@@ -0,0 +1,93 @@
+package log
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestRules(t *testing.T) {
+	dir := t.TempDir()
+	main := filepath.Join(dir, "main.log")
+	scooby := filepath.Join(dir, "scooby.log")
+	warn, info := LevelWarn, LevelInfo
+
+	reg := NewRegistry(Config{
+		Format: PlaintextOutput,
+		Level:  LevelInfo,
+		File:   main,
+		Rules: []Rule{
+			{Subsystem: "bitswap", MaxLevel: &info, Drop: true},
+			{Subsystem: "dht*", Fields: map[string]string{"peer": "QmScooby"}, Sink: scooby},
+			{MinLevel: &warn, Fields: map[string]string{"expected": "true"}, SetLevel: &info},
+			{Labels: map[string]string{"dc": "sjc-1"}},
+		},
+	})
+	reg.Logger("bitswap").Info("bitswap info")
+	reg.Logger("bitswap").Warn("bitswap warning")
+	reg.Logger("dht").With("peer", "QmScooby").Info("scooby")
+	reg.Logger("dht/providers").Infow("shaggy", "peer", "QmShaggy")
+	reg.Logger("net").Errorw("connection reset", "expected", true)
+	if err := reg.Close(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+
+	mainLog := readLines(t, main)
+	if len(mainLog) != 3 {
+		t.Fatalf("got %d entries, want 3: %q", len(mainLog), mainLog)
+	}
+	for i, want := range []string{"bitswap warning", "shaggy", "connection reset"} {
+		if !strings.Contains(mainLog[i], want) || !strings.Contains(mainLog[i], `"dc": "sjc-1"`) {
+			t.Errorf("got %q, want %q with the label", mainLog[i], want)
+		}
+	}
+	if !strings.Contains(mainLog[2], "INFO") {
+		t.Errorf("got %q, want the level changed to info", mainLog[2])
+	}
+
+	scoobyLog := readLines(t, scooby)
+	if len(scoobyLog) != 1 || !strings.Contains(scoobyLog[0], "scooby") || strings.Contains(scoobyLog[0], "sjc-1") {
+		t.Errorf("got %q, want the entry routed before being labeled", scoobyLog)
+	}
+}
+
+func TestInvalidRules(t *testing.T) {
+	reg := newRegistry()
+	err := reg.SetupLoggingWithError(Config{Rules: []Rule{{Subsystem: "[dht"}}})
+	if err == nil {
+		t.Error("expected an error for an invalid pattern")
+	}
+	err = reg.SetupLoggingWithError(Config{Rules: []Rule{{Drop: true, Sink: "stderr"}}})
+	if err == nil {
+		t.Error("expected an error for a rule dropping and routing entries")
+	}
+}
+
+func TestConfigFileRules(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "log.yaml")
+	data := "rules:\n  - subsystem: dht\n    min_level: debug\n    max_level: info\n    drop: true\n"
+	if err := os.WriteFile(path, []byte(data), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	cfg, err := LoadConfigFile(path, Config{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(cfg.Rules) != 1 {
+		t.Fatalf("got %d rules, want 1", len(cfg.Rules))
+	}
+	if r := cfg.Rules[0]; r.Subsystem != "dht" || *r.MinLevel != LevelDebug || *r.MaxLevel != LevelInfo || !r.Drop || r.SetLevel != nil {
+		t.Errorf("got %+v", r)
+	}
+}
+
+func readLines(t *testing.T, path string) []string {
+	t.Helper()
+	b, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return strings.Split(strings.TrimSpace(string(b)), "\n")
+}
@@ -0,0 +1,91 @@
+package log
+
+import (
+	"sync"
+	"time"
+
+	"go.uber.org/multierr"
+)
+
+// selfSubsystem is the subsystem the logging system reports the failures of
+// its own outputs under.
+const selfSubsystem = "golog"
+
+// selfReportInterval is the minimum time between two reports of output
+// failures. The failures in between are counted in the next report.
+const selfReportInterval = time.Minute
+
+// selfReporter logs the failures of the outputs as errors of the "golog"
+// subsystem, so that they reach the other outputs, hooks and readers rather
+// than only stderr.
+type selfReporter struct {
+	r *Registry
+
+	mu         sync.Mutex
+	last       time.Time
+	suppressed int
+}
+
+// report logs err, the failure of op on an output, unless another failure
+// was reported less than selfReportInterval ago.
+func (s *selfReporter) report(op string, err error) {
+	s.mu.Lock()
+	now := time.Now()
+	if !s.last.IsZero() && now.Sub(s.last) < selfReportInterval {
+		s.suppressed++
+		s.mu.Unlock()
+		return
+	}
+	suppressed := s.suppressed
+	s.last, s.suppressed = now, 0
+	s.mu.Unlock()
+
+	// The failing output may be written to from under a lock or from the
+	// goroutine draining the async queue, which logging from here would
+	// deadlock, so the report is logged by selfReportWorker.
+	selfReportsOnce.Do(func() { go selfReportWorker() })
+	select {
+	case selfReports <- selfReport{r: s.r, op: op, err: err, suppressed: suppressed}:
+	default:
+		// The worker is still busy with earlier reports, most likely
+		// stuck on a failing output itself.
+		s.mu.Lock()
+		s.suppressed += suppressed + 1
+		s.mu.Unlock()
+	}
+}
+
+// selfReport is a failure to be logged by selfReportWorker.
+type selfReport struct {
+	r          *Registry
+	op         string
+	err        error
+	suppressed int
+}
+
+var (
+	selfReports     = make(chan selfReport, 16)
+	selfReportsOnce sync.Once
+)
+
+// selfReportWorker logs the reports of all registries, started on the first
+// report.
+func selfReportWorker() {
+	for rep := range selfReports {
+		rep.r.getLogger(selfSubsystem).Errorw("logging output failed",
+			"op", rep.op, "error", rep.err, "suppressed", rep.suppressed)
+	}
+}
+
+// syncFailure returns err, the error syncing outputs, without the errors of
+// outputs that don't support syncing, like terminals and pipes, or nil if
+// none are left.
+func syncFailure(err error) error {
+	var failure error
+	for _, err := range multierr.Errors(err) {
+		if !unsyncable(err) {
+			failure = multierr.Append(failure, err)
+		}
+	}
+	return failure
+}
@@ -0,0 +1,9 @@
+//go:build !unix
+
+package log
+
+// unsyncable reports whether err is the error of syncing an output that
+// doesn't support it. No errors are recognized as such outside Unix.
+func unsyncable(err error) bool {
+	return false
+}
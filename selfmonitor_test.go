@@ -0,0 +1,39 @@
+package log
+
+import (
+	"testing"
+	"time"
+)
+
+func TestSelfReport(t *testing.T) {
+	reg := NewRegistry(Config{Level: LevelError})
+	r := reg.NewEntryReader()
+	defer r.Close()
+
+	w := &countingWriteSyncer{WriteSyncer: &brokenWriteSyncer{broken: true}, m: reg.metrics}
+	for i := 0; i < 3; i++ {
+		if _, err := w.Write([]byte("scooby\n")); err == nil {
+			t.Fatal("expected a write error")
+		}
+	}
+
+	select {
+	case e := <-r.Entries():
+		if e.Subsystem != selfSubsystem || e.Level != LevelError {
+			t.Errorf("got %+v", e)
+		}
+		if e.Fields["op"] != "write" || e.Fields["error"] != "read-only file system" {
+			t.Errorf("got fields %v", e.Fields)
+		}
+	case <-time.After(time.Second):
+		t.Fatal("no entry received")
+	}
+	select {
+	case e := <-r.Entries():
+		t.Errorf("got a second report %+v", e)
+	case <-time.After(50 * time.Millisecond):
+	}
+	if got := reg.GetMetrics().WriteErrors; got != 3 {
+		t.Errorf("got %d write errors", got)
+	}
+}
@@ -0,0 +1,14 @@
+//go:build unix
+
+package log
+
+import (
+	"errors"
+	"syscall"
+)
+
+// unsyncable reports whether err is the error of syncing an output that
+// doesn't support it, like a terminal or a pipe.
+func unsyncable(err error) bool {
+	return errors.Is(err, syscall.EINVAL) || errors.Is(err, syscall.ENOTTY)
+}
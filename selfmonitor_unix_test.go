@@ -0,0 +1,26 @@
+//go:build unix
+
+package log
+
+import (
+	"errors"
+	"os"
+	"syscall"
+	"testing"
+
+	"go.uber.org/multierr"
+)
+
+func TestSyncFailure(t *testing.T) {
+	unsyncable := &os.PathError{Op: "sync", Path: "/dev/stderr", Err: syscall.EINVAL}
+	if err := syncFailure(unsyncable); err != nil {
+		t.Errorf("got %v for an unsyncable output", err)
+	}
+	readOnly := errors.New("read-only file system")
+	if err := syncFailure(multierr.Combine(unsyncable, readOnly)); err != readOnly {
+		t.Errorf("got %v", err)
+	}
+	if err := syncFailure(nil); err != nil {
+		t.Errorf("got %v for no error", err)
+	}
+}
@@ -0,0 +1,56 @@
+package log
+
+import (
+	"sync/atomic"
+
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+)
+
+// SeqKey is the key of the sequence numbers added to entries with
+// Config.SequenceNumbers and the PipeSequenceNumbers option.
+const SeqKey = "seq"
+
+// seqCore numbers the entries written to core, starting from 1, so that
+// consumers can detect lost entries, honoring the levels of core. Numbers
+// are taken without holding a lock across the write, so entries logged
+// concurrently may reach core slightly out of order.
+type seqCore struct {
+	core  zapcore.Core
+	state *seqState // shared with the cores derived with With
+}
+
+type seqState struct {
+	last atomic.Uint64
+}
+
+func newSeqState() *seqState {
+	return &seqState{}
+}
+
+var _ zapcore.Core = (*seqCore)(nil)
+
+func (c *seqCore) Enabled(lvl zapcore.Level) bool {
+	return c.core.Enabled(lvl)
+}
+
+func (c *seqCore) With(fields []zapcore.Field) zapcore.Core {
+	return &seqCore{core: c.core.With(fields), state: c.state}
+}
+
+func (c *seqCore) Check(ent zapcore.Entry, ce *zapcore.CheckedEntry) *zapcore.CheckedEntry {
+	if c.Enabled(ent.Level) {
+		return ce.AddCore(ent, c)
+	}
+	return ce
+}
+
+func (c *seqCore) Write(ent zapcore.Entry, fields []zapcore.Field) error {
+	seq := c.state.last.Add(1)
+	fields = append(fields[:len(fields):len(fields)], zap.Uint64(SeqKey, seq))
+	return c.core.Write(ent, fields)
+}
+
+func (c *seqCore) Sync() error {
+	return c.core.Sync()
+}
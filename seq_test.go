@@ -0,0 +1,149 @@
+package log
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/json"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"testing"
+
+	"go.uber.org/zap/zapcore"
+)
+
+func TestSequenceNumbers(t *testing.T) {
+	reg := NewRegistry(Config{Level: LevelInfo})
+	var buf bytes.Buffer
+	cfg := Config{Format: JSONOutput, SequenceNumbers: true}
+	reg.SetPrimaryCore(reg.newPrimaryCore(cfg, zapcore.AddSync(&buf)))
+	log := reg.Logger("bitswap")
+
+	log.Info("scooby")
+	log.Debug("hidden")
+	log.With("peer", "QmShaggy").Warn("doo")
+	// The sequence survives reconfigurations.
+	reg.SetPrimaryCore(reg.newPrimaryCore(cfg, zapcore.AddSync(&buf)))
+	log.Error("velma")
+
+	if got := seqs(t, buf.String()); len(got) != 3 || got[0] != 1 || got[1] != 2 || got[2] != 3 {
+		t.Errorf("got sequence numbers %v", got)
+	}
+}
+
+func TestSequenceNumbersPerOutput(t *testing.T) {
+	dir := t.TempDir()
+	main := filepath.Join(dir, "main.log")
+	warnings := filepath.Join(dir, "warnings.log")
+	scooby := filepath.Join(dir, "scooby.log")
+	warn := LevelWarn
+
+	reg := NewRegistry(Config{
+		Format:          JSONOutput,
+		Level:           LevelInfo,
+		File:            main,
+		Files:           []FileOutput{{Path: warnings, Level: &warn}},
+		SequenceNumbers: true,
+		Rules: []Rule{
+			{Subsystem: "bitswap", MaxLevel: &warn, Drop: true},
+			{Subsystem: "dht", Sink: scooby},
+		},
+	})
+	reg.Logger("bitswap").Warn("dropped")
+	reg.Logger("dht").Warn("scooby")
+	reg.Logger("net").Info("shaggy")
+	reg.Logger("net").Warn("velma")
+	reg.Logger("dht").Info("doo")
+	if err := reg.Close(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+
+	// Each output numbers the entries it writes from 1, ignoring those
+	// dropped, sent to a sink or below its level.
+	for _, path := range []string{main, warnings, scooby} {
+		got := seqs(t, strings.Join(readLines(t, path), "\n"))
+		for i, seq := range got {
+			if seq != uint64(i+1) {
+				t.Errorf("%s: got sequence numbers %v", filepath.Base(path), got)
+				break
+			}
+		}
+	}
+	if n := len(readLines(t, warnings)); n != 1 {
+		t.Errorf("got %d entries in warnings.log, want 1", n)
+	}
+}
+
+func TestConcurrentSequenceNumbers(t *testing.T) {
+	reg := NewRegistry(Config{Level: LevelInfo})
+	var buf bytes.Buffer
+	cfg := Config{Format: JSONOutput, SequenceNumbers: true}
+	reg.SetPrimaryCore(reg.newPrimaryCore(cfg, zapcore.Lock(zapcore.AddSync(&buf))))
+	log := reg.Logger("bitswap")
+
+	const n = 100
+	var wg sync.WaitGroup
+	for i := 0; i < n; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			log.Info("scooby")
+		}()
+	}
+	wg.Wait()
+
+	got := seqs(t, buf.String())
+	sort.Slice(got, func(i, j int) bool { return got[i] < got[j] })
+	for i, seq := range got {
+		if seq != uint64(i+1) {
+			t.Fatalf("got sequence numbers %v, want each of 1 to %d once", got, n)
+		}
+	}
+	if len(got) != n {
+		t.Errorf("got %d entries, want %d", len(got), n)
+	}
+}
+
+func TestPipeSequenceNumbers(t *testing.T) {
+	reg := NewRegistry(Config{Level: LevelInfo})
+	p := reg.NewPipeReader(PipeSequenceNumbers(), PipeLevel(LevelWarn))
+	log := reg.Logger("bitswap")
+
+	done := make(chan []uint64)
+	go func() {
+		var lines []string
+		s := bufio.NewScanner(p)
+		for s.Scan() {
+			lines = append(lines, s.Text())
+		}
+		done <- seqs(t, strings.Join(lines, "\n"))
+	}()
+	log.Info("hidden")
+	log.Warn("scooby")
+	log.Error("doo")
+	if err := p.Close(); err != nil {
+		t.Fatal(err)
+	}
+	if got := <-done; len(got) != 2 || got[0] != 1 || got[1] != 2 {
+		t.Errorf("got sequence numbers %v", got)
+	}
+}
+
+// seqs returns the sequence numbers of the JSON entries in s.
+func seqs(t *testing.T, s string) []uint64 {
+	t.Helper()
+	var seqs []uint64
+	for _, line := range strings.Split(strings.TrimSpace(s), "\n") {
+		var entry struct {
+			Seq uint64 `json:"seq"`
+		}
+		if err := json.Unmarshal([]byte(line), &entry); err != nil {
+			t.Error(err)
+			continue
+		}
+		seqs = append(seqs, entry.Seq)
+	}
+	return seqs
+}
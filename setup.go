@@ -4,19 +4,91 @@ import (
 	"errors"
 	"fmt"
 	"os"
-	"regexp"
+	"strconv"
 	"strings"
 	"sync"
+	"time"
 
 	"github.com/mattn/go-isatty"
+	"go.uber.org/multierr"
 	"go.uber.org/zap"
 	"go.uber.org/zap/zapcore"
 )
 
-var config Config
-
 func init() {
-	SetupLogging(configFromEnv())
+	if size := os.Getenv(envFlightRecorder); size != "" {
+		if on, err := strconv.ParseBool(size); err == nil {
+			if on {
+				defaultRegistry.StartFlightRecorder(defaultFlightRecorderSize)
+			}
+		} else if n, err := strconv.Atoi(size); err == nil && n > 0 {
+			defaultRegistry.StartFlightRecorder(n)
+		} else {
+			fmt.Fprintf(os.Stderr, "ignoring invalid flight recorder size %q\n", size)
+		}
+	}
+
+	bufferSize, buffer := startupBufferFromEnv()
+
+	if noAutoInit, _ := strconv.ParseBool(os.Getenv(envNoAutoInit)); noAutoInit {
+		defaultRegistry.bufferStartup(bufferSize, 0)
+		return
+	}
+
+	setupFromEnv()
+	if buffer {
+		// Levels come from the environment, but entries are held until the
+		// application calls SetupLogging itself, or for a while at most.
+		defaultRegistry.bufferStartup(bufferSize, startupBufferDeadline)
+	}
+}
+
+var (
+	envSetupMu sync.Mutex // serializes setupFromEnv
+	// stopEnvConfigWatch stops watching the GOLOG_LOG_CONFIG file, if it is
+	stopEnvConfigWatch func() error
+)
+
+// setupFromEnv configures the default registry from the environment and the
+// GOLOG_LOG_CONFIG file, if set, which is then watched.
+func setupFromEnv() {
+	envSetupMu.Lock()
+	defer envSetupMu.Unlock()
+
+	if stopEnvConfigWatch != nil {
+		stopEnvConfigWatch() // nolint:errcheck
+		stopEnvConfigWatch = nil
+	}
+
+	cfg := configFromEnv()
+	if path := os.Getenv(envLoggingConfig); path != "" {
+		stop, err := defaultRegistry.WatchConfigFile(path, cfg)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "failed to load logging configuration: %s\n", err)
+			SetupLogging(cfg)
+		}
+		stopEnvConfigWatch = stop
+	} else {
+		SetupLogging(cfg)
+	}
+	if len(cfg.SubsystemLevels) > 0 {
+		envSubsystemLevels.Store(&cfg.SubsystemLevels)
+	}
+}
+
+// startupBufferFromEnv returns the size of the startup buffer and whether
+// buffering was explicitly requested.
+func startupBufferFromEnv() (int, bool) {
+	size := os.Getenv(envStartupBuffer)
+	if size == "" {
+		return defaultStartupBufferSize, false
+	}
+	n, err := strconv.Atoi(size)
+	if err != nil || n < 0 {
+		fmt.Fprintf(os.Stderr, "ignoring invalid startup buffer size %q\n", size)
+		return defaultStartupBufferSize, false
+	}
+	return n, n > 0
 }
 
 // Logging environment variables
@@ -32,11 +104,50 @@ const (
 	envLogging    = "GOLOG_LOG_LEVEL"
 	envLoggingFmt = "GOLOG_LOG_FMT"
 
-	envLoggingFile = "GOLOG_FILE" // /path/to/file
-	envLoggingURL  = "GOLOG_URL"  // url that will be processed by sink in the zap
-
-	envLoggingOutput = "GOLOG_OUTPUT"     // possible values: stdout|stderr|file combine multiple values with '+'
-	envLoggingLabels = "GOLOG_LOG_LABELS" // comma-separated key-value pairs, i.e. "app=example_app,dc=sjc-1"
+	envLoggingFile      = "GOLOG_FILE"                // /path/to/file, or a date template like /path/to/%Y-%m-%d.log
+	envFileRetention    = "GOLOG_FILE_RETENTION"      // how long the files of a dated GOLOG_FILE are kept, i.e. "168h"
+	envFileCompression  = "GOLOG_FILE_COMPRESSION"    // none|gzip, the compression GOLOG_FILE is written with
+	envFileMode         = "GOLOG_FILE_MODE"           // octal permissions of GOLOG_FILE, i.e. "0600"
+	envFileCreateDirs   = "GOLOG_FILE_CREATE_DIRS"    // true to create the missing parent directories of GOLOG_FILE
+	envFileMaxTotalSize = "GOLOG_FILE_MAX_TOTAL_SIZE" // size past which writing to GOLOG_FILE is suspended, i.e. "512M"
+	envFileMinFreeSpace = "GOLOG_FILE_MIN_FREE_SPACE" // free disk space under which writing to GOLOG_FILE is suspended, i.e. "1G"
+	envLoggingURL       = "GOLOG_URL"                 // url that will be processed by sink in the zap
+
+	envLoggingOutput  = "GOLOG_OUTPUT"      // possible values: stdout|stderr|file combine multiple values with '+'
+	envLoggingLabels  = "GOLOG_LOG_LABELS"  // comma-separated key-value pairs, i.e. "app=example_app,dc=sjc-1"
+	envLoggingConfig  = "GOLOG_LOG_CONFIG"  // /path/to/config.{json,yaml}, applied on top of the env and watched for changes
+	envVerbosity      = "GOLOG_VERBOSITY"   // like GOLOG_LOG_LEVEL with integers, i.e. "1,dht=4"
+	envLoggingProfile = "GOLOG_LOG_PROFILE" // name of the level profile to apply, defined in GOLOG_LOG_CONFIG
+
+	envStacktraceLevel = "GOLOG_STACKTRACE_LEVEL" // minimum level of the entries to attach a stack trace to
+	envCaller          = "GOLOG_CALLER"           // false, or the caller format: short|full|function
+	envSubsystemColors = "GOLOG_SUBSYSTEM_COLORS" // true to render each subsystem name in its own color
+	envRedact          = "GOLOG_REDACT"           // comma-separated field key patterns, i.e. "password,*token*"
+	envAnonymizeIPs    = "GOLOG_ANONYMIZE_IPS"    // true to truncate IP addresses in fields
+	envFieldFilters    = "GOLOG_FIELD_FILTERS"    // comma-separated key=value:level filters, i.e. "peer=QmFoo:debug"
+	envAuditFile       = "GOLOG_AUDIT_FILE"       // /path/to/file the hash-chained audit records are appended to
+	envEventFile       = "GOLOG_EVENT_FILE"       // /path/to/file structured events are appended to
+	envFileKey         = "GOLOG_FILE_KEY"         // hex-encoded AES key to encrypt GOLOG_FILE with
+	envFileKeyFile     = "GOLOG_FILE_KEY_FILE"    // /path/to/file holding the hex-encoded key, instead of GOLOG_FILE_KEY
+	envAsync           = "GOLOG_ASYNC"            // size of the queue of entries written asynchronously
+	envAsyncPolicy     = "GOLOG_ASYNC_POLICY"     // block|drop, what to do while the async queue is full
+	envSyncInterval    = "GOLOG_SYNC_INTERVAL"    // duration between background syncs of the outputs, i.e. "5s"
+	envSyncOnError     = "GOLOG_SYNC_ON_ERROR"    // true to sync the outputs shortly after errors
+	envMaxLoggers      = "GOLOG_MAX_LOGGERS"      // max loggers to keep, releasing the oldest created past it
+	envFallback        = "GOLOG_FALLBACK"         // stderr|stdout|/path/to/file|url to write to while the outputs fail
+	envContainer       = "GOLOG_CONTAINER"        // auto|true|false, whether to default to JSON on stdout as in containers
+	envTimestamp       = "GOLOG_TIMESTAMP"        // true|false|auto, whether entries are timestamped, auto drops it under journald
+	envGoroutineID     = "GOLOG_GOROUTINE_ID"     // true to add the ID of the logging goroutine to entries
+	envSeq             = "GOLOG_SEQ"              // true to number the entries written to the outputs
+	envUptime          = "GOLOG_UPTIME"           // true to add the milliseconds elapsed since the process started to entries
+
+	envNoAutoInit    = "GOLOG_NO_AUTO_INIT"   // don't configure logging on import, buffer until the application does
+	envStartupBuffer = "GOLOG_STARTUP_BUFFER" // max entries to hold and replay once the application calls SetupLogging
+
+	envPersistLevels = "GOLOG_PERSIST_LEVELS" // false to disable PersistLevels
+
+	envFlightRecorder = "GOLOG_FLIGHT_RECORDER" // true, or the number of entries to record in memory at all levels
+	envCrashFile      = "GOLOG_CRASH_FILE"      // /path/to/file crash reports are appended to
 )
 
 type LogFormat int
@@ -45,6 +156,70 @@ const (
 	ColorizedOutput LogFormat = iota
 	PlaintextOutput
 	JSONOutput
+	// PrettyOutput renders fields indented below the entry line, for local
+	// development.
+	PrettyOutput
+	// GitHubActionsOutput is PlaintextOutput with warn and error entries
+	// turned into GitHub Actions workflow commands, so that they show up
+	// as annotations. It is the default in GitHub Actions workflows.
+	GitHubActionsOutput
+)
+
+// logFormats lists every LogFormat.
+var logFormats = []LogFormat{ColorizedOutput, PlaintextOutput, JSONOutput, PrettyOutput, GitHubActionsOutput}
+
+// LogFormats returns every LogFormat, e.g. to check them all in tests.
+func LogFormats() []LogFormat {
+	return append([]LogFormat(nil), logFormats...)
+}
+
+// parseLogFormat parses the name of a format as accepted by GOLOG_LOG_FMT.
+func parseLogFormat(format string) (LogFormat, error) {
+	switch format {
+	case "color":
+		return ColorizedOutput, nil
+	case "nocolor":
+		return PlaintextOutput, nil
+	case "json":
+		return JSONOutput, nil
+	case "pretty":
+		return PrettyOutput, nil
+	case "github":
+		return GitHubActionsOutput, nil
+	default:
+		return ColorizedOutput, fmt.Errorf("unrecognized log format %q", format)
+	}
+}
+
+// String returns the name of the format as accepted by GOLOG_LOG_FMT.
+func (f LogFormat) String() string {
+	switch f {
+	case ColorizedOutput:
+		return "color"
+	case PlaintextOutput:
+		return "nocolor"
+	case JSONOutput:
+		return "json"
+	case PrettyOutput:
+		return "pretty"
+	case GitHubActionsOutput:
+		return "github"
+	default:
+		return fmt.Sprintf("LogFormat(%d)", int(f))
+	}
+}
+
+// CallerFormat is the format of the call site of entries.
+type CallerFormat int
+
+const (
+	// CallerShort renders the package directory, file and line.
+	CallerShort CallerFormat = iota
+	// CallerFull renders the full path of the file and the line.
+	CallerFull
+	// CallerFunction renders the call site like CallerShort, followed by
+	// the fully qualified name of the function.
+	CallerFunction
 )
 
 type Config struct {
@@ -57,47 +232,221 @@ type Config struct {
 	// SubsystemLevels are the default levels per-subsystem. When unspecified, defaults to Level.
 	SubsystemLevels map[string]LogLevel
 
+	// Verbosity is the default verbosity enabling V(n) logging for n up to
+	// it. See ZapEventLogger.V.
+	Verbosity int
+
+	// StacktraceLevel, if set, is the minimum level of the entries a stack
+	// trace is attached to.
+	StacktraceLevel *LogLevel
+
+	// SubsystemVerbosity are the verbosities per-subsystem. When
+	// unspecified, defaults to Verbosity.
+	SubsystemVerbosity map[string]int
+
+	// Profiles are named sets of levels that can be switched to at runtime
+	// with ApplyProfile, e.g. "quiet" or "verbose-dht".
+	Profiles map[string]LevelProfile
+
+	// Profile, if set, names the profile applied on top of Level and
+	// SubsystemLevels.
+	Profile string
+
 	// Stderr indicates whether logs should be written to stderr.
 	Stderr bool
 
 	// Stdout indicates whether logs should be written to stdout.
 	Stdout bool
 
-	// File is a path to a file that logs will be written to.
+	// File is a path to a file that logs will be written to. It may be a
+	// date template like "/var/log/ipfs/%Y-%m-%d.log", with the %Y, %m,
+	// %d, %H and %M directives, in which case a new file is started
+	// whenever the expansion changes, e.g. at midnight. In templates %%
+	// stands for a literal %; any other % is kept as is.
 	File string
 
+	// FileRetention, if positive, is how long the files of a dated File
+	// are kept. Older ones, per their modification time, are deleted when
+	// a new file is started.
+	FileRetention time.Duration
+
+	// FileCompression is the compression File is written with.
+	FileCompression Compression
+
+	// FileMode, if set, is the permissions of File and its backups, e.g.
+	// 0600. It is applied to an existing file too. Defaults to 0666 before
+	// the umask.
+	FileMode os.FileMode
+
+	// FileCreateDirs creates the missing parent directories of File,
+	// instead of failing to open it. They get the permissions of
+	// FileMode, plus search where reading is allowed.
+	FileCreateDirs bool
+
+	// FileMaxTotalSize, if positive, is the size in bytes of File, its
+	// backups and, if dated, the files of other dates, past which writing
+	// to File is suspended. A single warning is printed to stderr and the
+	// entries are counted as dropped until the size falls back below it,
+	// e.g. once old files are removed. It is checked every second.
+	FileMaxTotalSize int64
+
+	// FileMinFreeSpace, if positive, is the free disk space in bytes under
+	// which writing to File is suspended like for FileMaxTotalSize. It is
+	// only supported on Linux, macOS and FreeBSD.
+	FileMinFreeSpace int64
+
+	// FileKey, if set, is an AES key (16, 24 or 32 bytes) the file output
+	// is encrypted with. See DecryptLog.
+	FileKey []byte
+
 	// URL with schema supported by zap. Use zap.RegisterSink
 	URL string
 
+	// Files are additional log files, each with its own level, format and
+	// rotation settings, e.g. an errors.log at warn level next to a
+	// full.log.
+	Files []FileOutput
+
+	// Fallback, if set, is an output entries are redirected to when they
+	// fail to be written to the ones above: "stderr", "stdout", a file
+	// path or a URL supported by zap. After a few failures in a row, the
+	// outputs are only tried again every 10 seconds, and used again as
+	// soon as they work. Write errors are still counted in
+	// Metrics.WriteErrors.
+	Fallback string
+
+	// AsyncQueueSize, if positive, makes the outputs asynchronous: encoded
+	// entries are queued, up to this many, and written by a background
+	// goroutine. Call Flush or Close to make sure they were written.
+	AsyncQueueSize int
+
+	// AsyncPolicy decides what happens to entries logged while the queue
+	// of asynchronous outputs is full. Defaults to AsyncBlock.
+	AsyncPolicy AsyncPolicy
+
+	// SyncInterval, if positive, is how often the outputs are synced in the
+	// background, give or take 10%, so that fewer entries are lost on a
+	// crash without syncing after every entry.
+	SyncInterval time.Duration
+
+	// SyncOnError syncs the outputs shortly after entries at error level or
+	// above. Bursts of errors lead to a single sync.
+	SyncOnError bool
+
+	// MaxLoggers, if positive, caps the number of loggers created for
+	// dynamic names: once that many loggers exist, creating another one
+	// releases the oldest of those created after the cap was reached, as
+	// ReleaseLogger does. The loggers created before, typically those of
+	// packages, are kept, along with the most recent one even if that
+	// exceeds the cap. See Metrics.Evicted.
+	MaxLoggers int
+
 	// Labels is a set of key-values to apply to all loggers
 	Labels map[string]string
+
+	// DisableCaller omits the file and line of the logging call site from
+	// the entries. Loggers created while it is set don't compute it at all.
+	DisableCaller bool
+
+	// DisableTimestamp omits the time from the entries written to the
+	// outputs, e.g. when a supervisor like systemd's journal timestamps
+	// them already. Files and rule sinks keep it.
+	DisableTimestamp bool
+
+	// GoroutineID adds the ID of the logging goroutine to the entries
+	// written to the outputs, under GoroutineKey, to untangle the entries
+	// of concurrent operations. Getting it takes a few microseconds per
+	// entry.
+	GoroutineID bool
+
+	// Clock, if set, timestamps the entries, events included, instead of
+	// the system clock, e.g. for stable timestamps in tests and examples.
+	// See also SetClock.
+	Clock zapcore.Clock
+
+	// Uptime adds the time elapsed since the process started, in
+	// milliseconds, to the entries written to the outputs, under UptimeKey.
+	// Unlike timestamps, it is measured with the monotonic clock, so clock
+	// adjustments and Clock don't skew the durations derived from it.
+	Uptime bool
+
+	// SequenceNumbers numbers the entries written to each output, under
+	// SeqKey, starting from 1 when the process starts, so that collectors
+	// can detect lost entries, e.g. with AsyncDrop. Each output, like the
+	// Files and the sinks of Rules, has its own sequence, counting only the
+	// entries it writes. Entries logged concurrently may be written
+	// slightly out of order; sort by SeqKey to restore it.
+	SequenceNumbers bool
+
+	// CallerFormat is the format of the call site, CallerShort by default.
+	CallerFormat CallerFormat
+
+	// SubsystemColors renders each subsystem name in its own stable color
+	// with the ColorizedOutput and PrettyOutput formats.
+	SubsystemColors bool
+
+	// SubsystemLabels are key-values added to the entries of specific
+	// subsystems, e.g. {"dht": {"component": "routing"}}, as regular fields.
+	SubsystemLabels map[string]map[string]string
+
+	// Redact lists patterns of field keys, matched case-insensitively with
+	// path.Match (e.g. "password", "*token*"), whose values are replaced
+	// with "[REDACTED]" before reaching any output, including PipeReaders
+	// and hooks. Keys nested in objects, maps and structs are redacted
+	// too; the latter two are then logged in their JSON form.
+	Redact []string
+
+	// AnonymizeIPs truncates the IP addresses appearing in string fields,
+	// including within multiaddrs and host:port pairs, to their /24 (IPv4)
+	// or /48 (IPv6) network, so that logs can be shared without identifying
+	// peers. Strings within arrays (e.g. zap.Strings or zap.Stringers
+	// fields) are anonymized too, but not those within objects or fields
+	// logged with reflection (e.g. zap.Any of a []multiaddr.Multiaddr),
+	// which should be logged as strings or arrays of strings instead.
+	AnonymizeIPs bool
+
+	// FieldFilters enable the entries carrying specific field values below
+	// the level of their subsystem. See FieldFilter.
+	FieldFilters []FieldFilter
+
+	// Rules are evaluated in order against the entries reaching the
+	// outputs, to drop them, route them elsewhere, change their level or
+	// label them. See Rule.
+	Rules []Rule
+
+	// AuditFile is a path to the file the entries of audit loggers are
+	// appended to. See AuditLogger.
+	AuditFile string
+
+	// EventFile is a path to the file structured events are appended to.
+	// See ZapEventLogger.Event.
+	EventFile string
+
+	// MultilineFields renders string fields that span several lines (stack
+	// traces, YAML dumps, ...) as indented blocks below the entry instead of
+	// a single escaped line. Only applies to console formats.
+	MultilineFields bool
 }
 
 // ErrNoSuchLogger is returned when the util pkg is asked for a non existant logger
 var ErrNoSuchLogger = errors.New("error: No such logger")
 
-var loggerMutex sync.RWMutex // guards access to global logger state
-
-// loggers is the set of loggers in the system
-var loggers = make(map[string]*zap.SugaredLogger)
-var levels = make(map[string]zap.AtomicLevel)
-
-// primaryFormat is the format of the primary core used for logging
-var primaryFormat LogFormat = ColorizedOutput
+// ErrConfigurationLocked is returned when the logging configuration is
+// changed after LockConfiguration was called.
+var ErrConfigurationLocked = errors.New("logging configuration is locked")
 
-// defaultLevel is the default log level
-var defaultLevel LogLevel = LevelError
+// defaultRegistry backs the package-level API.
+var defaultRegistry = newRegistry()
 
-// primaryCore is the primary logging core
-var primaryCore zapcore.Core
-
-// loggerCore is the base for all loggers created by this package
-var loggerCore = &lockedMultiCore{}
+// DefaultRegistry returns the registry used by the package-level functions.
+func DefaultRegistry() *Registry {
+	return defaultRegistry
+}
 
 // GetConfig returns a copy of the saved config. It can be inspected, modified,
 // and re-applied using a subsequent call to SetupLogging().
 func GetConfig() Config {
-	return config
+	return defaultRegistry.GetConfig()
 }
 
 // SetupLogging will initialize the logger backend and set the flags.
@@ -105,74 +454,31 @@ func GetConfig() Config {
 // - move it out of `init`? then we need to change all the code (js-ipfs, go-ipfs) to call this explicitly
 // - have it look for a config file? need to define what that is
 func SetupLogging(cfg Config) {
-	loggerMutex.Lock()
-	defer loggerMutex.Unlock()
-
-	config = cfg
-
-	primaryFormat = cfg.Format
-	defaultLevel = cfg.Level
-
-	outputPaths := []string{}
-
-	if cfg.Stderr {
-		outputPaths = append(outputPaths, "stderr")
-	}
-	if cfg.Stdout {
-		outputPaths = append(outputPaths, "stdout")
-	}
-
-	// check if we log to a file
-	if len(cfg.File) > 0 {
-		if path, err := normalizePath(cfg.File); err != nil {
-			fmt.Fprintf(os.Stderr, "failed to resolve log path '%q', logging to %s\n", cfg.File, outputPaths)
-		} else {
-			outputPaths = append(outputPaths, path)
-		}
-	}
-	if len(cfg.URL) > 0 {
-		outputPaths = append(outputPaths, cfg.URL)
-	}
-
-	ws, _, err := zap.Open(outputPaths...)
-	if err != nil {
-		panic(fmt.Sprintf("unable to open logging output: %v", err))
-	}
-
-	newPrimaryCore := newCore(primaryFormat, ws, LevelDebug) // the main core needs to log everything.
-
-	for k, v := range cfg.Labels {
-		newPrimaryCore = newPrimaryCore.With([]zap.Field{zap.String(k, v)})
-	}
-
-	setPrimaryCore(newPrimaryCore)
-	setAllLoggers(defaultLevel)
+	defaultRegistry.SetupLogging(cfg)
+	checkEnvSubsystems()
+}
 
-	for name, level := range cfg.SubsystemLevels {
-		if leveler, ok := levels[name]; ok {
-			leveler.SetLevel(zapcore.Level(level))
-		} else {
-			levels[name] = zap.NewAtomicLevelAt(zapcore.Level(level))
-		}
-	}
+// SetupLoggingWithError is like SetupLogging, but fails instead of carrying
+// on with a partial configuration: if an output can't be opened or the
+// configuration is otherwise invalid, an error is returned and the previous
+// configuration is left in place.
+func SetupLoggingWithError(cfg Config) error {
+	defer checkEnvSubsystems()
+	return defaultRegistry.SetupLoggingWithError(cfg)
 }
 
 // SetPrimaryCore changes the primary logging core. If the SetupLogging was
 // called then the previously configured core will be replaced.
 func SetPrimaryCore(core zapcore.Core) {
-	loggerMutex.Lock()
-	defer loggerMutex.Unlock()
-
-	setPrimaryCore(core)
+	defaultRegistry.SetPrimaryCore(core)
 }
 
-func setPrimaryCore(core zapcore.Core) {
-	if primaryCore != nil {
-		loggerCore.ReplaceCore(primaryCore, core)
-	} else {
-		loggerCore.AddCore(core)
-	}
-	primaryCore = core
+// LockConfiguration prevents any further calls to SetupLogging or
+// SetPrimaryCore from taking effect; they are ignored and logged as an error
+// instead. This allows an application to configure logging and then stop
+// dependencies initialized later from silently redirecting its logs.
+func LockConfiguration() {
+	defaultRegistry.LockConfiguration()
 }
 
 // SetDebugLogging calls SetAllLoggers with logging.DEBUG
@@ -182,146 +488,105 @@ func SetDebugLogging() {
 
 // SetAllLoggers changes the logging level of all loggers to lvl
 func SetAllLoggers(lvl LogLevel) {
-	loggerMutex.RLock()
-	defer loggerMutex.RUnlock()
-
-	setAllLoggers(lvl)
-}
-
-func setAllLoggers(lvl LogLevel) {
-	for _, l := range levels {
-		l.SetLevel(zapcore.Level(lvl))
-	}
+	defaultRegistry.SetAllLoggers(lvl)
 }
 
 // SetLogLevel changes the log level of a specific subsystem
 // name=="*" changes all subsystems
 func SetLogLevel(name, level string) error {
-	lvl, err := LevelFromString(level)
-	if err != nil {
-		return err
-	}
-
-	// wildcard, change all
-	if name == "*" {
-		SetAllLoggers(lvl)
-		return nil
-	}
-
-	loggerMutex.RLock()
-	defer loggerMutex.RUnlock()
-
-	// Check if we have a logger by that name
-	if _, ok := levels[name]; !ok {
-		return ErrNoSuchLogger
-	}
-
-	levels[name].SetLevel(zapcore.Level(lvl))
-
-	return nil
+	return defaultRegistry.SetLogLevel(name, level)
 }
 
 // SetLogLevelRegex sets all loggers to level `l` that match expression `e`.
 // An error is returned if `e` fails to compile.
 func SetLogLevelRegex(e, l string) error {
-	lvl, err := LevelFromString(l)
-	if err != nil {
-		return err
-	}
+	return defaultRegistry.SetLogLevelRegex(e, l)
+}
 
-	rem, err := regexp.Compile(e)
-	if err != nil {
-		return err
-	}
+// DefaultLevel returns the default minimum enabled logging level, as
+// configured by the last call to SetupLogging.
+func DefaultLevel() LogLevel {
+	return defaultRegistry.DefaultLevel()
+}
 
-	loggerMutex.Lock()
-	defer loggerMutex.Unlock()
-	for name := range loggers {
-		if rem.MatchString(name) {
-			levels[name].SetLevel(zapcore.Level(lvl))
-		}
-	}
-	return nil
+// SubsystemLevel returns the current minimum enabled logging level of the
+// named subsystem. The boolean is false if no level is known for name.
+func SubsystemLevel(name string) (LogLevel, bool) {
+	return defaultRegistry.SubsystemLevel(name)
+}
+
+// SubsystemLevelEnabler returns a zapcore.LevelEnabler backed by the named
+// subsystem's level. Unlike SubsystemLevel, the result tracks subsequent
+// calls to SetLogLevel and friends, so it can be held onto and consulted
+// cheaply whenever a decision depends on the current level.
+func SubsystemLevelEnabler(name string) (zapcore.LevelEnabler, bool) {
+	return defaultRegistry.SubsystemLevelEnabler(name)
 }
 
 // GetSubsystems returns a slice containing the
 // names of the current loggers
 func GetSubsystems() []string {
-	loggerMutex.RLock()
-	defer loggerMutex.RUnlock()
-	subs := make([]string, 0, len(loggers))
-
-	for k := range loggers {
-		subs = append(subs, k)
-	}
-	return subs
+	return defaultRegistry.GetSubsystems()
 }
 
 func getLogger(name string) *zap.SugaredLogger {
-	loggerMutex.Lock()
-	defer loggerMutex.Unlock()
-	log, ok := loggers[name]
-	if !ok {
-		level, ok := levels[name]
-		if !ok {
-			level = zap.NewAtomicLevelAt(zapcore.Level(defaultLevel))
-			levels[name] = level
-		}
-		log = zap.New(loggerCore).
-			WithOptions(
-				zap.IncreaseLevel(level),
-				zap.AddCaller(),
-			).
-			Named(name).
-			Sugar()
+	return defaultRegistry.getLogger(name)
+}
 
-		loggers[name] = log
+// configFromEnv returns a Config with defaults populated using environment
+// variables, printing any problems with them to stderr.
+func configFromEnv() Config {
+	cfg, err := ConfigFromEnv()
+	for _, err := range multierr.Errors(err) {
+		fmt.Fprintln(os.Stderr, err)
 	}
-
-	return log
+	return cfg
 }
 
-// configFromEnv returns a Config with defaults populated using environment variables.
-func configFromEnv() Config {
+// ConfigFromEnv returns a Config with defaults populated using environment
+// variables, as used to configure logging on startup.
+//
+// Invalid settings are skipped and reported in the returned error, which may
+// combine several problems (see go.uber.org/multierr). The returned Config
+// is usable either way.
+func ConfigFromEnv() (Config, error) {
+	var errs error
 	cfg := Config{
 		Format:          ColorizedOutput,
 		Stderr:          true,
 		Level:           LevelError,
 		SubsystemLevels: map[string]LogLevel{},
 		Labels:          map[string]string{},
+
+		SubsystemVerbosity: map[string]int{},
 	}
 
-	format := os.Getenv(envLoggingFmt)
+	format := getenv(envLoggingFmt)
 	if format == "" {
-		format = os.Getenv(envIPFSLoggingFmt)
+		format = getenv(envIPFSLoggingFmt)
 	}
 
 	var noExplicitFormat bool
 
-	switch format {
-	case "color":
-		cfg.Format = ColorizedOutput
-	case "nocolor":
-		cfg.Format = PlaintextOutput
-	case "json":
-		cfg.Format = JSONOutput
-	default:
+	if f, err := parseLogFormat(format); err == nil {
+		cfg.Format = f
+	} else {
 		if format != "" {
-			fmt.Fprintf(os.Stderr, "ignoring unrecognized log format '%s'\n", format)
+			errs = multierr.Append(errs, fmt.Errorf("ignoring unrecognized log format '%s'", format))
 		}
 		noExplicitFormat = true
 	}
 
-	lvl := os.Getenv(envLogging)
+	lvl := getenv(envLogging)
 	if lvl == "" {
-		lvl = os.Getenv(envIPFSLogging)
+		lvl = getenv(envIPFSLogging)
 	}
 	if lvl != "" {
 		for _, kvs := range strings.Split(lvl, ",") {
 			kv := strings.SplitN(kvs, "=", 2)
 			lvl, err := LevelFromString(kv[len(kv)-1])
 			if err != nil {
-				fmt.Fprintf(os.Stderr, "error setting log level %q: %s\n", kvs, err)
+				errs = multierr.Append(errs, fmt.Errorf("error setting log level %q: %w", kvs, err))
 				continue
 			}
 			switch len(kv) {
@@ -333,15 +598,195 @@ func configFromEnv() Config {
 		}
 	}
 
-	cfg.File = os.Getenv(envLoggingFile)
+	if level := getenv(envStacktraceLevel); level != "" {
+		if lvl, err := LevelFromString(level); err == nil {
+			cfg.StacktraceLevel = &lvl
+		} else {
+			errs = multierr.Append(errs, fmt.Errorf("error setting stacktrace level %q: %w", level, err))
+		}
+	}
+
+	if redact := getenv(envRedact); redact != "" {
+		cfg.Redact = strings.Split(redact, ",")
+	}
+
+	if filters, err := fieldFiltersFromEnv(); err == nil {
+		cfg.FieldFilters = filters
+	} else {
+		errs = multierr.Append(errs, fmt.Errorf("ignoring %s: %w", envName(envFieldFilters), err))
+	}
+
+	if anonymize := getenv(envAnonymizeIPs); anonymize != "" {
+		if on, err := strconv.ParseBool(anonymize); err == nil {
+			cfg.AnonymizeIPs = on
+		} else {
+			errs = multierr.Append(errs, fmt.Errorf("ignoring invalid %s value %q", envName(envAnonymizeIPs), anonymize))
+		}
+	}
+
+	if caller := getenv(envCaller); caller != "" {
+		if on, err := strconv.ParseBool(caller); err == nil {
+			cfg.DisableCaller = !on
+		} else {
+			switch caller {
+			case "short":
+				cfg.CallerFormat = CallerShort
+			case "full":
+				cfg.CallerFormat = CallerFull
+			case "function":
+				cfg.CallerFormat = CallerFunction
+			default:
+				errs = multierr.Append(errs, fmt.Errorf("ignoring unrecognized caller format %q", caller))
+			}
+		}
+	}
+
+	if colors := getenv(envSubsystemColors); colors != "" {
+		if on, err := strconv.ParseBool(colors); err == nil {
+			cfg.SubsystemColors = on
+		} else {
+			errs = multierr.Append(errs, fmt.Errorf("ignoring invalid %s value %q", envName(envSubsystemColors), colors))
+		}
+	}
+
+	if goroutine := getenv(envGoroutineID); goroutine != "" {
+		if on, err := strconv.ParseBool(goroutine); err == nil {
+			cfg.GoroutineID = on
+		} else {
+			errs = multierr.Append(errs, fmt.Errorf("ignoring invalid %s value %q", envName(envGoroutineID), goroutine))
+		}
+	}
+
+	if uptime := getenv(envUptime); uptime != "" {
+		if on, err := strconv.ParseBool(uptime); err == nil {
+			cfg.Uptime = on
+		} else {
+			errs = multierr.Append(errs, fmt.Errorf("ignoring invalid %s value %q", envName(envUptime), uptime))
+		}
+	}
+	if seq := getenv(envSeq); seq != "" {
+		if on, err := strconv.ParseBool(seq); err == nil {
+			cfg.SequenceNumbers = on
+		} else {
+			errs = multierr.Append(errs, fmt.Errorf("ignoring invalid %s value %q", envName(envSeq), seq))
+		}
+	}
+
+	if verbosity := getenv(envVerbosity); verbosity != "" {
+		for _, kvs := range strings.Split(verbosity, ",") {
+			kv := strings.SplitN(kvs, "=", 2)
+			v, err := strconv.Atoi(kv[len(kv)-1])
+			if err != nil || v < 0 {
+				errs = multierr.Append(errs, fmt.Errorf("ignoring invalid verbosity %q", kvs))
+				continue
+			}
+			switch len(kv) {
+			case 1:
+				cfg.Verbosity = v
+			case 2:
+				cfg.SubsystemVerbosity[kv[0]] = v
+			}
+		}
+	}
+
+	cfg.File = getenv(envLoggingFile)
+	if retention := getenv(envFileRetention); retention != "" {
+		if d, err := time.ParseDuration(retention); err == nil && d >= 0 {
+			cfg.FileRetention = d
+		} else {
+			errs = multierr.Append(errs, fmt.Errorf("ignoring invalid %s value %q", envName(envFileRetention), retention))
+		}
+	}
+	if compression, err := parseCompression(getenv(envFileCompression)); err == nil {
+		cfg.FileCompression = compression
+	} else {
+		errs = multierr.Append(errs, fmt.Errorf("ignoring %s: %w", envName(envFileCompression), err))
+	}
+	if mode := getenv(envFileMode); mode != "" {
+		if m, err := strconv.ParseUint(mode, 8, 32); err == nil && m <= 0o777 {
+			cfg.FileMode = os.FileMode(m)
+		} else {
+			errs = multierr.Append(errs, fmt.Errorf("ignoring invalid %s value %q", envName(envFileMode), mode))
+		}
+	}
+	if create := getenv(envFileCreateDirs); create != "" {
+		if on, err := strconv.ParseBool(create); err == nil {
+			cfg.FileCreateDirs = on
+		} else {
+			errs = multierr.Append(errs, fmt.Errorf("ignoring invalid %s value %q", envName(envFileCreateDirs), create))
+		}
+	}
+	if size := getenv(envFileMaxTotalSize); size != "" {
+		if n, err := parseSize(size); err == nil {
+			cfg.FileMaxTotalSize = n
+		} else {
+			errs = multierr.Append(errs, fmt.Errorf("ignoring invalid %s value %q", envName(envFileMaxTotalSize), size))
+		}
+	}
+	if size := getenv(envFileMinFreeSpace); size != "" {
+		if n, err := parseSize(size); err == nil {
+			cfg.FileMinFreeSpace = n
+		} else {
+			errs = multierr.Append(errs, fmt.Errorf("ignoring invalid %s value %q", envName(envFileMinFreeSpace), size))
+		}
+	}
 	// Disable stderr logging when a file is specified
 	// https://github.com/ipfs/go-log/issues/83
 	if cfg.File != "" {
 		cfg.Stderr = false
 	}
 
-	cfg.URL = os.Getenv(envLoggingURL)
-	output := os.Getenv(envLoggingOutput)
+	if key, err := fileKeyFromEnv(); err != nil {
+		errs = multierr.Append(errs, err)
+	} else {
+		cfg.FileKey = key
+	}
+
+	if size := getenv(envAsync); size != "" {
+		if n, err := strconv.Atoi(size); err == nil && n >= 0 {
+			cfg.AsyncQueueSize = n
+		} else {
+			errs = multierr.Append(errs, fmt.Errorf("ignoring invalid %s value %q", envName(envAsync), size))
+		}
+	}
+	switch policy := getenv(envAsyncPolicy); policy {
+	case "", "block":
+		cfg.AsyncPolicy = AsyncBlock
+	case "drop":
+		cfg.AsyncPolicy = AsyncDrop
+	default:
+		errs = multierr.Append(errs, fmt.Errorf("ignoring unrecognized %s value %q", envName(envAsyncPolicy), policy))
+	}
+
+	if interval := getenv(envSyncInterval); interval != "" {
+		if d, err := time.ParseDuration(interval); err == nil && d >= 0 {
+			cfg.SyncInterval = d
+		} else {
+			errs = multierr.Append(errs, fmt.Errorf("ignoring invalid %s value %q", envName(envSyncInterval), interval))
+		}
+	}
+	if onError := getenv(envSyncOnError); onError != "" {
+		if on, err := strconv.ParseBool(onError); err == nil {
+			cfg.SyncOnError = on
+		} else {
+			errs = multierr.Append(errs, fmt.Errorf("ignoring invalid %s value %q", envName(envSyncOnError), onError))
+		}
+	}
+
+	if max := getenv(envMaxLoggers); max != "" {
+		if n, err := strconv.Atoi(max); err == nil && n >= 0 {
+			cfg.MaxLoggers = n
+		} else {
+			errs = multierr.Append(errs, fmt.Errorf("ignoring invalid %s value %q", envName(envMaxLoggers), max))
+		}
+	}
+
+	cfg.URL = getenv(envLoggingURL)
+	cfg.Fallback = getenv(envFallback)
+	cfg.Profile = getenv(envLoggingProfile)
+	cfg.AuditFile = getenv(envAuditFile)
+	cfg.EventFile = getenv(envEventFile)
+	output := getenv(envLoggingOutput)
 	outputOptions := strings.Split(output, "+")
 	for _, opt := range outputOptions {
 		switch opt {
@@ -351,15 +796,68 @@ func configFromEnv() Config {
 			cfg.Stderr = true
 		case "file":
 			if cfg.File == "" {
-				fmt.Fprint(os.Stderr, "please specify a GOLOG_FILE value to write to")
+				errs = multierr.Append(errs, fmt.Errorf("please specify a %s value to write to", envName(envLoggingFile)))
 			}
 		case "url":
 			if cfg.URL == "" {
-				fmt.Fprint(os.Stderr, "please specify a GOLOG_URL value to write to")
+				errs = multierr.Append(errs, fmt.Errorf("please specify a %s value to write to", envName(envLoggingURL)))
 			}
+		case "":
+		default:
+			errs = multierr.Append(errs, fmt.Errorf("ignoring unrecognized log output '%s'", opt))
+		}
+	}
+
+	if noExplicitFormat && !colorsForced() && inGitHubActions() {
+		cfg.Format = GitHubActionsOutput
+		noExplicitFormat = false
+	}
+
+	// In containers, the runtime collects stdout and log pipelines expect
+	// JSON, unless someone is watching, e.g. with docker run -it.
+	container := getenv(envContainer)
+	switch container {
+	case "", "auto":
+		container = strconv.FormatBool(inContainer() && !isTerm(os.Stderr))
+	}
+	if on, err := strconv.ParseBool(container); err != nil {
+		errs = multierr.Append(errs, fmt.Errorf("ignoring invalid %s value %q", envName(envContainer), container))
+	} else if on {
+		if noExplicitFormat && !colorsForced() {
+			cfg.Format = JSONOutput
+			noExplicitFormat = false
+		}
+		if output == "" && cfg.File == "" && cfg.URL == "" {
+			cfg.Stderr = false
+			cfg.Stdout = true
 		}
 	}
 
+	switch timestamp := getenv(envTimestamp); timestamp {
+	case "", "true":
+	case "false":
+		cfg.DisableTimestamp = true
+	case "auto":
+		// Files and URLs need their own timestamps.
+		cfg.DisableTimestamp = cfg.File == "" && cfg.URL == "" &&
+			(cfg.Stderr || cfg.Stdout) &&
+			(!cfg.Stderr || underJournal(os.Stderr)) &&
+			(!cfg.Stdout || underJournal(os.Stdout))
+	default:
+		errs = multierr.Append(errs, fmt.Errorf("ignoring unrecognized %s value %q", envName(envTimestamp), timestamp))
+	}
+
+	// Without an explicit format, follow the NO_COLOR and CLICOLOR
+	// conventions (https://no-color.org, https://bixense.com/clicolors) and
+	// only use colors for terminals.
+	if noExplicitFormat && colorsForced() {
+		noExplicitFormat = false
+	}
+	if noExplicitFormat && colorsDisabled() {
+		cfg.Format = PlaintextOutput
+		noExplicitFormat = false
+	}
+
 	// Check that neither of the requested Std* nor the file are TTYs
 	// At this stage (configFromEnv) we do not have a uniform list to examine yet
 	if noExplicitFormat &&
@@ -370,20 +868,54 @@ func configFromEnv() Config {
 		cfg.Format = PlaintextOutput
 	}
 
-	labels := os.Getenv(envLoggingLabels)
+	labels := getenv(envLoggingLabels)
 	if labels != "" {
 		labelKVs := strings.Split(labels, ",")
+		var auto []string
+		inAuto := false
 		for _, label := range labelKVs {
+			// "auto:" starts a list of built-in labels, up to the next
+			// key-value pair.
+			if name := strings.TrimPrefix(label, "auto:"); name != label {
+				inAuto = true
+				label = name
+			}
+			if inAuto && !strings.Contains(label, "=") {
+				auto = append(auto, label)
+				continue
+			}
+			inAuto = false
 			kv := strings.Split(label, "=")
 			if len(kv) != 2 {
-				fmt.Fprint(os.Stderr, "invalid label k=v: ", label)
+				errs = multierr.Append(errs, fmt.Errorf("invalid label k=v: %s", label))
 				continue
 			}
 			cfg.Labels[kv[0]] = kv[1]
 		}
+		if len(auto) > 0 {
+			builtin, err := AutoLabels(auto...)
+			errs = multierr.Append(errs, err)
+			for k, v := range builtin {
+				if _, ok := cfg.Labels[k]; !ok {
+					cfg.Labels[k] = v
+				}
+			}
+		}
 	}
 
-	return cfg
+	return cfg, errs
+}
+
+// colorsDisabled reports whether NO_COLOR or CLICOLOR=0 ask for no colors.
+func colorsDisabled() bool {
+	return os.Getenv("NO_COLOR") != "" || os.Getenv("CLICOLOR") == "0"
+}
+
+// colorsForced reports whether CLICOLOR_FORCE asks for colors even when not
+// writing to a terminal. NO_COLOR takes precedence.
+func colorsForced() bool {
+	force := os.Getenv("CLICOLOR_FORCE")
+	return force != "" && force != "0" && os.Getenv("NO_COLOR") == ""
 }
 
 func isTerm(f *os.File) bool {
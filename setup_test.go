@@ -4,9 +4,11 @@ import (
 	"bytes"
 	"io"
 	"os"
+	"path/filepath"
 	"strings"
 	"testing"
 
+	"go.uber.org/multierr"
 	"go.uber.org/zap"
 	"go.uber.org/zap/zapcore"
 )
@@ -155,7 +157,7 @@ func TestLogLabels(t *testing.T) {
 	}
 
 	t.Log(buf.String())
-	if !strings.Contains(buf.String(), "{\"dc\": \"sjc-1\"}") {
+	if !strings.HasSuffix(buf.String(), "\tdc=sjc-1\n") {
 		t.Errorf("got %q, wanted it to contain log output", buf.String())
 	}
 }
@@ -257,3 +259,105 @@ func TestTeeCore(t *testing.T) {
 	SetPrimaryCore(zap.NewNop().Core())
 	log.Error("doo")
 }
+
+func TestSubsystemLevel(t *testing.T) {
+	SetupLogging(Config{Level: LevelWarn})
+	getLogger("test-level")
+
+	if lvl := DefaultLevel(); lvl != LevelWarn {
+		t.Errorf("got default level %v, want %v", lvl, LevelWarn)
+	}
+
+	lvl, ok := SubsystemLevel("test-level")
+	if !ok || lvl != LevelWarn {
+		t.Errorf("got level %v (%v), want %v", lvl, ok, LevelWarn)
+	}
+	if _, ok := SubsystemLevel("test-level-missing"); ok {
+		t.Error("expected missing subsystem to not be found")
+	}
+
+	enabler, ok := SubsystemLevelEnabler("test-level")
+	if !ok {
+		t.Fatal("expected enabler for subsystem")
+	}
+	if enabler.Enabled(zapcore.DebugLevel) {
+		t.Error("debug should not be enabled")
+	}
+	if err := SetLogLevel("test-level", "debug"); err != nil {
+		t.Fatal(err)
+	}
+	if !enabler.Enabled(zapcore.DebugLevel) {
+		t.Error("enabler should track level changes")
+	}
+	if lvl, _ := SubsystemLevel("test-level"); lvl != LevelDebug {
+		t.Errorf("got level %v, want %v", lvl, LevelDebug)
+	}
+}
+
+func TestSetupLoggingWithError(t *testing.T) {
+	reg := NewRegistry(Config{Level: LevelInfo})
+
+	err := reg.SetupLoggingWithError(Config{
+		Level: LevelDebug,
+		File:  filepath.Join(t.TempDir(), "missing", "go-log-test"),
+	})
+	if err == nil {
+		t.Fatal("expected an error for an unreachable file")
+	}
+	if lvl := reg.DefaultLevel(); lvl != LevelInfo {
+		t.Errorf("got default level %v, wanted the previous configuration to be kept", lvl)
+	}
+
+	if err := reg.SetupLoggingWithError(Config{Format: LogFormat(42)}); err == nil {
+		t.Error("expected an error for an invalid format")
+	}
+
+	logfile := filepath.Join(t.TempDir(), "go-log-test")
+	if err := reg.SetupLoggingWithError(Config{Level: LevelDebug, File: logfile}); err != nil {
+		t.Fatal(err)
+	}
+	if lvl := reg.DefaultLevel(); lvl != LevelDebug {
+		t.Errorf("got default level %v, want %v", lvl, LevelDebug)
+	}
+}
+
+func TestConfigFromEnvErrors(t *testing.T) {
+	t.Setenv(envLogging, "info,test1=loud")
+	t.Setenv(envLoggingFmt, "fancy")
+	t.Setenv(envLoggingOutput, "stderr+carrier-pigeon")
+
+	cfg, err := ConfigFromEnv()
+	if n := len(multierr.Errors(err)); n != 3 {
+		t.Errorf("got %d errors (%v), want 3", n, err)
+	}
+	if cfg.Level != LevelInfo {
+		t.Errorf("got level %v, want valid settings to still apply", cfg.Level)
+	}
+
+	t.Setenv(envLogging, "info,test1=debug")
+	t.Setenv(envLoggingFmt, "json")
+	t.Setenv(envLoggingOutput, "stderr")
+	if _, err := ConfigFromEnv(); err != nil {
+		t.Errorf("unexpected error: %v", err)
+	}
+}
+
+func TestConfigFromEnvColors(t *testing.T) {
+	t.Setenv(envLoggingFmt, "")
+	t.Setenv(envIPFSLoggingFmt, "")
+
+	t.Setenv("CLICOLOR_FORCE", "1")
+	if cfg, _ := ConfigFromEnv(); cfg.Format != ColorizedOutput {
+		t.Errorf("got format %v with CLICOLOR_FORCE, want color", cfg.Format)
+	}
+
+	t.Setenv("NO_COLOR", "1")
+	if cfg, _ := ConfigFromEnv(); cfg.Format != PlaintextOutput {
+		t.Errorf("got format %v with NO_COLOR, want nocolor", cfg.Format)
+	}
+
+	t.Setenv(envLoggingFmt, "color")
+	if cfg, _ := ConfigFromEnv(); cfg.Format != ColorizedOutput {
+		t.Errorf("got format %v, want the explicit format to win", cfg.Format)
+	}
+}
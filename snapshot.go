@@ -0,0 +1,76 @@
+package log
+
+// Snapshot describes the state of a registry, as returned by GetSnapshot,
+// for debugging tools to inspect.
+type Snapshot struct {
+	// Format is the name of the format of the outputs, as accepted by
+	// GOLOG_LOG_FMT.
+	Format string `json:"format"`
+
+	// DefaultLevel is the level of the subsystems without a level of their
+	// own.
+	DefaultLevel LogLevel `json:"default_level"`
+
+	// Subsystems are the effective levels of the known subsystems.
+	Subsystems map[string]LogLevel `json:"subsystems"`
+
+	// Outputs are the outputs configured with SetupLogging: "stderr",
+	// "stdout", the paths of the log files or the URL of the sink. It is
+	// "custom" if the primary core was set with SetPrimaryCore.
+	Outputs []string `json:"outputs"`
+
+	// PipeReaders is the number of open PipeReaders and EntryReaders.
+	PipeReaders int `json:"pipe_readers"`
+
+	// Metrics are the entry, drop and error counters.
+	Metrics Metrics `json:"metrics"`
+}
+
+// GetSnapshot returns a snapshot of the state of the logging system.
+func GetSnapshot() Snapshot {
+	return defaultRegistry.GetSnapshot()
+}
+
+// GetSnapshot returns a snapshot of the state of this registry.
+func (r *Registry) GetSnapshot() Snapshot {
+	r.mu.RLock()
+	s := Snapshot{
+		Format:       r.primaryFormat.String(),
+		DefaultLevel: r.defaultLevel,
+		Subsystems:   make(map[string]LogLevel, len(r.levels)),
+		PipeReaders:  len(r.pipes),
+	}
+	for name, level := range r.levels {
+		s.Subsystems[name] = LogLevel(level.Level())
+	}
+	if r.outputs != nil {
+		s.Outputs = configuredOutputs(r.config)
+	} else {
+		s.Outputs = []string{"custom"}
+	}
+	r.mu.RUnlock()
+
+	s.Metrics = r.metrics.snapshot()
+	return s
+}
+
+// configuredOutputs names the outputs configured in cfg.
+func configuredOutputs(cfg Config) []string {
+	var outputs []string
+	if cfg.Stderr {
+		outputs = append(outputs, "stderr")
+	}
+	if cfg.Stdout {
+		outputs = append(outputs, "stdout")
+	}
+	if cfg.File != "" {
+		outputs = append(outputs, cfg.File)
+	}
+	if cfg.URL != "" {
+		outputs = append(outputs, cfg.URL)
+	}
+	for _, f := range cfg.Files {
+		outputs = append(outputs, f.Path)
+	}
+	return outputs
+}
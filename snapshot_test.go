@@ -0,0 +1,36 @@
+package log
+
+import (
+	"encoding/json"
+	"testing"
+)
+
+func TestGetSnapshot(t *testing.T) {
+	reg := NewRegistry(Config{Format: JSONOutput, Level: LevelError, Stderr: true})
+	reg.Logger("dht")
+	if err := reg.SetLogLevel("dht", "debug"); err != nil {
+		t.Fatal(err)
+	}
+	pipe := reg.NewPipeReader()
+	defer pipe.Close()
+
+	s := reg.GetSnapshot()
+	if s.Format != "json" || s.DefaultLevel != LevelError || s.Subsystems["dht"] != LevelDebug || s.PipeReaders != 1 {
+		t.Errorf("got snapshot %+v", s)
+	}
+	if len(s.Outputs) != 1 || s.Outputs[0] != "stderr" {
+		t.Errorf("got outputs %v", s.Outputs)
+	}
+
+	b, err := json.Marshal(s)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var decoded Snapshot
+	if err := json.Unmarshal(b, &decoded); err != nil {
+		t.Fatal(err)
+	}
+	if decoded.Subsystems["dht"] != LevelDebug {
+		t.Errorf("got %s, want levels to round-trip through JSON", b)
+	}
+}
@@ -0,0 +1,41 @@
+package log
+
+import (
+	"strings"
+	"testing"
+
+	"go.uber.org/zap/zapcore"
+	"go.uber.org/zap/zaptest/observer"
+)
+
+func TestStacktraceLevel(t *testing.T) {
+	reg := NewRegistry(Config{Level: LevelInfo})
+	core, logs := observer.New(zapcore.DebugLevel)
+	reg.SetPrimaryCore(core)
+	log := reg.Logger("test")
+
+	log.Error("scooby")
+
+	lvl := LevelWarn
+	reg.SetupLogging(Config{Level: LevelInfo, StacktraceLevel: &lvl})
+	reg.SetPrimaryCore(core)
+	log.Info("doo")
+	log.Warn("velma")
+
+	entries := logs.All()
+	if len(entries) != 3 {
+		t.Fatalf("got %d entries, want 3", len(entries))
+	}
+	if entries[0].Stack != "" || entries[1].Stack != "" {
+		t.Errorf("got a stack trace below the stacktrace level")
+	}
+	if !strings.Contains(entries[2].Stack, "TestStacktraceLevel") {
+		t.Errorf("got stack %q, want the test's", entries[2].Stack)
+	}
+
+	t.Setenv(envStacktraceLevel, "error")
+	cfg, err := ConfigFromEnv()
+	if err != nil || cfg.StacktraceLevel == nil || *cfg.StacktraceLevel != LevelError {
+		t.Errorf("got %v, %v, want the error level", cfg.StacktraceLevel, err)
+	}
+}
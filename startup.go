@@ -0,0 +1,170 @@
+package log
+
+import (
+	"os"
+	"sync"
+	"time"
+
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+)
+
+// defaultStartupBufferSize is the maximum number of entries held while
+// waiting for the application to configure logging.
+const defaultStartupBufferSize = 1024
+
+// startupBufferDeadline is how long entries are held back from the outputs
+// configured from the environment before they are written there anyway, in
+// case the application never configures logging itself.
+const startupBufferDeadline = 10 * time.Second
+
+// startupBuffer holds entries logged before logging was configured so they
+// can be replayed through the final primary core.
+type startupBuffer struct {
+	mu      sync.Mutex // guards the fields below
+	entries []bufferedEntry
+	max     int
+	dropped int
+
+	// flush writes the entries out. It is called on entries at DPanic
+	// level or above, which may be the last ones of the process.
+	flush func()
+}
+
+type bufferedEntry struct {
+	ent    zapcore.Entry
+	fields []zapcore.Field
+}
+
+func newStartupBuffer(max int) *startupBuffer {
+	return &startupBuffer{max: max}
+}
+
+func (b *startupBuffer) add(ent zapcore.Entry, fields []zapcore.Field) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	if len(b.entries) >= b.max {
+		b.dropped++
+		return
+	}
+	b.entries = append(b.entries, bufferedEntry{ent: ent, fields: fields})
+}
+
+// take returns and clears the buffered entries along with the number of
+// entries dropped because the buffer was full.
+func (b *startupBuffer) take() ([]bufferedEntry, int) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	entries, dropped := b.entries, b.dropped
+	b.entries, b.dropped = nil, 0
+	return entries, dropped
+}
+
+// bufferCore is a primary core that stores entries in a startupBuffer.
+type bufferCore struct {
+	buf    *startupBuffer
+	fields []zapcore.Field
+}
+
+var _ zapcore.Core = (*bufferCore)(nil)
+
+func (c *bufferCore) Enabled(zapcore.Level) bool {
+	// Loggers filter by level before reaching the primary core.
+	return true
+}
+
+func (c *bufferCore) With(fields []zapcore.Field) zapcore.Core {
+	return &bufferCore{
+		buf:    c.buf,
+		fields: append(append([]zapcore.Field(nil), c.fields...), fields...),
+	}
+}
+
+func (c *bufferCore) Check(ent zapcore.Entry, ce *zapcore.CheckedEntry) *zapcore.CheckedEntry {
+	return ce.AddCore(ent, c)
+}
+
+func (c *bufferCore) Write(ent zapcore.Entry, fields []zapcore.Field) error {
+	all := make([]zapcore.Field, 0, len(c.fields)+len(fields))
+	all = append(all, c.fields...)
+	all = append(all, fields...)
+	c.buf.add(ent, all)
+	if ent.Level >= zapcore.DPanicLevel {
+		c.buf.flush()
+	}
+	return nil
+}
+
+func (c *bufferCore) Sync() error {
+	return nil
+}
+
+// bufferStartup makes the registry hold entries in memory until logging is
+// configured with SetupLogging or SetPrimaryCore. Entries are written to the
+// primary core the registry has already, or stderr if none, from the first
+// one at DPanic level or above; with a primary core, also once deadline
+// passes if positive.
+func (r *Registry) bufferStartup(max int, deadline time.Duration) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	buf := newStartupBuffer(max)
+	out := r.primaryCore
+	if out == nil {
+		// Logging isn't configured at all: entries that may be the last
+		// ones of the process still go to stderr.
+		out = newCore(PlaintextOutput, zapcore.Lock(os.Stderr), LevelTrace)
+	} else if deadline > 0 {
+		time.AfterFunc(deadline, func() { r.flushStartup(buf, out) })
+	}
+	buf.flush = func() { r.flushStartup(buf, out) }
+	r.startup = buf
+	r.buffering.Store(buf)
+	r.setPrimaryCore(&bufferCore{buf: buf})
+}
+
+// flushStartup makes out the primary core again and replays the entries of
+// buf to it, unless logging was configured since buf was created.
+func (r *Registry) flushStartup(buf *startupBuffer, out zapcore.Core) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if r.startup != buf {
+		return
+	}
+	r.setPrimaryCore(out)
+	r.replayStartup()
+	_ = out.Sync()
+}
+
+// replayStartup writes the entries held since bufferStartup to the primary
+// core, honoring the levels in effect now. r.mu must be held.
+func (r *Registry) replayStartup() {
+	if r.startup == nil {
+		return
+	}
+	entries, dropped := r.startup.take()
+	r.startup = nil
+	r.buffering.Store(nil)
+
+	for _, e := range entries {
+		if level, ok := r.levels[e.ent.LoggerName]; ok && !level.Enabled(e.ent.Level) {
+			continue
+		}
+		if ce := r.primaryCore.Check(e.ent, nil); ce != nil {
+			ce.Write(e.fields...)
+		}
+	}
+
+	if dropped > 0 {
+		r.metrics.addDropped(dropped)
+		_ = r.primaryCore.Write(zapcore.Entry{
+			LoggerName: "setup-logger",
+			Level:      zapcore.WarnLevel,
+			Time:       r.clock.Now(),
+			Message:    "dropped entries logged before logging was configured",
+		}, []zapcore.Field{zap.Int("count", dropped)})
+	}
+}
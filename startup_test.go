@@ -0,0 +1,172 @@
+package log
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"go.uber.org/zap/zapcore"
+)
+
+func TestStartupBuffer(t *testing.T) {
+	reg := newRegistry()
+	reg.SetClock(fixedClock(scoobyTime))
+	reg.bufferStartup(2, 0)
+
+	log1 := reg.Logger("test1")
+	log2 := reg.Logger("test2")
+
+	log1.Error("scooby")
+	log2.Error("doo")
+	log2.Error("dropped")
+
+	buf := &bytes.Buffer{}
+	reg.SetPrimaryCore(newCore(PlaintextOutput, zapcore.AddSync(buf), LevelDebug))
+	if err := reg.SetLogLevel("test2", "fatal"); err != nil {
+		t.Fatal(err)
+	}
+	log2.Error("hidden")
+
+	out := buf.String()
+	if !strings.Contains(out, "scooby") || !strings.Contains(out, "doo") {
+		t.Errorf("got %q, wanted it to contain buffered entries", out)
+	}
+	if strings.Contains(out, "dropped\n") || strings.Contains(out, "hidden") {
+		t.Errorf("got %q, wanted it to not contain dropped entries", out)
+	}
+	if !strings.Contains(out, `{"count": 1}`) {
+		t.Errorf("got %q, wanted it to report one dropped entry", out)
+	}
+	for _, line := range strings.Split(strings.TrimSpace(out), "\n") {
+		if !strings.HasPrefix(line, "2010-05-23") {
+			t.Errorf("got %q, wanted it timestamped with the registry clock", line)
+		}
+	}
+}
+
+func TestStartupBufferLevels(t *testing.T) {
+	reg := newRegistry()
+	reg.bufferStartup(defaultStartupBufferSize, 0)
+
+	log1 := reg.Logger("test1")
+	log2 := reg.Logger("test2")
+
+	log1.Error("scooby")
+	log2.Error("doo")
+
+	logfile := filepath.Join(t.TempDir(), "go-log-test")
+	reg.SetupLogging(Config{
+		Format:          PlaintextOutput,
+		Level:           LevelError,
+		SubsystemLevels: map[string]LogLevel{"test2": LevelFatal},
+		File:            logfile,
+	})
+	log1.Error("velma")
+
+	content, err := os.ReadFile(logfile)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(string(content), "scooby") || !strings.Contains(string(content), "velma") {
+		t.Errorf("got %q, wanted it to contain log output", content)
+	}
+	if strings.Contains(string(content), "doo") {
+		t.Errorf("got %q, wanted entries of disabled subsystems to be dropped", content)
+	}
+}
+
+func TestStartupBufferBelowDefaultLevel(t *testing.T) {
+	reg := newRegistry()
+	reg.bufferStartup(defaultStartupBufferSize, 0)
+	log := reg.Logger("test")
+
+	// The default level is error until logging is configured.
+	log.Debug("hidden")
+	log.Info("scooby")
+
+	logfile := filepath.Join(t.TempDir(), "go-log-test")
+	reg.SetupLogging(Config{Format: PlaintextOutput, Level: LevelInfo, File: logfile})
+	content, err := os.ReadFile(logfile)
+	if err != nil {
+		t.Fatal(err)
+	}
+	out := string(content)
+	if !strings.Contains(out, "scooby") {
+		t.Errorf("got %q, wanted the info entry logged before configuration", out)
+	}
+	if strings.Contains(out, "hidden") {
+		t.Errorf("got %q, wanted entries below the configured level dropped", out)
+	}
+}
+
+func TestStartupBufferFlush(t *testing.T) {
+	reg := newRegistry()
+	buf := &bytes.Buffer{}
+	reg.SetPrimaryCore(newCore(PlaintextOutput, zapcore.AddSync(buf), LevelDebug))
+	reg.bufferStartup(defaultStartupBufferSize, 0)
+	log := reg.Logger("test")
+
+	log.Error("scooby")
+	if buf.Len() != 0 {
+		t.Fatalf("got %q, wanted entries held back", buf.String())
+	}
+	// Entries that may be the last ones of the process flush the buffer.
+	log.DPanic("doo")
+	out := buf.String()
+	if !strings.Contains(out, "scooby") || !strings.Contains(out, "doo") {
+		t.Errorf("got %q, wanted the buffered and urgent entries", out)
+	}
+	log.Error("velma")
+	if !strings.Contains(buf.String(), "velma") {
+		t.Errorf("got %q, wanted later entries written directly", buf.String())
+	}
+}
+
+func TestStartupBufferDeadline(t *testing.T) {
+	reg := newRegistry()
+	buf := &bytes.Buffer{}
+	ws := zapcore.Lock(zapcore.AddSync(buf))
+	reg.SetPrimaryCore(newCore(PlaintextOutput, ws, LevelDebug))
+	reg.bufferStartup(defaultStartupBufferSize, time.Millisecond)
+	reg.Logger("test").Error("scooby")
+
+	deadline := time.Now().Add(5 * time.Second)
+	for {
+		reg.mu.RLock()
+		flushed := reg.startup == nil
+		reg.mu.RUnlock()
+		if flushed {
+			break
+		}
+		if time.Now().After(deadline) {
+			t.Fatal("startup buffer was not flushed")
+		}
+		time.Sleep(time.Millisecond)
+	}
+	if !strings.Contains(buf.String(), "scooby") {
+		t.Errorf("got %q, wanted the buffered entry", buf.String())
+	}
+}
+
+func TestStartupBufferFromEnv(t *testing.T) {
+	for _, tc := range []struct {
+		env    string
+		size   int
+		buffer bool
+	}{
+		{"", defaultStartupBufferSize, false},
+		{"0", 0, false},
+		{"42", 42, true},
+		{"-1", defaultStartupBufferSize, false},
+		{"lots", defaultStartupBufferSize, false},
+	} {
+		t.Setenv(envStartupBuffer, tc.env)
+		size, buffer := startupBufferFromEnv()
+		if size != tc.size || buffer != tc.buffer {
+			t.Errorf("%q: got (%d, %v), want (%d, %v)", tc.env, size, buffer, tc.size, tc.buffer)
+		}
+	}
+}
@@ -0,0 +1,88 @@
+package log
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"sync/atomic"
+
+	"go.uber.org/multierr"
+	"go.uber.org/zap/zapcore"
+)
+
+var errNoOutputs = errors.New("no outputs were set up with SetupLogging")
+
+// SetStandardOutput enables or disables writing to "stderr" or "stdout",
+// leaving the other outputs and the levels untouched. It fails if the primary
+// core was set with SetPrimaryCore.
+func SetStandardOutput(name string, enabled bool) error {
+	return defaultRegistry.SetStandardOutput(name, enabled)
+}
+
+// SetStandardOutput toggles a standard output of this registry. See the
+// package-level SetStandardOutput.
+func (r *Registry) SetStandardOutput(name string, enabled bool) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if r.configLocked {
+		return ErrConfigurationLocked
+	}
+	if r.std == nil {
+		return errNoOutputs
+	}
+	switch name {
+	case "stderr":
+		r.std.stderr.Store(enabled)
+		r.config.Stderr = enabled
+	case "stdout":
+		r.std.stdout.Store(enabled)
+		r.config.Stdout = enabled
+	default:
+		return fmt.Errorf("unknown output %q, expected stderr or stdout", name)
+	}
+	return nil
+}
+
+// standardOutputs writes to stderr and stdout while they are enabled, so that
+// they can be toggled without reopening the other outputs.
+type standardOutputs struct {
+	stderr, stdout     atomic.Bool
+	stderrWS, stdoutWS zapcore.WriteSyncer
+}
+
+var _ zapcore.WriteSyncer = (*standardOutputs)(nil)
+
+func newStandardOutputs(cfg Config) *standardOutputs {
+	s := &standardOutputs{
+		stderrWS: zapcore.Lock(os.Stderr),
+		stdoutWS: zapcore.Lock(os.Stdout),
+	}
+	s.stderr.Store(cfg.Stderr)
+	s.stdout.Store(cfg.Stdout)
+	return s
+}
+
+func (s *standardOutputs) Write(p []byte) (int, error) {
+	var err error
+	if s.stderr.Load() {
+		_, werr := s.stderrWS.Write(p)
+		err = multierr.Append(err, werr)
+	}
+	if s.stdout.Load() {
+		_, werr := s.stdoutWS.Write(p)
+		err = multierr.Append(err, werr)
+	}
+	return len(p), err
+}
+
+func (s *standardOutputs) Sync() error {
+	var err error
+	if s.stderr.Load() {
+		err = multierr.Append(err, s.stderrWS.Sync())
+	}
+	if s.stdout.Load() {
+		err = multierr.Append(err, s.stdoutWS.Sync())
+	}
+	return err
+}
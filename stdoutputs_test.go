@@ -0,0 +1,63 @@
+package log
+
+import (
+	"bytes"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestSetStandardOutput(t *testing.T) {
+	r, w, err := os.Pipe()
+	if err != nil {
+		t.Fatalf("failed to open pipe: %v", err)
+	}
+	stderr := os.Stderr
+	os.Stderr = w
+	defer func() {
+		os.Stderr = stderr
+	}()
+
+	path := filepath.Join(t.TempDir(), "ipfs.log")
+	reg := NewRegistry(Config{Format: PlaintextOutput, Level: LevelInfo, File: path})
+	log := reg.Logger("test")
+	outputs := reg.outputs
+
+	log.Info("scooby")
+	if err := reg.SetStandardOutput("stderr", true); err != nil {
+		t.Fatal(err)
+	}
+	log.Info("doo")
+	if err := reg.SetStandardOutput("stderr", false); err != nil {
+		t.Fatal(err)
+	}
+	log.Info("velma")
+	w.Close()
+
+	buf := &bytes.Buffer{}
+	if _, err := io.Copy(buf, r); err != nil {
+		t.Fatal(err)
+	}
+	if got := buf.String(); strings.Contains(got, "scooby") || !strings.Contains(got, "doo") || strings.Contains(got, "velma") {
+		t.Errorf("got %q on stderr, want only the entry logged while enabled", got)
+	}
+	if reg.outputs != outputs {
+		t.Error("toggling stderr reopened the outputs")
+	}
+	if b, _ := os.ReadFile(path); strings.Count(string(b), "\n") != 3 {
+		t.Errorf("got %q in the file, want all entries", b)
+	}
+	if cfg := reg.GetConfig(); cfg.Stderr {
+		t.Error("got stderr enabled in the config, want it disabled")
+	}
+
+	if err := reg.SetStandardOutput("stdin", true); err == nil {
+		t.Error("got no error toggling an unknown output")
+	}
+	reg.SetPrimaryCore(newCore(PlaintextOutput, w, LevelInfo))
+	if err := reg.SetStandardOutput("stderr", true); err == nil {
+		t.Error("got no error toggling stderr with a custom primary core")
+	}
+}
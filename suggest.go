@@ -0,0 +1,116 @@
+package log
+
+import (
+	"fmt"
+	"os"
+	"sort"
+	"strings"
+	"sync/atomic"
+)
+
+// envSubsystemLevels holds the subsystem levels of GOLOG_LOG_LEVEL until
+// they are checked by checkEnvSubsystems.
+var envSubsystemLevels atomic.Pointer[map[string]LogLevel]
+
+// checkEnvSubsystems warns about the subsystems of GOLOG_LOG_LEVEL without a
+// logger, the first time the application calls SetupLogging or
+// SetupLoggingWithError. By then its packages, which create most loggers,
+// are initialized, and processes that never configure logging themselves,
+// like tests, aren't bothered.
+func checkEnvSubsystems() {
+	if levels := envSubsystemLevels.Swap(nil); levels != nil {
+		defaultRegistry.warnUnknownSubsystems(*levels)
+	}
+}
+
+// maxSuggestions is the number of similar names suggested for an unknown
+// subsystem.
+const maxSuggestions = 3
+
+// warnUnknownSubsystems prints a warning on stderr for each subsystem in
+// levels that never had a logger, suggesting the names of existing loggers it may
+// be a typo of, e.g. "bitwap" for "bitswap".
+func (r *Registry) warnUnknownSubsystems(levels map[string]LogLevel) {
+	for _, warning := range r.unknownSubsystems(levels) {
+		fmt.Fprintln(os.Stderr, warning)
+	}
+}
+
+func (r *Registry) unknownSubsystems(levels map[string]LogLevel) []string {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	var unknown []string
+	for name := range levels {
+		_, created := r.loggers[name]
+		if _, released := r.released[name]; !created && !released {
+			unknown = append(unknown, name)
+		}
+	}
+	sort.Strings(unknown)
+
+	var warnings []string
+	for _, name := range unknown {
+		warning := fmt.Sprintf("no logger was created for subsystem %q set in %s", name, envName(envLogging))
+		if similar := r.similarSubsystems(name); len(similar) > 0 {
+			warning += fmt.Sprintf(", did you mean %s?", strings.Join(similar, " or "))
+		}
+		warnings = append(warnings, warning)
+	}
+	return warnings
+}
+
+// similarSubsystems returns the quoted names of the closest loggers to
+// name, at most maxSuggestions of them. r.mu must be held.
+func (r *Registry) similarSubsystems(name string) []string {
+	// Allow a typo every four characters or so.
+	maxDistance := len(name)/4 + 1
+
+	type candidate struct {
+		name     string
+		distance int
+	}
+	var candidates []candidate
+	for other := range r.loggers {
+		if d := editDistance(name, other); d <= maxDistance {
+			candidates = append(candidates, candidate{other, d})
+		}
+	}
+	sort.Slice(candidates, func(i, j int) bool {
+		if candidates[i].distance != candidates[j].distance {
+			return candidates[i].distance < candidates[j].distance
+		}
+		return candidates[i].name < candidates[j].name
+	})
+
+	var similar []string
+	for i, c := range candidates {
+		if i == maxSuggestions {
+			break
+		}
+		similar = append(similar, fmt.Sprintf("%q", c.name))
+	}
+	return similar
+}
+
+// editDistance returns the Levenshtein distance between a and b, compared
+// byte by byte.
+func editDistance(a, b string) int {
+	prev := make([]int, len(b)+1)
+	cur := make([]int, len(b)+1)
+	for j := range prev {
+		prev[j] = j
+	}
+	for i := 1; i <= len(a); i++ {
+		cur[0] = i
+		for j := 1; j <= len(b); j++ {
+			cost := 1
+			if a[i-1] == b[j-1] {
+				cost = 0
+			}
+			cur[j] = min(prev[j]+1, cur[j-1]+1, prev[j-1]+cost)
+		}
+		prev, cur = cur, prev
+	}
+	return prev[len(b)]
+}
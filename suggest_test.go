@@ -0,0 +1,58 @@
+package log
+
+import (
+	"testing"
+)
+
+func TestUnknownSubsystems(t *testing.T) {
+	reg := NewRegistry(Config{Level: LevelError})
+	for _, name := range []string{"bitswap", "bitswap-server", "dht", "net/identify"} {
+		reg.Logger(name)
+	}
+
+	got := reg.unknownSubsystems(map[string]LogLevel{
+		"bitwap":       LevelDebug,
+		"dht":          LevelInfo,
+		"scooby":       LevelDebug,
+		"net/idnetify": LevelDebug,
+	})
+	want := []string{
+		`no logger was created for subsystem "bitwap" set in GOLOG_LOG_LEVEL, did you mean "bitswap"?`,
+		`no logger was created for subsystem "net/idnetify" set in GOLOG_LOG_LEVEL, did you mean "net/identify"?`,
+		`no logger was created for subsystem "scooby" set in GOLOG_LOG_LEVEL`,
+	}
+	if len(got) != len(want) {
+		t.Fatalf("got %q, want %q", got, want)
+	}
+	for i := range want {
+		if got[i] != want[i] {
+			t.Errorf("got %q, want %q", got[i], want[i])
+		}
+	}
+}
+
+func TestReleasedSubsystemsAreKnown(t *testing.T) {
+	reg := NewRegistry(Config{Level: LevelError, SubsystemLevels: map[string]LogLevel{"peer-1": LevelDebug}})
+	reg.Logger("peer-1")
+	reg.ReleaseLogger("peer-1")
+
+	if got := reg.unknownSubsystems(reg.config.SubsystemLevels); len(got) != 0 {
+		t.Errorf("got %q, want no warning for a released logger", got)
+	}
+}
+
+func TestEditDistance(t *testing.T) {
+	for _, tc := range []struct {
+		a, b string
+		want int
+	}{
+		{"", "", 0},
+		{"dht", "", 3},
+		{"bitwap", "bitswap", 1},
+		{"kitten", "sitting", 3},
+	} {
+		if got := editDistance(tc.a, tc.b); got != tc.want {
+			t.Errorf("editDistance(%q, %q) = %d, want %d", tc.a, tc.b, got, tc.want)
+		}
+	}
+}
@@ -0,0 +1,7 @@
+package log
+
+import "github.com/ipfs/go-log/v2/internal/testhooks"
+
+func init() {
+	testhooks.ReplacePrimaryCore = defaultRegistry.replacePrimaryCore
+}
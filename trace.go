@@ -0,0 +1,51 @@
+package log
+
+import (
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+)
+
+// sweetenFields turns key-value pairs, as accepted by Infow and friends, into
+// fields. Like the zap.SugaredLogger, it accepts zapcore.Fields in place of a
+// pair; invalid keys are kept under "ignored".
+func sweetenFields(kv []interface{}) []zapcore.Field {
+	fields := make([]zapcore.Field, 0, len(kv)/2)
+	for i := 0; i < len(kv); i++ {
+		if f, ok := kv[i].(zapcore.Field); ok {
+			fields = append(fields, f)
+			continue
+		}
+		key, ok := kv[i].(string)
+		if !ok || i == len(kv)-1 {
+			fields = append(fields, zap.Any("ignored", kv[i]))
+			continue
+		}
+		fields = append(fields, zap.Any(key, kv[i+1]))
+		i++
+	}
+	return fields
+}
+
+func lowercaseLevelEncoder(l zapcore.Level, enc zapcore.PrimitiveArrayEncoder) {
+	if l == traceLevel {
+		enc.AppendString("trace")
+		return
+	}
+	zapcore.LowercaseLevelEncoder(l, enc)
+}
+
+func capitalLevelEncoder(l zapcore.Level, enc zapcore.PrimitiveArrayEncoder) {
+	if l == traceLevel {
+		enc.AppendString("TRACE")
+		return
+	}
+	zapcore.CapitalLevelEncoder(l, enc)
+}
+
+func capitalColorLevelEncoder(l zapcore.Level, enc zapcore.PrimitiveArrayEncoder) {
+	if l == traceLevel {
+		enc.AppendString("\x1b[36mTRACE\x1b[0m") // cyan
+		return
+	}
+	zapcore.CapitalColorLevelEncoder(l, enc)
+}
@@ -0,0 +1,45 @@
+//go:build !golog_nodebug
+
+package log
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+
+	"go.uber.org/zap/zapcore"
+)
+
+func TestTraceLevel(t *testing.T) {
+	lvl, err := LevelFromString("TRACE")
+	if err != nil || lvl != LevelTrace {
+		t.Fatalf("got %v, %v, want the trace level", lvl, err)
+	}
+	if s := LevelTrace.String(); s != "trace" {
+		t.Errorf("got %q, want trace", s)
+	}
+
+	reg := NewRegistry(Config{Level: LevelDebug})
+	var buf bytes.Buffer
+	reg.SetPrimaryCore(newCore(PlaintextOutput, zapcore.AddSync(&buf), LevelTrace))
+	log := reg.Logger("test")
+
+	log.Tracew("hidden")
+	log.Debug("scooby")
+	if err := reg.SetLogLevel("test", "trace"); err != nil {
+		t.Fatal(err)
+	}
+	log.Tracew("doo", "snacks", 2)
+	log.Tracef("velma %d", 3)
+
+	lines := strings.Split(strings.TrimSpace(buf.String()), "\n")
+	if len(lines) != 3 {
+		t.Fatalf("got %q, want 3 lines", lines)
+	}
+	if !strings.Contains(lines[1], "\tTRACE\t") || !strings.Contains(lines[1], "trace_test.go") || !strings.Contains(lines[1], `{"snacks": 2}`) {
+		t.Errorf("got %q", lines[1])
+	}
+	if m := reg.GetMetrics().Entries["test"]; m[LevelTrace] != 2 {
+		t.Errorf("got metrics %v, want 2 trace entries", m)
+	}
+}
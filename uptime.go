@@ -0,0 +1,19 @@
+package log
+
+import (
+	"time"
+
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+)
+
+// UptimeKey is the key of the uptime added to entries with Config.Uptime.
+const UptimeKey = "uptime_ms"
+
+// uptimeField returns the time elapsed since the start of the process, in
+// milliseconds, as a field. It is read from the monotonic clock when ent is
+// written rather than derived from ent.Time, which clock adjustments and
+// Config.Clock affect.
+func uptimeField(zapcore.Entry) zapcore.Field {
+	return zap.Int64(UptimeKey, time.Since(bootTime).Milliseconds())
+}
@@ -0,0 +1,28 @@
+package log
+
+import (
+	"bytes"
+	"encoding/json"
+	"testing"
+	"time"
+
+	"go.uber.org/zap/zapcore"
+)
+
+func TestUptime(t *testing.T) {
+	// The uptime doesn't depend on the clock timestamping the entries.
+	reg := NewRegistry(Config{Level: LevelInfo, Clock: fixedClock(time.Date(2010, 5, 23, 15, 14, 0, 0, time.UTC))})
+	var buf bytes.Buffer
+	reg.SetPrimaryCore(reg.newPrimaryCore(Config{Format: JSONOutput, Uptime: true}, zapcore.AddSync(&buf)))
+	reg.Logger("core").Info("scooby")
+
+	var entry struct {
+		Uptime *int64 `json:"uptime_ms"`
+	}
+	if err := json.Unmarshal(buf.Bytes(), &entry); err != nil {
+		t.Fatal(err)
+	}
+	if entry.Uptime == nil || *entry.Uptime < 0 || *entry.Uptime > time.Since(bootTime).Milliseconds() {
+		t.Errorf("got %q", buf.String())
+	}
+}
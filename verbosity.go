@@ -0,0 +1,92 @@
+package log
+
+import (
+	"go.uber.org/zap/zapcore"
+)
+
+// SetVerbosity changes the V(n) verbosity of a specific subsystem.
+// name=="*" changes the default verbosity and resets all subsystems to it.
+func SetVerbosity(name string, v int) {
+	defaultRegistry.SetVerbosity(name, v)
+}
+
+// SetVerbosity changes the verbosity of a subsystem of this registry. See
+// the package-level SetVerbosity.
+func (r *Registry) SetVerbosity(name string, v int) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if name == "*" {
+		r.defaultVerbosity = v
+		r.verbosity = make(map[string]int)
+		return
+	}
+	r.verbosity[name] = v
+}
+
+// Verbosity returns the current V(n) verbosity of the named subsystem.
+func (r *Registry) Verbosity(name string) int {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	if v, ok := r.verbosity[name]; ok {
+		return v
+	}
+	return r.defaultVerbosity
+}
+
+// Verbose logs at info level if enabled, and is a no-op otherwise. See
+// ZapEventLogger.V.
+type Verbose struct {
+	logger *ZapEventLogger // nil if disabled
+}
+
+// V returns a logger for glog-style verbose logging, enabled if n is at most
+// the verbosity of the subsystem as set by GOLOG_VERBOSITY, Config.Verbosity
+// or SetVerbosity:
+//
+//	logger.V(2).Infow("dialing", "peer", p)
+//	if v := logger.V(4); v.Enabled() {
+//		v.Info(expensiveDump())
+//	}
+//
+// This gives libraries more granularity than the debug and info levels.
+// Entries are logged at info level, so they are also subject to the
+// subsystem's level.
+func (logger *ZapEventLogger) V(n int) Verbose {
+	reg := logger.reg
+	if reg == nil {
+		reg = defaultRegistry
+	}
+	if n > reg.Verbosity(logger.system) {
+		return Verbose{}
+	}
+	return Verbose{logger: logger}
+}
+
+// Enabled reports whether entries logged through v are emitted.
+func (v Verbose) Enabled() bool {
+	return v.logger != nil && v.logger.Desugar().Core().Enabled(zapcore.InfoLevel)
+}
+
+// Info logs a message at info level if v is enabled.
+func (v Verbose) Info(args ...interface{}) {
+	if v.logger != nil {
+		v.logger.skipLogger.Info(args...)
+	}
+}
+
+// Infof logs a formatted message at info level if v is enabled.
+func (v Verbose) Infof(format string, args ...interface{}) {
+	if v.logger != nil {
+		v.logger.skipLogger.Infof(format, args...)
+	}
+}
+
+// Infow logs a message at info level with some additional context if v is
+// enabled.
+func (v Verbose) Infow(msg string, keysAndValues ...interface{}) {
+	if v.logger != nil {
+		v.logger.skipLogger.Infow(msg, keysAndValues...)
+	}
+}
@@ -0,0 +1,55 @@
+package log
+
+import (
+	"strings"
+	"testing"
+
+	"go.uber.org/zap/zapcore"
+	"go.uber.org/zap/zaptest/observer"
+)
+
+func TestVerbosity(t *testing.T) {
+	reg := NewRegistry(Config{
+		Level:              LevelInfo,
+		Verbosity:          1,
+		SubsystemVerbosity: map[string]int{"dht": 3},
+	})
+	core, logs := observer.New(zapcore.DebugLevel)
+	reg.SetPrimaryCore(core)
+	log := reg.Logger("test")
+	dht := reg.Logger("dht")
+
+	log.V(1).Info("scooby")
+	log.V(2).Info("hidden")
+	dht.V(3).Infow("doo", "snacks", 2)
+	if dht.V(4).Enabled() {
+		t.Error("V(4) enabled above the dht verbosity")
+	}
+
+	reg.SetVerbosity("*", 2)
+	log.V(2).Infof("velma %d", 3)
+	dht.V(3).Info("hidden")
+
+	var got []string
+	for _, ent := range logs.All() {
+		got = append(got, ent.Message)
+		if !strings.HasSuffix(ent.Caller.File, "verbosity_test.go") {
+			t.Errorf("got caller %v, want the test", ent.Caller)
+		}
+	}
+	if strings.Join(got, " ") != "scooby doo velma 3" {
+		t.Errorf("got %q", got)
+	}
+}
+
+func TestVerbosityFromEnv(t *testing.T) {
+	t.Setenv(envVerbosity, "2,dht=4,bitswap=lots")
+
+	cfg, err := ConfigFromEnv()
+	if err == nil {
+		t.Error("expected an error for the invalid verbosity")
+	}
+	if cfg.Verbosity != 2 || len(cfg.SubsystemVerbosity) != 1 || cfg.SubsystemVerbosity["dht"] != 4 {
+		t.Errorf("got verbosity %d, %v", cfg.Verbosity, cfg.SubsystemVerbosity)
+	}
+}
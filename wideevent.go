@@ -0,0 +1,67 @@
+package log
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+// WideEvent accumulates fields over the course of an operation and logs them
+// as a single summary entry when it is done. See BeginEvent.
+type WideEvent struct {
+	logger *ZapEventLogger
+	name   string
+	start  time.Time
+
+	mu     sync.Mutex // guards the fields below
+	fields []interface{}
+	done   bool
+}
+
+// BeginEvent starts a wide event named name, a single entry summarizing an
+// operation such as a request, in place of many scattered debug lines:
+//
+//	ev := logger.BeginEvent(ctx, "provide")
+//	ev.Add("cid", c)
+//	...
+//	ev.Done(err)
+//
+// The entry includes the fields attached to ctx with ContextWithFields.
+func (logger *ZapEventLogger) BeginEvent(ctx context.Context, name string) *WideEvent {
+	return &WideEvent{
+		logger: logger,
+		name:   name,
+		start:  time.Now(),
+		fields: append([]interface{}(nil), contextFields(ctx)...),
+	}
+}
+
+// Add adds key-value pairs (as accepted by Infow and friends) to the event.
+// It is safe for concurrent use.
+func (ev *WideEvent) Add(keysAndValues ...interface{}) {
+	ev.mu.Lock()
+	defer ev.mu.Unlock()
+
+	ev.fields = append(ev.fields, keysAndValues...)
+}
+
+// Done logs the event along with its duration and outcome: at info level
+// with outcome "success" if err is nil, at error level with outcome "error"
+// and the standard error fields otherwise. Only the first call logs.
+func (ev *WideEvent) Done(err error) {
+	ev.mu.Lock()
+	if ev.done {
+		ev.mu.Unlock()
+		return
+	}
+	ev.done = true
+	fields := append(ev.fields, "duration", time.Since(ev.start))
+	ev.mu.Unlock()
+
+	if err != nil {
+		fields = append(fields, "outcome", "error")
+		ev.logger.skipLogger.Errorw(ev.name, append(fields, errorFields(err)...)...)
+		return
+	}
+	ev.logger.skipLogger.Infow(ev.name, append(fields, "outcome", "success")...)
+}
@@ -0,0 +1,48 @@
+package log
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+
+	"go.uber.org/zap/zapcore"
+	"go.uber.org/zap/zaptest/observer"
+)
+
+func TestWideEvent(t *testing.T) {
+	reg := NewRegistry(Config{Level: LevelInfo})
+	core, logs := observer.New(zapcore.DebugLevel)
+	reg.SetPrimaryCore(core)
+	log := reg.Logger("test")
+
+	ctx := ContextWithFields(context.Background(), "peer", "scooby")
+	ev := log.BeginEvent(ctx, "provide")
+	ev.Add("snacks", 2)
+	ev.Done(nil)
+	ev.Done(errors.New("ignored"))
+
+	ev = log.BeginEvent(context.Background(), "fetch")
+	ev.Done(errors.New("doo"))
+
+	entries := logs.All()
+	if len(entries) != 2 {
+		t.Fatalf("got %d entries, want 2", len(entries))
+	}
+
+	fields := entries[0].ContextMap()
+	if entries[0].Message != "provide" || entries[0].Level != zapcore.InfoLevel || fields["peer"] != "scooby" || fields["snacks"] != int64(2) || fields["outcome"] != "success" {
+		t.Errorf("got %+v", entries[0])
+	}
+	if _, ok := fields["duration"]; !ok {
+		t.Errorf("got fields %v, want a duration", fields)
+	}
+	if !strings.HasSuffix(entries[0].Caller.File, "wideevent_test.go") {
+		t.Errorf("got caller %v, want the test", entries[0].Caller)
+	}
+
+	fields = entries[1].ContextMap()
+	if entries[1].Level != zapcore.ErrorLevel || fields["outcome"] != "error" || fields["error"] != "doo" {
+		t.Errorf("got %+v", entries[1])
+	}
+}
@@ -0,0 +1,77 @@
+package log
+
+import (
+	"bytes"
+	"io"
+	"sync"
+
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+)
+
+// WriterAt returns an io.WriteCloser that logs every line written to it as a
+// separate entry of the given subsystem at the given level. This is useful
+// for capturing the output of child processes, e.g. by assigning it to the
+// Stdout or Stderr of an exec.Cmd.
+//
+// Lines are split on '\n'; a trailing '\r' is dropped. Close logs any
+// unterminated partial line that is still buffered.
+func WriterAt(subsystem string, level LogLevel) io.WriteCloser {
+	return defaultRegistry.WriterAt(subsystem, level)
+}
+
+// WriterAt returns an io.WriteCloser that logs lines to the given subsystem
+// of this registry. See the package-level WriterAt.
+func (r *Registry) WriterAt(subsystem string, level LogLevel) io.WriteCloser {
+	return &lineWriter{
+		logger: r.getLogger(subsystem).Desugar().WithOptions(zap.WithCaller(false)),
+		level:  zapcore.Level(level),
+	}
+}
+
+type lineWriter struct {
+	mu     sync.Mutex // guards buf
+	buf    []byte
+	logger *zap.Logger
+	level  zapcore.Level
+}
+
+// Write implements the standard Write interface
+func (w *lineWriter) Write(p []byte) (int, error) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	w.buf = append(w.buf, p...)
+	for {
+		i := bytes.IndexByte(w.buf, '\n')
+		if i < 0 {
+			break
+		}
+		w.emit(w.buf[:i])
+		w.buf = w.buf[i+1:]
+	}
+	// Don't hold on to a large backing array once everything is consumed.
+	if len(w.buf) == 0 {
+		w.buf = nil
+	}
+	return len(p), nil
+}
+
+// Close flushes any buffered partial line.
+func (w *lineWriter) Close() error {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	if len(w.buf) > 0 {
+		w.emit(w.buf)
+		w.buf = nil
+	}
+	return nil
+}
+
+func (w *lineWriter) emit(line []byte) {
+	line = bytes.TrimSuffix(line, []byte{'\r'})
+	if ce := w.logger.Check(w.level, string(line)); ce != nil {
+		ce.Write()
+	}
+}
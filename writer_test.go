@@ -0,0 +1,52 @@
+package log
+
+import (
+	"bytes"
+	"io"
+	"strings"
+	"sync"
+	"testing"
+)
+
+func TestWriterAt(t *testing.T) {
+	SetupLogging(Config{
+		Level:  LevelDebug,
+		Format: PlaintextOutput,
+	})
+
+	var wg sync.WaitGroup
+	wg.Add(1)
+
+	r := NewPipeReader(PipeFormat(PlaintextOutput))
+
+	buf := &bytes.Buffer{}
+	go func() {
+		defer wg.Done()
+		if _, err := io.Copy(buf, r); err != nil && err != io.ErrClosedPipe {
+			t.Errorf("unexpected error: %v", err)
+		}
+	}()
+
+	w := WriterAt("test-writer", LevelInfo)
+	if _, err := io.WriteString(w, "scooby\r\nvel"); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := io.WriteString(w, "ma\nsha"); err != nil {
+		t.Fatal(err)
+	}
+	if err := w.Close(); err != nil {
+		t.Fatal(err)
+	}
+	r.Close()
+	wg.Wait()
+
+	lines := strings.Split(strings.TrimSpace(buf.String()), "\n")
+	if len(lines) != 3 {
+		t.Fatalf("got %d log lines, wanted 3: %q", len(lines), buf.String())
+	}
+	for i, want := range []string{"scooby", "velma", "sha"} {
+		if !strings.HasSuffix(lines[i], "\tINFO\ttest-writer\t"+want) {
+			t.Errorf("line %d: got %q, wanted it to end with %q", i, lines[i], want)
+		}
+	}
+}